@@ -0,0 +1,1109 @@
+package composer
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// InstalledPackage is a package of vendor/composer/installed.json
+type InstalledPackage struct {
+	Package
+	// InstallPath is the directory of the package relative to vendor/composer, e.g. "../monolog/monolog"
+	InstallPath string `json:"install-path,omitempty"`
+}
+
+// Installed is vendor/composer/installed.json, the packages Composer installed
+type Installed struct {
+	Packages        []InstalledPackage `json:"packages"`
+	Dev             bool               `json:"dev"`
+	DevPackageNames []string           `json:"dev-package-names"`
+}
+
+// ReadInstalled read vendor/composer/installed.json, the list of packages written by Composer 1 is supported
+func ReadInstalled(file string) (*Installed, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var installed Installed
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+		err = json.Unmarshal(data, &installed.Packages)
+	} else {
+		err = json.Unmarshal(data, &installed)
+	}
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %s: %v", file, err))
+	}
+	return &installed, nil
+}
+
+// AutoloadDumper write the files of vendor/composer the way composer dump-autoload of Composer 2.8 does:
+// autoload_namespaces.php, autoload_psr4.php, autoload_classmap.php, autoload_files.php, include_paths.php,
+// autoload_static.php, autoload_real.php, platform_check.php and vendor/autoload.php.
+// ClassLoader.php, InstalledVersions.php and installed.php are part of Composer and are not written,
+// neither is the autoloader of the deprecated target-dir of the root package
+type AutoloadDumper struct {
+	// Dir is the project directory
+	Dir  string
+	Root *Manifest
+	// Packages are the installed packages, in the order of installed.json
+	Packages []InstalledPackage
+	// DevPackageNames are the packages installed for development only
+	DevPackageNames []string
+	// NoDev skip the development packages and autoload-dev of the root package
+	NoDev bool
+	// Optimize add the classes of PSR-0 and PSR-4 directories to the class map, like optimize-autoloader
+	Optimize bool
+	// ClassmapAuthoritative only load classes of the class map, it implies Optimize
+	ClassmapAuthoritative bool
+	// Apcu cache the found and missing classes in APCu, with ApcuPrefix or a random prefix
+	Apcu       bool
+	ApcuPrefix string
+	// PrependAutoloader register the autoloader before the existing ones, Composer does by default
+	PrependAutoloader bool
+	// PlatformCheck is the platform-check option: true checks PHP and extensions, "php-only" checks PHP only
+	PlatformCheck BoolOrString
+	// Suffix of the autoloader class names, a random one when empty
+	Suffix string
+}
+
+var autoloadSuffixRegex = regexp.MustCompile(`ComposerAutoloaderInit([^:\s]+)::`)
+
+// NewAutoloadDumper read the composer.json, composer.lock and vendor/composer/installed.json of a project.
+// Options come from the config section with the Composer defaults, the suffix is autoloader-suffix,
+// or the suffix of the existing vendor/autoload.php, or the content-hash of the lock file
+func NewAutoloadDumper(dir string) (*AutoloadDumper, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, "composer.json"))
+	if err != nil {
+		return nil, err
+	}
+	var root Manifest
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	var raw struct {
+		Config map[string]json.RawMessage `json:"config"`
+	}
+	_ = json.Unmarshal(data, &raw)
+
+	d := &AutoloadDumper{
+		Dir:                   dir,
+		Root:                  &root,
+		Optimize:              bool(root.Config.OptimizeAutoloader),
+		ClassmapAuthoritative: bool(root.Config.ClassmapAuthoritative),
+		Apcu:                  bool(root.Config.ApcuAutoloader),
+		PrependAutoloader:     true,
+		PlatformCheck:         BoolOrString{String: "php-only"},
+		Suffix:                root.Config.AutoloaderSuffix,
+	}
+	if _, ok := raw.Config["prepend-autoloader"]; ok {
+		d.PrependAutoloader = bool(root.Config.PrependAutoloader)
+	}
+	if _, ok := raw.Config["platform-check"]; ok {
+		d.PlatformCheck = root.Config.PlatformCheck
+	}
+
+	vendor := d.vendorDir()
+	installed, err := ReadInstalled(filepath.Join(vendor, "composer", "installed.json"))
+	switch {
+	case err == nil:
+		d.Packages, d.DevPackageNames = installed.Packages, installed.DevPackageNames
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+
+	if d.Suffix == "" {
+		if content, err := ioutil.ReadFile(filepath.Join(vendor, "autoload.php")); err == nil {
+			if m := autoloadSuffixRegex.FindSubmatch(content); m != nil {
+				d.Suffix = string(m[1])
+			}
+		}
+	}
+	if d.Suffix == "" {
+		if content, err := ioutil.ReadFile(filepath.Join(dir, "composer.lock")); err == nil {
+			var lock Lock
+			if json.Unmarshal(content, &lock) == nil {
+				d.Suffix = lock.ContentHash
+			}
+		}
+	}
+	return d, nil
+}
+
+func (d *AutoloadDumper) vendorDir() string {
+	vendor := "vendor"
+	if d.Root != nil && d.Root.Config.VendorDir != "" {
+		vendor = d.Root.Config.VendorDir
+	}
+	if filepath.IsAbs(vendor) {
+		return vendor
+	}
+	return filepath.Join(d.Dir, vendor)
+}
+
+// autoloadPackage is a package with the directory it is installed in, empty for the root package
+type autoloadPackage struct {
+	name        string
+	manifest    *Manifest
+	installPath string
+	root        bool
+}
+
+// Dump write the autoload files, the ones which are not needed any more are removed
+func (d *AutoloadDumper) Dump() (*ClassMap, error) {
+	files, classMap, err := d.Generate()
+	if err != nil {
+		return nil, err
+	}
+	vendor := d.vendorDir()
+	for _, name := range []string{"composer/autoload_files.php", "composer/include_paths.php", "composer/platform_check.php"} {
+		if _, ok := files[name]; !ok {
+			if err := os.Remove(filepath.Join(vendor, filepath.FromSlash(name))); err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+		}
+	}
+	for name, content := range files {
+		file := filepath.Join(vendor, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return nil, err
+		}
+		if err := ioutil.WriteFile(file, content, 0644); err != nil {
+			return nil, err
+		}
+	}
+	return classMap, nil
+}
+
+// Generate return the content of the autoload files by path relative to the vendor directory,
+// e.g. "autoload.php" and "composer/autoload_real.php", and the class map with its warnings
+func (d *AutoloadDumper) Generate() (map[string][]byte, *ClassMap, error) {
+	p, err := d.paths()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	root, all, packages, devNames := d.packageMap(p)
+	reversed := autoloadOrder(root, packages)
+	sorted := make([]autoloadPackage, len(reversed))
+	for i, pkg := range reversed {
+		sorted[len(reversed)-1-i] = pkg
+	}
+
+	files := map[string][]byte{}
+	psr0, psr4, err := d.psrFiles(p, reversed, files)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	classMap, err := d.classMap(p, reversed)
+	if err != nil {
+		return nil, nil, err
+	}
+	classes := make([]string, 0, len(classMap.Classes))
+	for class := range classMap.Classes {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	classmapFile := p.header("autoload_classmap.php")
+	var classmapStatic []phpArrayEntry
+	for _, class := range classes {
+		code, value := p.code(classMap.Classes[class])
+		classmapFile += "    " + phpString(class) + " => " + code + ",\n"
+		classmapStatic = append(classmapStatic, phpArrayEntry{class, value})
+	}
+	files["composer/autoload_classmap.php"] = []byte(classmapFile + ");\n")
+
+	includeFiles := d.includeFiles(p, sorted)
+	var filesStatic []phpArrayEntry
+	if len(includeFiles) > 0 {
+		content := p.header("autoload_files.php")
+		for _, f := range includeFiles {
+			content += "    " + phpString(f.Identifier) + " => " + f.code + ",\n"
+			filesStatic = append(filesStatic, phpArrayEntry{f.Identifier, f.File})
+		}
+		files["composer/autoload_files.php"] = []byte(content + ");\n")
+	}
+
+	var includePaths []string
+	for _, pkg := range append([]autoloadPackage{root}, packages...) {
+		for _, include := range pkg.manifest.IncludePath {
+			include = strings.Trim(include, "/")
+			if pkg.installPath != "" {
+				include = pkg.installPath + "/" + include
+			}
+			includePaths = append(includePaths, include)
+		}
+	}
+	if len(includePaths) > 0 {
+		content := p.header("include_paths.php")
+		for _, include := range includePaths {
+			code, _ := p.code(include)
+			content += "    " + code + ",\n"
+		}
+		files["composer/include_paths.php"] = []byte(content + ");\n")
+	}
+
+	suffix := d.Suffix
+	if suffix == "" {
+		suffix = randomHex(16)
+	}
+	files["composer/autoload_static.php"] = []byte(p.staticFile(suffix, filesStatic, psr0, psr4, classmapStatic))
+
+	platformCheck := ""
+	if d.PlatformCheck.Bool || d.PlatformCheck.String != "" {
+		platformCheck = platformCheckFile(all, devNames, bool(d.PlatformCheck.Bool))
+		if platformCheck != "" {
+			files["composer/platform_check.php"] = []byte(platformCheck)
+		}
+	}
+	files["autoload.php"] = []byte(autoloadFile(p.shortestPathCode(p.vendor, p.target, true, false), suffix))
+	files["composer/autoload_real.php"] = []byte(d.autoloadRealFile(suffix, len(includePaths) > 0, len(includeFiles) > 0, platformCheck != ""))
+	return files, classMap, nil
+}
+
+// paths resolve the project and vendor directories
+func (d *AutoloadDumper) paths() (*autoloadPaths, error) {
+	if d.Root == nil {
+		return nil, errors.New("cannot dump the autoloader without a root package")
+	}
+	base, err := absolutePath(d.Dir)
+	if err != nil {
+		return nil, err
+	}
+	vendor, err := absolutePath(d.vendorDir())
+	if err != nil {
+		return nil, err
+	}
+	vendor = realPath(vendor)
+	return &autoloadPaths{base: realPath(base), vendor: vendor, target: vendor + "/composer"}, nil
+}
+
+// packageMap return the root package, all the packages with the root first, the packages to autoload
+// without the root, and the names of the development packages
+func (d *AutoloadDumper) packageMap(p *autoloadPaths) (root autoloadPackage, all, packages []autoloadPackage, devNames map[string]bool) {
+	rootName := strings.ToLower(d.Root.Name)
+	if rootName == "" {
+		rootName = RootPackageName
+	}
+	root = autoloadPackage{name: rootName, manifest: d.Root, root: true}
+	devNames = map[string]bool{}
+	for _, name := range d.DevPackageNames {
+		devNames[strings.ToLower(name)] = true
+	}
+	all = []autoloadPackage{root}
+	for i := range d.Packages {
+		pkg := &d.Packages[i]
+		if pkg.Type == "metapackage" {
+			continue
+		}
+		installPath := p.vendor + "/" + pkg.Name
+		if pkg.InstallPath != "" {
+			installPath = path.Clean(p.target + "/" + filepath.ToSlash(pkg.InstallPath))
+		}
+		ap := autoloadPackage{name: strings.ToLower(pkg.Name), manifest: &pkg.Manifest, installPath: installPath}
+		all = append(all, ap)
+		if !d.NoDev || !devNames[ap.name] {
+			packages = append(packages, ap)
+		}
+	}
+	return root, all, packages, devNames
+}
+
+// autoloadOrder return the packages in the order of their PSR and classmap rules: the root package first,
+// then the packages used by the fewest packages
+func autoloadOrder(root autoloadPackage, packages []autoloadPackage) []autoloadPackage {
+	sorted := sortAutoloadPackages(packages)
+	reversed := []autoloadPackage{root}
+	for i := len(sorted) - 1; i >= 0; i-- {
+		reversed = append(reversed, sorted[i])
+	}
+	return reversed
+}
+
+// AutoloadSources return the autoload sections of the root package and the installed packages in the order
+// of the autoloader, the root package first. Paths are relative to the install directory of each package
+func (d *AutoloadDumper) AutoloadSources() ([]AutoloadSource, error) {
+	p, err := d.paths()
+	if err != nil {
+		return nil, err
+	}
+	root, _, packages, _ := d.packageMap(p)
+	return d.sources(p, autoloadOrder(root, packages)), nil
+}
+
+func (d *AutoloadDumper) sources(p *autoloadPaths, packages []autoloadPackage) []AutoloadSource {
+	sources := make([]AutoloadSource, len(packages))
+	for i, pkg := range packages {
+		sources[i] = AutoloadSource{Name: pkg.name, Dir: pkg.installPath, Autoload: d.autoloadOf(pkg)}
+		if pkg.root {
+			sources[i].Name, sources[i].Dir = "", p.base
+		}
+	}
+	return sources
+}
+
+// autoloadOf return the autoload section of a package, with autoload-dev for the root package in dev mode
+func (d *AutoloadDumper) autoloadOf(pkg autoloadPackage) Autoload {
+	a := pkg.manifest.Autoload
+	if !pkg.root || d.NoDev {
+		return a
+	}
+	dev := pkg.manifest.AutoloadDev
+	mergePsr := func(a, b Psr) Psr {
+		if len(b) == 0 {
+			return a
+		}
+		merged := Psr{}
+		for _, m := range []Psr{a, b} {
+			for namespace, paths := range m {
+				merged[namespace] = append(append([]string{}, merged[namespace]...), paths...)
+			}
+		}
+		return merged
+	}
+	return Autoload{
+		Psr0:                mergePsr(a.Psr0, dev.Psr0),
+		Psr4:                mergePsr(a.Psr4, dev.Psr4),
+		Classmap:            append(append([]string{}, a.Classmap...), dev.Classmap...),
+		Files:               append(append([]string{}, a.Files...), dev.Files...),
+		ExcludeFromClassmap: append(append([]string{}, a.ExcludeFromClassmap...), dev.ExcludeFromClassmap...),
+	}
+}
+
+// relativePath is the path of an autoload rule relative to the project directory, or absolute
+func (pkg autoloadPackage) relativePath(p string) string {
+	if pkg.installPath == "" {
+		if p == "" {
+			return "."
+		}
+		return p
+	}
+	return pkg.installPath + "/" + p
+}
+
+// psrFiles add autoload_namespaces.php and autoload_psr4.php, and return their namespaces in reverse order
+// with the evaluated paths
+func (d *AutoloadDumper) psrFiles(p *autoloadPaths, packages []autoloadPackage, files map[string][]byte) (psr0, psr4 []phpArrayEntry, err error) {
+	for _, typ := range []string{"psr-0", "psr-4"} {
+		rules := map[string][]string{}
+		for _, pkg := range packages {
+			a := d.autoloadOf(pkg)
+			psr := a.Psr4
+			if typ == "psr-0" {
+				psr = a.Psr0
+			}
+			namespaces := make([]string, 0, len(psr))
+			for namespace := range psr {
+				namespaces = append(namespaces, namespace)
+			}
+			sort.Strings(namespaces)
+			for _, namespace := range namespaces {
+				for _, dir := range psr[namespace] {
+					rules[namespace] = append(rules[namespace], pkg.relativePath(dir))
+				}
+			}
+		}
+		namespaces := make([]string, 0, len(rules))
+		for namespace := range rules {
+			namespaces = append(namespaces, namespace)
+		}
+		sort.Sort(sort.Reverse(sort.StringSlice(namespaces)))
+
+		name := "autoload_namespaces.php"
+		if typ == "psr-4" {
+			name = "autoload_psr4.php"
+		}
+		content := p.header(name)
+		var entries []phpArrayEntry
+		for _, namespace := range namespaces {
+			if typ == "psr-4" && namespace != "" && !strings.HasSuffix(namespace, `\`) {
+				return nil, nil, errors.New(fmt.Sprintf("a non-empty PSR-4 prefix must end with a namespace separator, %s given", namespace))
+			}
+			var codes []string
+			var values []phpArrayEntry
+			for i, dir := range rules[namespace] {
+				code, value := p.code(dir)
+				codes = append(codes, code)
+				values = append(values, phpArrayEntry{i, value})
+			}
+			content += "    " + phpString(namespace) + " => array(" + strings.Join(codes, ", ") + "),\n"
+			entries = append(entries, phpArrayEntry{namespace, values})
+		}
+		files["composer/"+name] = []byte(content + ");\n")
+		if typ == "psr-0" {
+			psr0 = entries
+		} else {
+			psr4 = entries
+		}
+	}
+	return psr0, psr4, nil
+}
+
+// classMap scan the classmap rules, and the PSR-0 and PSR-4 directories when optimizing
+func (d *AutoloadDumper) classMap(p *autoloadPaths, packages []autoloadPackage) (*ClassMap, error) {
+	optimize := d.Optimize || d.ClassmapAuthoritative
+	sources := d.sources(p, packages)
+	if !optimize {
+		for i := range sources {
+			sources[i].Autoload.Psr0, sources[i].Autoload.Psr4 = nil, nil
+		}
+	}
+	m, err := ClassMapGenerator{BaseDir: p.base}.Generate(sources)
+	if err != nil {
+		return nil, err
+	}
+	var violations []PsrViolation
+	for _, v := range m.PsrViolations {
+		if !strings.HasPrefix(v.File, p.vendor+"/") {
+			violations = append(violations, v)
+		}
+	}
+	m.PsrViolations = violations
+	m.Classes[`Composer\InstalledVersions`] = p.vendor + "/composer/InstalledVersions.php"
+	return m, nil
+}
+
+// AutoloadFile is a file of an autoload files rule, included once by the autoloader
+type AutoloadFile struct {
+	// Identifier is the key of the file in autoload_files.php, see FileIdentifier
+	Identifier string `json:"identifier"`
+	Package    string `json:"package"`
+	// Path is the path of the rule, relative to the package
+	Path string `json:"path"`
+	// File is the slash separated absolute path the autoloader includes
+	File string `json:"file"`
+	code string
+}
+
+// IncludeFiles return the files of the autoload files rules in the order the autoloader includes them:
+// the packages used by the most packages first and the root package last
+func (d *AutoloadDumper) IncludeFiles() ([]AutoloadFile, error) {
+	p, err := d.paths()
+	if err != nil {
+		return nil, err
+	}
+	root, _, packages, _ := d.packageMap(p)
+	return d.includeFiles(p, append(sortAutoloadPackages(packages), root)), nil
+}
+
+func (d *AutoloadDumper) includeFiles(p *autoloadPaths, sorted []autoloadPackage) []AutoloadFile {
+	var files []AutoloadFile
+	index := map[string]int{}
+	for _, pkg := range sorted {
+		for _, path := range d.autoloadOf(pkg).Files {
+			f := AutoloadFile{Identifier: FileIdentifier(pkg.name, path), Package: pkg.name, Path: path}
+			f.code, f.File = p.code(pkg.relativePath(path))
+			// a file listed twice keeps its first position
+			if i, ok := index[f.Identifier]; ok {
+				files[i] = f
+				continue
+			}
+			index[f.Identifier] = len(files)
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// FileIdentifier is the key of a files autoload rule in autoload_files.php, Composer includes each key once
+func FileIdentifier(packageName, path string) string {
+	sum := md5.Sum([]byte(packageName + ":" + path))
+	return hex.EncodeToString(sum[:])
+}
+
+// sortAutoloadPackages sort packages like Composer PackageSorter: the packages required by the most packages
+// come first, then by natural order of their names
+func sortAutoloadPackages(packages []autoloadPackage) []autoloadPackage {
+	users := map[string][]string{}
+	for _, pkg := range packages {
+		for _, target := range sortedKeys(pkg.manifest.Require) {
+			target = strings.ToLower(target)
+			users[target] = append(users[target], pkg.name)
+		}
+	}
+	weights := packageWeights(packages, users)
+	sorted := append([]autoloadPackage{}, packages...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		wi, wj := weights[sorted[i].name], weights[sorted[j].name]
+		if wi != wj {
+			return wi < wj
+		}
+		return naturalCompare(strings.ToLower(sorted[i].name), strings.ToLower(sorted[j].name)) < 0
+	})
+	return sorted
+}
+
+// packageWeights compute the importance of packages, each package using a package lowers its weight by one
+// plus the weight of the user. Circular dependencies count as zero
+func packageWeights(packages []autoloadPackage, users map[string][]string) map[string]int {
+	computed := map[string]int{}
+	computing := map[string]bool{}
+	var importance func(name string) int
+	importance = func(name string) int {
+		if w, ok := computed[name]; ok {
+			return w
+		}
+		if computing[name] {
+			return 0
+		}
+		computing[name] = true
+		weight := 0
+		for _, user := range users[name] {
+			weight -= 1 - importance(user)
+		}
+		delete(computing, name)
+		computed[name] = weight
+		return weight
+	}
+	for _, pkg := range packages {
+		importance(pkg.name)
+	}
+	return computed
+}
+
+// autoloadPaths turn paths into PHP code relative to the vendor and project directories
+type autoloadPaths struct {
+	base   string
+	vendor string
+	// target is vendor/composer
+	target string
+}
+
+// header is the beginning of the autoload_*.php files returning an array
+func (p *autoloadPaths) header(name string) string {
+	appBaseDirCode := strings.Replace(p.shortestPathCode(p.vendor, p.base, true, false), "__DIR__", "$vendorDir", -1)
+	return "<?php\n\n// " + name + " @generated by Composer\n\n" +
+		"$vendorDir = " + p.shortestPathCode(p.target, p.vendor, true, false) + ";\n" +
+		"$baseDir = " + appBaseDirCode + ";\n\nreturn array(\n"
+}
+
+// code return the PHP code of a path using $vendorDir or $baseDir, and the path it evaluates to
+func (p *autoloadPaths) code(file string) (code, value string) {
+	if !strings.HasPrefix(file, "/") {
+		file = p.base + "/" + file
+	}
+	file = normalizePath(file)
+
+	prefix, dir := "", ""
+	if strings.HasPrefix(file+"/", p.vendor+"/") {
+		file = file[len(p.vendor):]
+		prefix, dir = "$vendorDir . ", p.vendor
+	} else {
+		file = normalizePath(shortestPath(p.base, file, true))
+		if !strings.HasPrefix(file, "/") {
+			file = "/" + file
+			prefix, dir = "$baseDir . ", p.base
+		}
+	}
+	if strings.Contains(file, ".phar") {
+		return "'phar://' . " + prefix + phpString(file), "phar://" + dir + file
+	}
+	return prefix + phpString(file), dir + file
+}
+
+// normalizePath is Composer Filesystem::normalizePath for slash separated paths: . and empty segments
+// are removed, .. removes the previous segment unless the path is relative and starts with ..
+func normalizePath(p string) string {
+	absolute := ""
+	if strings.HasPrefix(p, "/") {
+		absolute, p = "/", p[1:]
+	}
+	var parts []string
+	up := false
+	for _, chunk := range strings.Split(p, "/") {
+		switch {
+		case chunk == ".." && (absolute != "" || up):
+			if len(parts) > 0 {
+				parts = parts[:len(parts)-1]
+			}
+			up = !(len(parts) == 0 || parts[len(parts)-1] == "..")
+		case chunk != "." && chunk != "":
+			parts = append(parts, chunk)
+			up = chunk != ".."
+		}
+	}
+	return absolute + strings.Join(parts, "/")
+}
+
+// shortestPath is Composer Filesystem::findShortestPath between absolute paths
+func shortestPath(from, to string, directories bool) string {
+	from, to = normalizePath(from), normalizePath(to)
+	if directories {
+		from = strings.TrimRight(from, "/") + "/dummy_file"
+	}
+	if path.Dir(from) == path.Dir(to) {
+		return "./" + path.Base(to)
+	}
+	common := commonPath(from, to)
+	if !strings.HasPrefix(from, common) {
+		return to
+	}
+	common = strings.TrimRight(common, "/") + "/"
+	depth := strings.Count(from[len(common):], "/")
+	if common == "/" && depth > 1 {
+		return to
+	}
+	result := strings.Repeat("../", depth)
+	if len(to) > len(common) {
+		result += to[len(common):]
+	}
+	if result == "" {
+		return "./"
+	}
+	return result
+}
+
+// shortestPathCode is Composer Filesystem::findShortestPathCode, PHP code of the path to from the file or
+// directory from, with dirname() calls or with /.. segments for static code
+func (p *autoloadPaths) shortestPathCode(from, to string, directories, static bool) string {
+	from, to = normalizePath(from), normalizePath(to)
+	if from == to {
+		if directories {
+			return "__DIR__"
+		}
+		return "__FILE__"
+	}
+	common := commonPath(from, to)
+	if !strings.HasPrefix(from, common) || common == "/" || common == "." {
+		return phpString(to)
+	}
+	common = strings.TrimRight(common, "/") + "/"
+	if strings.HasPrefix(to, from+"/") {
+		return "__DIR__ . " + phpString(to[len(from):])
+	}
+	depth := strings.Count(from[len(common):], "/")
+	if directories {
+		depth++
+	}
+	code := strings.Repeat("dirname(", depth) + "__DIR__" + strings.Repeat(")", depth)
+	if static {
+		code = "__DIR__ . '" + strings.Repeat("/..", depth) + "'"
+	}
+	if len(to) > len(common) {
+		return code + "." + phpString("/"+to[len(common):])
+	}
+	return code
+}
+
+// commonPath return the longest parent directory of to which is also a parent of from
+func commonPath(from, to string) string {
+	common := to
+	for !strings.HasPrefix(from+"/", common+"/") && common != "/" && common != "." {
+		common = path.Dir(common)
+	}
+	return common
+}
+
+// phpArrayEntry is an entry of a PHP array, the key is a string or an int and the value a string, an int
+// or a []phpArrayEntry
+type phpArrayEntry struct {
+	key   interface{}
+	value interface{}
+}
+
+// phpString is PHP var_export of a string
+func phpString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// phpExport is PHP var_export of a value
+func phpExport(v interface{}, indent string) string {
+	switch t := v.(type) {
+	case string:
+		return phpString(t)
+	case int:
+		return strconv.Itoa(t)
+	case []phpArrayEntry:
+		var b strings.Builder
+		b.WriteString("array (\n")
+		for _, e := range t {
+			b.WriteString(indent + "  " + phpExport(e.key, "") + " => ")
+			if _, ok := e.value.([]phpArrayEntry); ok {
+				b.WriteString("\n" + indent + "  ")
+			}
+			b.WriteString(phpExport(e.value, indent+"  ") + ",\n")
+		}
+		b.WriteString(indent + ")")
+		return b.String()
+	}
+	return "NULL"
+}
+
+// strtr is PHP strtr with an array, the longest key matching at a position is replaced
+func strtr(s string, pairs map[string]string) string {
+	keys := make([]string, 0, len(pairs))
+	for k := range pairs {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return len(keys[i]) > len(keys[j]) })
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		replaced := false
+		for _, k := range keys {
+			if strings.HasPrefix(s[i:], k) {
+				b.WriteString(pairs[k])
+				i += len(k)
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			b.WriteByte(s[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+var lineIndentRegex = regexp.MustCompile(`(?m)^ *`)
+
+// staticFile render autoload_static.php, the ClassLoader properties set by the other autoload files
+func (p *autoloadPaths) staticFile(suffix string, files, psr0, psr4, classMap []phpArrayEntry) string {
+	var prefixLengthsPsr4, prefixDirsPsr4, fallbackDirsPsr4, prefixesPsr0, fallbackDirsPsr0 []phpArrayEntry
+	group := func(groups []phpArrayEntry, first string, e phpArrayEntry) []phpArrayEntry {
+		for i := range groups {
+			if groups[i].key == first {
+				groups[i].value = append(groups[i].value.([]phpArrayEntry), e)
+				return groups
+			}
+		}
+		return append(groups, phpArrayEntry{first, []phpArrayEntry{e}})
+	}
+	for _, e := range psr4 {
+		namespace := e.key.(string)
+		if namespace == "" {
+			fallbackDirsPsr4 = e.value.([]phpArrayEntry)
+			continue
+		}
+		prefixLengthsPsr4 = group(prefixLengthsPsr4, namespace[:1], phpArrayEntry{namespace, len(namespace)})
+		prefixDirsPsr4 = append(prefixDirsPsr4, e)
+	}
+	for _, e := range psr0 {
+		namespace := e.key.(string)
+		if namespace == "" {
+			fallbackDirsPsr0 = e.value.([]phpArrayEntry)
+			continue
+		}
+		prefixesPsr0 = group(prefixesPsr0, namespace[:1], e)
+	}
+
+	staticCode := func(dir string) string {
+		return " => " + p.shortestPathCode(p.target, dir, true, true) + " . '/"
+	}
+	absoluteCode := func(dir string) string {
+		code := phpString(strings.TrimRight(dir, "/") + "/")
+		return " => " + code[:len(code)-1]
+	}
+	replacements := map[string]string{
+		absoluteCode(p.vendor):             staticCode(p.vendor),
+		absoluteCode("phar://" + p.vendor): " => 'phar://' . " + staticCode(p.vendor)[4:],
+		absoluteCode(p.base):               staticCode(p.base),
+		absoluteCode("phar://" + p.base):   " => 'phar://' . " + staticCode(p.base)[4:],
+	}
+
+	content := "<?php\n\n// autoload_static.php @generated by Composer\n\nnamespace Composer\\Autoload;\n\n" +
+		"class ComposerStaticInit" + suffix + "\n{\n"
+	initializer := ""
+	for _, m := range []struct {
+		name  string
+		value []phpArrayEntry
+	}{
+		{"files", files},
+		{"prefixLengthsPsr4", prefixLengthsPsr4},
+		{"prefixDirsPsr4", prefixDirsPsr4},
+		{"fallbackDirsPsr4", fallbackDirsPsr4},
+		{"prefixesPsr0", prefixesPsr0},
+		{"fallbackDirsPsr0", fallbackDirsPsr0},
+		{"classMap", classMap},
+	} {
+		if len(m.value) == 0 {
+			continue
+		}
+		value := strtr(phpExport(m.value, ""), replacements)
+		value = strings.TrimLeft(lineIndentRegex.ReplaceAllStringFunc(value, func(s string) string { return "    " + s + s }), " \t\n\r\x00\x0B")
+		content += "    public static $" + m.name + " = " + value + ";\n\n"
+		if m.name != "files" {
+			initializer += "            $loader->" + m.name + " = ComposerStaticInit" + suffix + "::$" + m.name + ";\n"
+		}
+	}
+	return content + "    public static function getInitializer(ClassLoader $loader)\n    {\n" +
+		"        return \\Closure::bind(function () use ($loader) {\n" + initializer + "\n" +
+		"        }, null, ClassLoader::class);\n    }\n}\n"
+}
+
+func autoloadFile(vendorPathToTargetDirCode, suffix string) string {
+	if last := vendorPathToTargetDirCode[len(vendorPathToTargetDirCode)-1]; last == '\'' || last == '"' {
+		vendorPathToTargetDirCode = vendorPathToTargetDirCode[:len(vendorPathToTargetDirCode)-1] + "/autoload_real.php" + string(last)
+	} else {
+		vendorPathToTargetDirCode += " . '/autoload_real.php'"
+	}
+	return `<?php
+
+// autoload.php @generated by Composer
+
+if (PHP_VERSION_ID < 50600) {
+    if (!headers_sent()) {
+        header('HTTP/1.1 500 Internal Server Error');
+    }
+    $err = 'Composer 2.3.0 dropped support for autoloading on PHP <5.6 and you are running '.PHP_VERSION.', please upgrade PHP or use Composer 2.2 LTS via "composer self-update --2.2". Aborting.'.PHP_EOL;
+    if (!ini_get('display_errors')) {
+        if (PHP_SAPI === 'cli' || PHP_SAPI === 'phpdbg') {
+            fwrite(STDERR, $err);
+        } elseif (!headers_sent()) {
+            echo $err;
+        }
+    }
+    throw new RuntimeException($err);
+}
+
+require_once ` + vendorPathToTargetDirCode + `;
+
+return ComposerAutoloaderInit` + suffix + `::getLoader();
+`
+}
+
+func (d *AutoloadDumper) autoloadRealFile(suffix string, includePaths, includeFiles, platformCheck bool) string {
+	prepend := strconv.FormatBool(d.PrependAutoloader)
+	file := `<?php
+
+// autoload_real.php @generated by Composer
+
+class ComposerAutoloaderInit` + suffix + `
+{
+    private static $loader;
+
+    public static function loadClassLoader($class)
+    {
+        if ('Composer\Autoload\ClassLoader' === $class) {
+            require __DIR__ . '/ClassLoader.php';
+        }
+    }
+
+    /**
+     * @return \Composer\Autoload\ClassLoader
+     */
+    public static function getLoader()
+    {
+        if (null !== self::$loader) {
+            return self::$loader;
+        }
+
+`
+	if platformCheck {
+		file += "        require __DIR__ . '/platform_check.php';\n\n"
+	}
+	file += "        spl_autoload_register(array('ComposerAutoloaderInit" + suffix + "', 'loadClassLoader'), true, " + prepend + ");\n" +
+		"        self::$loader = $loader = new \\Composer\\Autoload\\ClassLoader(\\dirname(__DIR__));\n" +
+		"        spl_autoload_unregister(array('ComposerAutoloaderInit" + suffix + "', 'loadClassLoader'));\n\n"
+	if includePaths {
+		file += "        $includePaths = require __DIR__ . '/include_paths.php';\n" +
+			"        $includePaths[] = get_include_path();\n" +
+			"        set_include_path(implode(PATH_SEPARATOR, $includePaths));\n\n"
+	}
+	file += "        require __DIR__ . '/autoload_static.php';\n" +
+		"        call_user_func(\\Composer\\Autoload\\ComposerStaticInit" + suffix + "::getInitializer($loader));\n\n"
+	if d.ClassmapAuthoritative {
+		file += "        $loader->setClassMapAuthoritative(true);\n"
+	}
+	if d.Apcu {
+		prefix := d.ApcuPrefix
+		if prefix == "" {
+			prefix = randomHex(10)
+		}
+		file += "        $loader->setApcuPrefix(" + phpString(prefix) + ");\n"
+	}
+	if d.Root.Config.UseIncludePath {
+		file += "        $loader->setUseIncludePath(true);\n"
+	}
+	file += "        $loader->register(" + prepend + ");\n\n"
+	if includeFiles {
+		file += "        $filesToLoad = \\Composer\\Autoload\\ComposerStaticInit" + suffix + `::$files;
+        $requireFile = \Closure::bind(static function ($fileIdentifier, $file) {
+            if (empty($GLOBALS['__composer_autoload_files'][$fileIdentifier])) {
+                $GLOBALS['__composer_autoload_files'][$fileIdentifier] = true;
+
+                require $file;
+            }
+        }, null, null);
+        foreach ($filesToLoad as $fileIdentifier => $file) {
+            $requireFile($fileIdentifier, $file);
+        }
+
+`
+	}
+	return file + "        return $loader;\n    }\n}\n"
+}
+
+// platformCheckFile render platform_check.php from the requirements of the packages which are not dev packages:
+// the lowest PHP version, a 64-bit PHP, and the extensions when checkExtensions is set.
+// It is empty when there is nothing to check
+func platformCheckFile(packages []autoloadPackage, devNames map[string]bool, checkExtensions bool) string {
+	providers := map[string][]Constraint{}
+	for _, pkg := range packages {
+		for _, links := range []map[string]string{pkg.manifest.Replace, pkg.manifest.Provide} {
+			for target, constraint := range links {
+				if strings.HasPrefix(strings.ToLower(target), "ext-") {
+					providers[target[4:]] = append(providers[target[4:]], parseConstraintOrAll(constraint))
+				}
+			}
+		}
+	}
+
+	lowest := bound{version: "0.0.0.0-dev", inclusive: true}
+	php64bit := false
+	extensions := map[string]string{}
+	for _, pkg := range packages {
+		if devNames[pkg.name] {
+			continue
+		}
+	links:
+		for target, constraint := range pkg.manifest.Require {
+			if target == "php" || target == "php-64bit" {
+				if low := lowerBound(parseConstraintOrAll(constraint)); boundGreater(low, lowest) {
+					lowest = low
+				}
+			}
+			if target == "php-64bit" {
+				php64bit = true
+			}
+			if !checkExtensions || !strings.HasPrefix(strings.ToLower(target), "ext-") || len(target) == 4 {
+				continue
+			}
+			extension := target[4:]
+			for _, provided := range providers[extension] {
+				if Intersects(provided, parseConstraintOrAll(constraint)) {
+					continue links
+				}
+			}
+			if extension == "zend-opcache" {
+				extension = "zend opcache"
+			}
+			name := phpString(extension)
+			if extension == "pcntl" || extension == "readline" {
+				extensions[name] = "PHP_SAPI !== 'cli' || extension_loaded(" + name + ") || $missingExtensions[] = " + name + ";\n"
+			} else {
+				extensions[name] = "extension_loaded(" + name + ") || $missingExtensions[] = " + name + ";\n"
+			}
+		}
+	}
+
+	requiredPHP := ""
+	if lowest.version != "0.0.0.0-dev" {
+		operator := ">"
+		if lowest.inclusive {
+			operator = ">="
+		}
+		chunks := strings.Split(strings.Replace(lowest.version, "-", ".", -1), ".")
+		id := 0
+		for i, factor := range []int{10000, 100, 1} {
+			if i < len(chunks) {
+				n, _ := strconv.Atoi(chunks[i])
+				id += n * factor
+			}
+		}
+		if len(chunks) > 3 {
+			chunks = chunks[:3]
+		}
+		requiredPHP = "\nif (!(PHP_VERSION_ID " + operator + " " + strconv.Itoa(id) + ")) {\n" +
+			"    $issues[] = 'Your Composer dependencies require a PHP version \"" + operator + " " + strings.Join(chunks, ".") +
+			"\". You are running ' . PHP_VERSION . '.';\n}\n"
+	}
+	if php64bit {
+		requiredPHP += "\nif (PHP_INT_SIZE !== 8) {\n    $issues[] = 'Your Composer dependencies require a 64-bit build of PHP.';\n}\n"
+	}
+	requiredExtensions := ""
+	if len(extensions) > 0 {
+		names := make([]string, 0, len(extensions))
+		for name := range extensions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		requiredExtensions = "\n$missingExtensions = array();\n"
+		for _, name := range names {
+			requiredExtensions += extensions[name]
+		}
+		requiredExtensions += "\nif ($missingExtensions) {\n" +
+			"    $issues[] = 'Your Composer dependencies require the following PHP extensions to be installed: ' . implode(', ', $missingExtensions) . '.';\n}\n"
+	}
+	if requiredPHP == "" && requiredExtensions == "" {
+		return ""
+	}
+	return `<?php
+
+// platform_check.php @generated by Composer
+
+$issues = array();
+` + requiredPHP + requiredExtensions + `
+if ($issues) {
+    if (!headers_sent()) {
+        header('HTTP/1.1 500 Internal Server Error');
+    }
+    if (!ini_get('display_errors')) {
+        if (PHP_SAPI === 'cli' || PHP_SAPI === 'phpdbg') {
+            fwrite(STDERR, 'Composer detected issues in your platform:' . PHP_EOL.PHP_EOL . implode(PHP_EOL, $issues) . PHP_EOL.PHP_EOL);
+        } elseif (!headers_sent()) {
+            echo 'Composer detected issues in your platform:' . PHP_EOL.PHP_EOL . str_replace('You are running '.PHP_VERSION.'.', '', implode(PHP_EOL, $issues)) . PHP_EOL.PHP_EOL;
+        }
+    }
+    throw new \RuntimeException(
+        'Composer detected issues in your platform: ' . implode(' ', $issues)
+    );
+}
+`
+}
+
+// parseConstraintOrAll parse a constraint, one which cannot be parsed like self.version matches everything
+func parseConstraintOrAll(constraint string) Constraint {
+	c, err := ParseConstraints(constraint)
+	if err != nil {
+		return matchAll{}
+	}
+	return c
+}
+
+// lowerBound return the lowest version a constraint matches, 0.0.0.0-dev when it has no lower bound
+func lowerBound(c Constraint) bound {
+	zero := bound{version: "0.0.0.0-dev", inclusive: true}
+	ranges := c.intervals().ranges
+	if len(ranges) == 0 {
+		return zero
+	}
+	low := ranges[0].low
+	for _, r := range ranges[1:] {
+		if r.low.unbounded || !low.unbounded && boundGreater(low, r.low) {
+			low = r.low
+		}
+	}
+	if low.unbounded {
+		return zero
+	}
+	return low
+}
+
+// boundGreater reports whether the lower bound a is higher than b, an exclusive bound is higher than
+// the inclusive bound of the same version
+func boundGreater(a, b bound) bool {
+	if c := CompareVersions(a.version, b.version); c != 0 {
+		return c > 0
+	}
+	return a.inclusive != b.inclusive && b.inclusive
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
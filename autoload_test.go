@@ -0,0 +1,354 @@
+package composer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAutoloadDumper_Generate(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"composer.json": `{
+    "name": "app/app",
+    "require": {"php": ">=7.4", "ext-json": "*", "ext-pcntl": "*", "ext-mbstring": "*", "acme/lib": "^1.0"},
+    "autoload": {"psr-4": {"App\\": "src/"}, "files": ["helpers.php"]},
+    "autoload-dev": {"psr-0": {"Legacy_": "tests/"}},
+    "config": {"platform-check": true, "prepend-autoloader": false}
+}`,
+		"composer.lock":       `{"content-hash": "0123456789abcdef"}`,
+		"src/Foo.php":         "<?php\nnamespace App;\nclass Foo {}\n",
+		"vendor/autoload.php": "<?php\nreturn ComposerAutoloaderInitexisting::getLoader();\n",
+		"vendor/composer/installed.json": `{"packages": [
+    {"name": "acme/lib", "version": "1.0.0", "require": {"php": "^8.0.1"}, "provide": {"ext-mbstring": "*"},
+        "autoload": {"psr-4": {"Acme\\": "src"}, "classmap": ["lib/"], "files": ["boot.php"]}, "install-path": "../acme/lib"},
+    {"name": "acme/dev", "version": "1.0.0", "require": {"php": ">=9"}, "autoload": {"psr-4": {"Acme\\Dev\\": ""}}, "install-path": "../acme/dev"}
+], "dev": true, "dev-package-names": ["acme/dev"]}`,
+		"vendor/acme/lib/lib/Util.php": "<?php\nclass Acme_Util {}\n",
+	})
+
+	d, err := NewAutoloadDumper(dir)
+	if err != nil {
+		t.Fatalf("NewAutoloadDumper() error = %v", err)
+	}
+	if d.Suffix != "existing" || d.PrependAutoloader || !bool(d.PlatformCheck.Bool) || len(d.Packages) != 2 {
+		t.Fatalf("NewAutoloadDumper() = %+v", d)
+	}
+	files, classMap, err := d.Generate()
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if want := []string{`Acme_Util`, `Composer\InstalledVersions`}; len(classMap.Classes) != len(want) {
+		t.Errorf("Generate() classes = %v, want %v", classMap.Classes, want)
+	}
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{
+			file: "composer/autoload_psr4.php",
+			want: `<?php
+
+// autoload_psr4.php @generated by Composer
+
+$vendorDir = dirname(__DIR__);
+$baseDir = dirname($vendorDir);
+
+return array(
+    'App\\' => array($baseDir . '/src'),
+    'Acme\\Dev\\' => array($vendorDir . '/acme/dev'),
+    'Acme\\' => array($vendorDir . '/acme/lib/src'),
+);
+`,
+		},
+		{
+			file: "composer/autoload_files.php",
+			want: `<?php
+
+// autoload_files.php @generated by Composer
+
+$vendorDir = dirname(__DIR__);
+$baseDir = dirname($vendorDir);
+
+return array(
+    'a7a84b05701b32ae9ebfe9b7a7de0da2' => $vendorDir . '/acme/lib/boot.php',
+    '23c764eff359994945b1910e804ebb21' => $baseDir . '/helpers.php',
+);
+`,
+		},
+		{
+			file: "composer/autoload_static.php",
+			want: `<?php
+
+// autoload_static.php @generated by Composer
+
+namespace Composer\Autoload;
+
+class ComposerStaticInitexisting
+{
+    public static $files = array (
+        'a7a84b05701b32ae9ebfe9b7a7de0da2' => __DIR__ . '/..' . '/acme/lib/boot.php',
+        '23c764eff359994945b1910e804ebb21' => __DIR__ . '/../..' . '/helpers.php',
+    );
+
+    public static $prefixLengthsPsr4 = array (
+        'A' => 
+        array (
+            'App\\' => 4,
+            'Acme\\Dev\\' => 9,
+            'Acme\\' => 5,
+        ),
+    );
+
+    public static $prefixDirsPsr4 = array (
+        'App\\' => 
+        array (
+            0 => __DIR__ . '/../..' . '/src',
+        ),
+        'Acme\\Dev\\' => 
+        array (
+            0 => __DIR__ . '/..' . '/acme/dev',
+        ),
+        'Acme\\' => 
+        array (
+            0 => __DIR__ . '/..' . '/acme/lib/src',
+        ),
+    );
+
+    public static $prefixesPsr0 = array (
+        'L' => 
+        array (
+            'Legacy_' => 
+            array (
+                0 => __DIR__ . '/../..' . '/tests',
+            ),
+        ),
+    );
+
+    public static $classMap = array (
+        'Acme_Util' => __DIR__ . '/..' . '/acme/lib/lib/Util.php',
+        'Composer\\InstalledVersions' => __DIR__ . '/..' . '/composer/InstalledVersions.php',
+    );
+
+    public static function getInitializer(ClassLoader $loader)
+    {
+        return \Closure::bind(function () use ($loader) {
+            $loader->prefixLengthsPsr4 = ComposerStaticInitexisting::$prefixLengthsPsr4;
+            $loader->prefixDirsPsr4 = ComposerStaticInitexisting::$prefixDirsPsr4;
+            $loader->prefixesPsr0 = ComposerStaticInitexisting::$prefixesPsr0;
+            $loader->classMap = ComposerStaticInitexisting::$classMap;
+
+        }, null, ClassLoader::class);
+    }
+}
+`,
+		},
+		{
+			file: "composer/platform_check.php",
+			want: `<?php
+
+// platform_check.php @generated by Composer
+
+$issues = array();
+
+if (!(PHP_VERSION_ID >= 80001)) {
+    $issues[] = 'Your Composer dependencies require a PHP version ">= 8.0.1". You are running ' . PHP_VERSION . '.';
+}
+
+$missingExtensions = array();
+extension_loaded('json') || $missingExtensions[] = 'json';
+PHP_SAPI !== 'cli' || extension_loaded('pcntl') || $missingExtensions[] = 'pcntl';
+
+if ($missingExtensions) {
+    $issues[] = 'Your Composer dependencies require the following PHP extensions to be installed: ' . implode(', ', $missingExtensions) . '.';
+}
+
+if ($issues) {
+    if (!headers_sent()) {
+        header('HTTP/1.1 500 Internal Server Error');
+    }
+    if (!ini_get('display_errors')) {
+        if (PHP_SAPI === 'cli' || PHP_SAPI === 'phpdbg') {
+            fwrite(STDERR, 'Composer detected issues in your platform:' . PHP_EOL.PHP_EOL . implode(PHP_EOL, $issues) . PHP_EOL.PHP_EOL);
+        } elseif (!headers_sent()) {
+            echo 'Composer detected issues in your platform:' . PHP_EOL.PHP_EOL . str_replace('You are running '.PHP_VERSION.'.', '', implode(PHP_EOL, $issues)) . PHP_EOL.PHP_EOL;
+        }
+    }
+    throw new \RuntimeException(
+        'Composer detected issues in your platform: ' . implode(' ', $issues)
+    );
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			if got := string(files[tt.file]); got != tt.want {
+				t.Errorf("Generate() %s =\n%s\nwant\n%s", tt.file, got, tt.want)
+			}
+		})
+	}
+
+	real := string(files["composer/autoload_real.php"])
+	for _, want := range []string{
+		"class ComposerAutoloaderInitexisting\n",
+		"        require __DIR__ . '/platform_check.php';\n\n",
+		"spl_autoload_register(array('ComposerAutoloaderInitexisting', 'loadClassLoader'), true, false);\n",
+		"        $loader->register(false);\n\n        $filesToLoad = \\Composer\\Autoload\\ComposerStaticInitexisting::$files;\n",
+	} {
+		if !strings.Contains(real, want) {
+			t.Errorf("Generate() autoload_real.php does not contain %q:\n%s", want, real)
+		}
+	}
+	if want := "require_once __DIR__ . '/composer/autoload_real.php';\n\nreturn ComposerAutoloaderInitexisting::getLoader();\n"; !strings.HasSuffix(string(files["autoload.php"]), want) {
+		t.Errorf("Generate() autoload.php =\n%s", files["autoload.php"])
+	}
+}
+
+func TestAutoloadDumper_Dump(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"composer.json":                      `{"autoload": {"psr-4": {"App\\": "src/"}}, "config": {"platform-check": false}}`,
+		"src/Foo.php":                        "<?php\nnamespace App;\nclass Foo {}\n",
+		"vendor/composer/autoload_files.php": "<?php\n",
+		"vendor/composer/platform_check.php": "<?php\n",
+	})
+	d, err := NewAutoloadDumper(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Suffix = "test"
+	d.ClassmapAuthoritative = true
+	d.Apcu = true
+	d.ApcuPrefix = "prefix"
+	if _, err := d.Dump(); err != nil {
+		t.Fatalf("Dump() error = %v", err)
+	}
+
+	for _, name := range []string{"autoload_files.php", "platform_check.php"} {
+		if _, err := os.Stat(filepath.Join(dir, "vendor", "composer", name)); !os.IsNotExist(err) {
+			t.Errorf("Dump() kept %s", name)
+		}
+	}
+	content, err := ioutil.ReadFile(filepath.Join(dir, "vendor", "composer", "autoload_real.php"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "        call_user_func(\\Composer\\Autoload\\ComposerStaticInittest::getInitializer($loader));\n\n" +
+		"        $loader->setClassMapAuthoritative(true);\n" +
+		"        $loader->setApcuPrefix('prefix');\n" +
+		"        $loader->register(true);\n\n" +
+		"        return $loader;\n"
+	if !strings.Contains(string(content), want) {
+		t.Errorf("Dump() autoload_real.php =\n%s\nwant it to contain\n%s", content, want)
+	}
+	content, err = ioutil.ReadFile(filepath.Join(dir, "vendor", "composer", "autoload_classmap.php"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "    'App\\\\Foo' => $baseDir . '/src/Foo.php',\n"; !strings.Contains(string(content), want) {
+		t.Errorf("Dump() autoload_classmap.php =\n%s\nwant it to contain\n%s", content, want)
+	}
+}
+
+func TestShortestPath(t *testing.T) {
+	tests := []struct {
+		from, to    string
+		directories bool
+		want        string
+		wantCode    string
+	}{
+		{"/app/vendor/composer", "/app/vendor", true, "../", "dirname(__DIR__)"},
+		{"/app/vendor", "/app", true, "../", "dirname(__DIR__)"},
+		{"/app/vendor", "/app/vendor/composer", true, "./composer", "__DIR__ . '/composer'"},
+		{"/app/vendor", "/app/src/Foo.php", true, "../src/Foo.php", "dirname(__DIR__).'/src/Foo.php'"},
+		{"/app", "/app", true, "./", "__DIR__"},
+		{"/app/lib/vendor", "/app", true, "../../", "dirname(dirname(__DIR__))"},
+		{"/app/vendor/file.php", "/app/src/Foo.php", false, "../src/Foo.php", "dirname(__DIR__).'/src/Foo.php'"},
+		{"/app/vendor", "/other/vendor", true, "/other/vendor", "'/other/vendor'"},
+		{"/app", "/app/./src/../lib", true, "./lib", "__DIR__ . '/lib'"},
+	}
+	p := &autoloadPaths{}
+	for _, tt := range tests {
+		t.Run(tt.from+" "+tt.to, func(t *testing.T) {
+			if got := shortestPath(tt.from, tt.to, tt.directories); got != tt.want {
+				t.Errorf("shortestPath() = %s, want %s", got, tt.want)
+			}
+			if got := p.shortestPathCode(tt.from, tt.to, tt.directories, false); got != tt.wantCode {
+				t.Errorf("shortestPathCode() = %s, want %s", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestNormalizePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/app/./src//Foo.php", "/app/src/Foo.php"},
+		{"/app/vendor/../src/", "/app/src"},
+		{"../app/../src", "../src"},
+		{"../../src", "../../src"},
+		{"src/../../lib", "../lib"},
+		{"./", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := normalizePath(tt.path); got != tt.want {
+				t.Errorf("normalizePath() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAutoloadDumper_IncludeFiles(t *testing.T) {
+	files := func(paths ...string) Autoload { return Autoload{Files: paths} }
+	installed := func(name string, require map[string]string, autoload Autoload) InstalledPackage {
+		return InstalledPackage{Package: Package{Manifest: Manifest{Name: name, Require: require, Autoload: autoload}}}
+	}
+	d := &AutoloadDumper{
+		Dir:  "/app",
+		Root: &Manifest{Require: map[string]string{"c/top": "*"}, Autoload: files("bootstrap.php"), AutoloadDev: files("tests/helpers.php")},
+		Packages: []InstalledPackage{
+			installed("z/other", nil, files("z.php")),
+			installed("c/top", map[string]string{"b/mid": "*", "pkg/lib10": "*"}, files("top.php")),
+			installed("pkg/lib10", nil, files("lib.php")),
+			installed("pkg/lib9", nil, files("lib.php")),
+			installed("b/mid", map[string]string{"a/base": "*", "d/cycle": "*"}, files("mid.php")),
+			installed("d/cycle", map[string]string{"b/mid": "*"}, files("cycle.php")),
+			installed("a/base", nil, files("src/functions.php", "src/functions.php")),
+			installed("dev/tool", nil, files("tool.php")),
+		},
+		DevPackageNames: []string{"dev/tool"},
+		NoDev:           true,
+	}
+	got, err := d.IncludeFiles()
+	if err != nil {
+		t.Fatalf("IncludeFiles() error = %v", err)
+	}
+	var order []string
+	for _, f := range got {
+		order = append(order, f.Package+":"+f.Path)
+	}
+	want := []string{
+		"a/base:src/functions.php",
+		"b/mid:mid.php",
+		"d/cycle:cycle.php",
+		"pkg/lib10:lib.php",
+		"c/top:top.php",
+		"pkg/lib9:lib.php",
+		"z/other:z.php",
+		"__root__:bootstrap.php",
+	}
+	if strings.Join(order, "\n") != strings.Join(want, "\n") {
+		t.Errorf("IncludeFiles() = %v, want %v", order, want)
+	}
+	if first := got[0]; first.Identifier != "974ed1d7902fb723c772b2ea8e566cbb" || first.File != "/app/vendor/a/base/src/functions.php" {
+		t.Errorf("IncludeFiles() first = %+v", first)
+	}
+	if last := got[len(got)-1]; last.Identifier != "2a1181a15c0b875073a40ff3b11f1688" || last.File != "/app/bootstrap.php" {
+		t.Errorf("IncludeFiles() last = %+v", last)
+	}
+}
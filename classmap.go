@@ -0,0 +1,415 @@
+package composer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// classMapExtensions are the extensions of the files scanned in directories
+var classMapExtensions = map[string]bool{".php": true, ".inc": true, ".hh": true}
+
+// ambiguousFilterRegex match the files of tests and fixtures, which are not reported as ambiguous
+var ambiguousFilterRegex = regexp.MustCompile(`(?i)/(test|fixture|example|stub)s?/`)
+
+// AutoloadSource is the autoload section of a package and the directory its paths are relative to
+type AutoloadSource struct {
+	// Name of the package, empty for the root package
+	Name     string
+	Dir      string
+	Autoload Autoload
+}
+
+// PsrViolation is a class found in a PSR-0 or PSR-4 directory whose file does not follow the rule
+type PsrViolation struct {
+	Class string
+	File  string
+	// Type is psr-0 or psr-4
+	Type      string
+	Namespace string
+	// Dir is the directory of the rule
+	Dir string
+}
+
+// String render the violation like Composer, paths relative to base start with ./
+func (v PsrViolation) String() string {
+	return v.format("")
+}
+
+func (v PsrViolation) format(base string) string {
+	return fmt.Sprintf("Class %s located in %s does not comply with %s autoloading standard (rule: %s => %s). Skipping.",
+		v.Class, shortPath(v.File, base), v.Type, v.Namespace, shortPath(v.Dir, base))
+}
+
+// ClassMap is the class map Composer dumps with --optimize
+type ClassMap struct {
+	// Classes map fully qualified class names to the slash separated absolute path of their file
+	Classes map[string]string
+	// Ambiguous classes are declared in several files, they map to the files which are not used
+	Ambiguous map[string][]string
+	// PsrViolations are the classes skipped because their file does not follow the PSR rule which found it
+	PsrViolations []PsrViolation
+	// base directory of the paths in warnings
+	base string
+	// scans are the scans which found the classes of a file
+	scans map[string]classMapScan
+}
+
+// AmbiguousClasses return the ambiguous classes without the files of tests, fixtures, examples and stubs,
+// which Composer does not report. A class whose used file is one of them is not reported at all
+func (c *ClassMap) AmbiguousClasses() map[string][]string {
+	ambiguous := map[string][]string{}
+	for class, files := range c.Ambiguous {
+		if ambiguousFilterRegex.MatchString(c.Classes[class]) {
+			continue
+		}
+		var reported []string
+		for _, f := range files {
+			if !ambiguousFilterRegex.MatchString(f) {
+				reported = append(reported, f)
+			}
+		}
+		if len(reported) > 0 {
+			ambiguous[class] = reported
+		}
+	}
+	return ambiguous
+}
+
+// Warnings return the warnings of composer dump-autoload --optimize: ambiguous classes then PSR violations
+func (c *ClassMap) Warnings() []string {
+	var warnings []string
+	ambiguous := c.AmbiguousClasses()
+	classes := make([]string, 0, len(ambiguous))
+	for class := range ambiguous {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	for _, class := range classes {
+		files := ambiguous[class]
+		found := "in both"
+		if len(files) > 1 {
+			found = fmt.Sprintf("%dx: in", len(files)+1)
+		}
+		warnings = append(warnings, fmt.Sprintf(`Warning: Ambiguous class resolution, "%s" was found %s "%s" and "%s", the first will be used.`,
+			class, found, c.Classes[class], strings.Join(files, `", "`)))
+	}
+	for _, v := range c.PsrViolations {
+		warnings = append(warnings, v.format(c.base))
+	}
+	return warnings
+}
+
+// shortPath replace the base directory at the start of a path by .
+func shortPath(path, base string) string {
+	if base != "" && (path == base || strings.HasPrefix(path, base+"/")) {
+		return "." + path[len(base):]
+	}
+	return path
+}
+
+// ClassMapGenerator build the class map of autoload sections the way composer dump-autoload --optimize does:
+// classmap paths are scanned first, then PSR-4 and PSR-0 directories by namespace in reverse order.
+// A class keeps the first file declaring it, files are parsed in parallel
+type ClassMapGenerator struct {
+	// BaseDir is the project directory, warnings show paths relative to it. The working directory by default
+	BaseDir string
+	// Workers is the number of files parsed in parallel, the number of CPUs by default
+	Workers int
+}
+
+// GenerateClassMap build the class map of the autoload section of a package installed in dir
+func GenerateClassMap(dir string, autoload Autoload) (*ClassMap, error) {
+	return ClassMapGenerator{BaseDir: dir}.Generate([]AutoloadSource{{Dir: dir, Autoload: autoload}})
+}
+
+// classMapScan is a file to scan for a rule, type and namespace are empty for classmap rules
+type classMapScan struct {
+	file      string
+	realPath  string
+	typ       string
+	namespace string
+	dir       string
+}
+
+// Generate build the class map of autoload sections, e.g. the root package and the installed packages
+func (g ClassMapGenerator) Generate(sources []AutoloadSource) (*ClassMap, error) {
+	base := g.BaseDir
+	if base == "" {
+		base = "."
+	}
+	base, err := absolutePath(base)
+	if err != nil {
+		return nil, err
+	}
+
+	exclude, err := NewExcludeMatcher(sources)
+	if err != nil {
+		return nil, err
+	}
+
+	scans, err := classMapScans(sources)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	seen := map[string]bool{}
+	for i, s := range scans {
+		if exclude.match(s) {
+			scans[i].file = ""
+			continue
+		}
+		if !seen[s.file] {
+			seen[s.file] = true
+			files = append(files, s.file)
+		}
+	}
+	classes, err := g.findClasses(files)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &ClassMap{Classes: map[string]string{}, Ambiguous: map[string][]string{}, base: base, scans: map[string]classMapScan{}}
+	scanned := map[string]bool{}
+	for _, s := range scans {
+		if s.file == "" || scanned[s.realPath] {
+			continue
+		}
+		found := classes[s.file]
+		if s.typ != "" {
+			var violations []PsrViolation
+			found, violations = filterByNamespace(found, s)
+			m.PsrViolations = append(m.PsrViolations, violations...)
+			if len(found) > 0 {
+				scanned[s.realPath] = true
+			}
+		} else {
+			scanned[s.realPath] = true
+		}
+		if _, ok := m.scans[s.file]; !ok && len(found) > 0 {
+			m.scans[s.file] = s
+		}
+		for _, class := range found {
+			if existing, ok := m.Classes[class]; !ok {
+				m.Classes[class] = s.file
+			} else if existing != s.file {
+				m.Ambiguous[class] = append(m.Ambiguous[class], s.file)
+			}
+		}
+	}
+	return m, nil
+}
+
+// classMapScans list the files to scan in the order Composer scans them
+func classMapScans(sources []AutoloadSource) ([]classMapScan, error) {
+	var scans []classMapScan
+	for _, s := range sources {
+		for _, path := range s.Autoload.Classmap {
+			path, err := absolutePath(filepath.Join(s.Dir, filepath.FromSlash(path)))
+			if err != nil {
+				return nil, err
+			}
+			files, err := classMapFiles(path)
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range files {
+				scans = append(scans, classMapScan{file: f, realPath: realPath(f), dir: path})
+			}
+		}
+	}
+
+	type psrGroup struct {
+		typ  string
+		dirs []string
+	}
+	groups := map[string][]psrGroup{}
+	for _, typ := range []string{"psr-4", "psr-0"} {
+		for _, s := range sources {
+			rules := s.Autoload.Psr4
+			if typ == "psr-0" {
+				rules = s.Autoload.Psr0
+			}
+			for namespace, paths := range rules {
+				var dirs []string
+				for _, p := range paths {
+					dir, err := absolutePath(filepath.Join(s.Dir, filepath.FromSlash(p)))
+					if err != nil {
+						return nil, err
+					}
+					dirs = append(dirs, dir)
+				}
+				groups[namespace] = append(groups[namespace], psrGroup{typ: typ, dirs: dirs})
+			}
+		}
+	}
+	namespaces := make([]string, 0, len(groups))
+	for namespace := range groups {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(namespaces)))
+	for _, namespace := range namespaces {
+		for _, group := range groups[namespace] {
+			for _, dir := range group.dirs {
+				if info, err := os.Stat(filepath.FromSlash(dir)); err != nil || !info.IsDir() {
+					continue
+				}
+				files, err := classMapFiles(dir)
+				if err != nil {
+					return nil, err
+				}
+				for _, f := range files {
+					scans = append(scans, classMapScan{file: f, realPath: realPath(f), typ: group.typ, namespace: namespace, dir: dir})
+				}
+			}
+		}
+	}
+	return scans, nil
+}
+
+// classMapFiles return a file, or the PHP files of a directory and its subdirectories following symlinks.
+// Hidden files and directories are skipped like the Symfony Finder does
+func classMapFiles(path string) ([]string, error) {
+	info, err := os.Stat(filepath.FromSlash(path))
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("could not scan for classes inside %s which does not appear to be a file nor a folder", path))
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	var files []string
+	visited := map[string]bool{}
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		real := realPath(dir)
+		if visited[real] {
+			return nil
+		}
+		visited[real] = true
+		entries, err := os.ReadDir(filepath.FromSlash(dir))
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if strings.HasPrefix(e.Name(), ".") {
+				continue
+			}
+			p := dir + "/" + e.Name()
+			info, err := os.Stat(filepath.FromSlash(p))
+			if err != nil {
+				continue
+			}
+			if info.IsDir() {
+				if err := walk(p); err != nil {
+					return err
+				}
+			} else if classMapExtensions[filepath.Ext(e.Name())] {
+				files = append(files, p)
+			}
+		}
+		return nil
+	}
+	return files, walk(path)
+}
+
+// findClasses parse files in parallel
+func (g ClassMapGenerator) findClasses(files []string) (map[string][]string, error) {
+	workers := g.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	results := make([][]string, len(files))
+	errs := make([]error, len(files))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i], errs[i] = FindClassesInFile(filepath.FromSlash(files[i]))
+			}
+		}()
+	}
+	for i := range files {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	classes := make(map[string][]string, len(files))
+	for i, f := range files {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		classes[f] = results[i]
+	}
+	return classes, nil
+}
+
+// filterByNamespace keep the classes whose path follows the PSR rule of the scan. The classes outside of the
+// namespace of the rule are skipped, the rejected classes are violations only when the file has no valid class,
+// otherwise they are skipped silently
+func filterByNamespace(classes []string, s classMapScan) ([]string, []PsrViolation) {
+	subPath := strings.TrimPrefix(s.file, s.dir+"/")
+	if dot := strings.LastIndex(subPath, "."); dot != -1 {
+		subPath = subPath[:dot]
+	}
+
+	var valid, rejected []string
+	for _, class := range classes {
+		if !strings.HasPrefix(class, s.namespace) {
+			continue
+		}
+		var classPath string
+		if s.typ == "psr-0" {
+			namespace, name := "", class
+			if sep := strings.LastIndex(class, `\`); sep != -1 {
+				namespace, name = class[:sep+1], class[sep+1:]
+			}
+			classPath = strings.Replace(namespace, `\`, "/", -1) + strings.Replace(name, "_", "/", -1)
+		} else {
+			classPath = strings.Replace(class[len(s.namespace):], `\`, "/", -1)
+		}
+		if classPath == subPath {
+			valid = append(valid, class)
+		} else {
+			rejected = append(rejected, class)
+		}
+	}
+	if len(valid) > 0 {
+		return valid, nil
+	}
+	violations := make([]PsrViolation, len(rejected))
+	for i, class := range rejected {
+		violations[i] = PsrViolation{Class: class, File: s.file, Type: s.typ, Namespace: s.namespace, Dir: s.dir}
+	}
+	return nil, violations
+}
+
+// absolutePath return the clean absolute slash separated form of a path
+func absolutePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(abs), nil
+}
+
+// realPath resolve the symlinks of a slash separated path, the path itself when it cannot be resolved
+func realPath(path string) string {
+	real, err := filepath.EvalSymlinks(filepath.FromSlash(path))
+	if err != nil {
+		return path
+	}
+	if abs, err := absolutePath(real); err == nil {
+		return abs
+	}
+	return path
+}
@@ -0,0 +1,126 @@
+package composer
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGenerateClassMap(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"src/Foo.php":               "<?php\nnamespace App;\nclass Foo {}\n",
+		"src/Sub/Bar.php":           "<?php\nnamespace App\\Sub;\ninterface Bar {}\n",
+		"src/Wrong.php":             "<?php\nnamespace Other;\nclass Wrong {}\n",
+		"src/Mixed.php":             "<?php\nnamespace App;\nclass Mixed {}\nclass Helper {}\n",
+		"src/Sub/Moved.php":         "<?php\nnamespace App;\nclass Moved {}\n",
+		"src/readme.txt":            "class NotPhp {}",
+		"src/.hidden/Hidden.php":    "<?php\nnamespace App\\Hidden;\nclass Hidden {}\n",
+		"src/Tests/FooTest.php":     "<?php\nnamespace App\\Tests;\nclass FooTest {}\n",
+		"lib/Legacy/Old_Thing.php":  "<?php\nclass Legacy_Old_Thing {}\n",
+		"lib/Legacy/Old/Thing.php":  "<?php\nclass Legacy_Old_Thing {}\n",
+		"lib/Vendor/Ns/Named.php":   "<?php\nnamespace Vendor\\Ns;\nclass Named {}\n",
+		"classes/functions.inc":     "<?php\nclass Functions {}\n",
+		"classes/Duplicate.php":     "<?php\nnamespace App;\nclass Foo {}\n",
+		"classes/excluded/Skip.php": "<?php\nclass Skip {}\n",
+		"single.php":                "<?php\nclass Single {}\n",
+	})
+	abs, err := absolutePath(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	autoload := Autoload{
+		Psr4:                Psr{`App\`: {"src/"}},
+		Psr0:                Psr{"Legacy_": {"lib/"}, `Vendor\`: {"lib/"}},
+		Classmap:            []string{"classes/", "single.php"},
+		ExcludeFromClassmap: []string{"src/Tests/", "classes/**/Skip.php"},
+	}
+
+	m, err := GenerateClassMap(dir, autoload)
+	if err != nil {
+		t.Fatalf("GenerateClassMap() error = %v", err)
+	}
+	want := map[string]string{
+		`App\Foo`:          abs + "/classes/Duplicate.php",
+		`App\Sub\Bar`:      abs + "/src/Sub/Bar.php",
+		`App\Mixed`:        abs + "/src/Mixed.php",
+		"Functions":        abs + "/classes/functions.inc",
+		"Single":           abs + "/single.php",
+		"Legacy_Old_Thing": abs + "/lib/Legacy/Old/Thing.php",
+		`Vendor\Ns\Named`:  abs + "/lib/Vendor/Ns/Named.php",
+	}
+	if !reflect.DeepEqual(m.Classes, want) {
+		t.Errorf("GenerateClassMap() classes = %v, want %v", m.Classes, want)
+	}
+	if want := map[string][]string{`App\Foo`: {abs + "/src/Foo.php"}}; !reflect.DeepEqual(m.Ambiguous, want) {
+		t.Errorf("GenerateClassMap() ambiguous = %v, want %v", m.Ambiguous, want)
+	}
+
+	var violations []string
+	for _, v := range m.PsrViolations {
+		violations = append(violations, v.Class+" in "+strings.TrimPrefix(v.File, abs))
+	}
+	// classes outside of the namespace of a rule are skipped silently: Other\Wrong by App\ and Legacy_Old_Thing by Vendor\
+	wantViolations := []string{
+		"Legacy_Old_Thing in /lib/Legacy/Old_Thing.php",
+		`App\Moved in /src/Sub/Moved.php`,
+	}
+	if !reflect.DeepEqual(violations, wantViolations) {
+		t.Errorf("GenerateClassMap() violations = %v, want %v", violations, wantViolations)
+	}
+
+	warnings := m.Warnings()
+	wantWarnings := []string{
+		fmt.Sprintf(`Warning: Ambiguous class resolution, "App\Foo" was found in both "%s/classes/Duplicate.php" and "%s/src/Foo.php", the first will be used.`, abs, abs),
+		`Class Legacy_Old_Thing located in ./lib/Legacy/Old_Thing.php does not comply with psr-0 autoloading standard (rule: Legacy_ => ./lib). Skipping.`,
+		`Class App\Moved located in ./src/Sub/Moved.php does not comply with psr-4 autoloading standard (rule: App\ => ./src). Skipping.`,
+	}
+	if !reflect.DeepEqual(warnings, wantWarnings) {
+		t.Errorf("Warnings() = %q, want %q", warnings, wantWarnings)
+	}
+}
+
+func TestClassMap_AmbiguousClasses(t *testing.T) {
+	m := &ClassMap{Classes: map[string]string{
+		"Foo": "/app/src/Foo.php",
+		"Bar": "/app/src/Bar.php",
+		"Baz": "/app/tests/Baz.php",
+	}, Ambiguous: map[string][]string{
+		"Foo": {"/app/tests/Foo.php", "/app/lib/Foo.php"},
+		"Bar": {"/app/Fixtures/Bar.php"},
+		"Baz": {"/app/lib/Baz.php"},
+	}}
+	want := map[string][]string{"Foo": {"/app/lib/Foo.php"}}
+	if got := m.AmbiguousClasses(); !reflect.DeepEqual(got, want) {
+		t.Errorf("AmbiguousClasses() = %v, want %v", got, want)
+	}
+}
+
+func TestClassMapGenerator_Generate(t *testing.T) {
+	files := map[string]string{}
+	for i := 0; i < 200; i++ {
+		files[fmt.Sprintf("vendor/acme/lib/src/Class%d.php", i)] = fmt.Sprintf("<?php\nnamespace Acme;\nclass Class%d {}\n", i)
+	}
+	files["src/Class0.php"] = "<?php\nnamespace Acme;\nclass Class0 {}\n"
+	dir := writeFiles(t, files)
+
+	sources := []AutoloadSource{
+		{Dir: dir, Autoload: Autoload{Classmap: []string{"src"}}},
+		{Name: "acme/lib", Dir: filepath.Join(dir, "vendor", "acme", "lib"), Autoload: Autoload{Psr4: Psr{`Acme\`: {"src"}}}},
+	}
+	m, err := ClassMapGenerator{BaseDir: dir, Workers: 4}.Generate(sources)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if len(m.Classes) != 200 {
+		t.Errorf("Generate() found %d classes, want 200", len(m.Classes))
+	}
+	if !strings.HasSuffix(m.Classes[`Acme\Class0`], "/src/Class0.php") || strings.Contains(m.Classes[`Acme\Class0`], "vendor") {
+		t.Errorf("Generate() Acme\\Class0 = %s, want the root file", m.Classes[`Acme\Class0`])
+	}
+
+	if _, err := GenerateClassMap(dir, Autoload{Classmap: []string{"missing"}}); err == nil {
+		t.Error("GenerateClassMap() of a missing classmap path, want an error")
+	}
+}
@@ -0,0 +1,370 @@
+package composer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTtl is the cache-ttl Composer uses when the config does not set one, in seconds
+const DefaultCacheTtl = 15552000
+
+// ErrPackageNotFound is returned when a repository does not have a package or filters it out
+var ErrPackageNotFound = errors.New("package not found")
+
+// RepositoryClient fetches metadata from a repository of type "composer", it is safe for concurrent use
+type RepositoryClient struct {
+	Repository Repository
+	Config     Config
+	HTTPClient *http.Client
+
+	// Now returns the current time, used to expire cache entries
+	Now func() time.Time
+
+	baseURL *url.URL
+	mu      sync.Mutex
+	root    *RepositoryRoot
+	only    *regexp.Regexp
+	exclude *regexp.Regexp
+}
+
+// NewRepositoryClient create a client for a repository of type "composer"
+func NewRepositoryClient(repository Repository, config Config) (*RepositoryClient, error) {
+	if repository.Type != "composer" {
+		return nil, errors.New("unsupported repository type " + repository.Type)
+	}
+
+	raw := strings.TrimRight(repository.Url, "/")
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repository url %s: %w", repository.Url, err)
+	}
+	// A url pointing to packages.json directly is allowed
+	if strings.HasSuffix(u.Path, ".json") {
+		u.Path = path.Dir(u.Path)
+	}
+
+	c := &RepositoryClient{
+		Repository: repository,
+		Config:     config,
+		HTTPClient: http.DefaultClient,
+		Now:        time.Now,
+		baseURL:    u,
+	}
+	if len(repository.Only) > 0 {
+		c.only = packageNamesToRegexp(repository.Only)
+	}
+	if len(repository.Exclude) > 0 {
+		c.exclude = packageNamesToRegexp(repository.Exclude)
+	}
+	return c, nil
+}
+
+// Root fetch and cache packages.json of the repository, a failed fetch is retried on the next call
+func (c *RepositoryClient) Root(ctx context.Context) (*RepositoryRoot, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.root != nil {
+		return c.root, nil
+	}
+
+	body, err := c.fetch(ctx, c.resolve("packages.json"), "packages.json")
+	if err != nil {
+		return nil, err
+	}
+	var root RepositoryRoot
+	if err := json.Unmarshal(body, &root); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal packages.json of %s: %w", c.Repository.Url, err)
+	}
+	c.root = &root
+	return c.root, nil
+}
+
+// Filtered reports whether the only and exclude rules of the repository hide a package
+func (c *RepositoryClient) Filtered(name string) bool {
+	if c.only != nil && !c.only.MatchString(name) {
+		return true
+	}
+	return c.exclude != nil && c.exclude.MatchString(name)
+}
+
+// PackageMetadata fetch the p2 file of a package, dev selects the ~dev file with branches
+func (c *RepositoryClient) PackageMetadata(ctx context.Context, name string, dev bool) (*PackageMetadata, error) {
+	name = strings.ToLower(name)
+	if c.Filtered(name) {
+		return nil, ErrPackageNotFound
+	}
+
+	root, err := c.Root(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !root.lists(name) {
+		return nil, ErrPackageNotFound
+	}
+
+	if root.MetadataUrl == "" {
+		return root.inlineMetadata(name, dev)
+	}
+
+	cacheKey := "provider-" + strings.Replace(name, "/", "~", -1)
+	if dev {
+		cacheKey += "~dev"
+	}
+	body, err := c.fetch(ctx, c.resolve(MetadataUrl(root.MetadataUrl, name, dev)), cacheKey+".json")
+	if err != nil {
+		return nil, err
+	}
+
+	var pm PackageMetadata
+	if err := json.Unmarshal(body, &pm); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal metadata of %s: %w", name, err)
+	}
+	return &pm, nil
+}
+
+// Versions fetch all tagged and dev versions of a package
+func (c *RepositoryClient) Versions(ctx context.Context, name string) ([]Package, error) {
+	name = strings.ToLower(name)
+	var versions []Package
+	for _, dev := range []bool{false, true} {
+		pm, err := c.PackageMetadata(ctx, name, dev)
+		if errors.Is(err, ErrPackageNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, pm.Packages[name]...)
+	}
+	if len(versions) == 0 {
+		return nil, ErrPackageNotFound
+	}
+	return versions, nil
+}
+
+// lists reports whether a package can be served according to available-packages
+func (r *RepositoryRoot) lists(name string) bool {
+	if len(r.AvailablePackages) == 0 && len(r.AvailablePackagePatterns) == 0 {
+		return true
+	}
+	for _, p := range r.AvailablePackages {
+		if strings.ToLower(p) == name {
+			return true
+		}
+	}
+	return len(r.AvailablePackagePatterns) > 0 && packageNamesToRegexp(r.AvailablePackagePatterns).MatchString(name)
+}
+
+// inlineMetadata build p2 like metadata from packages listed in packages.json itself
+func (r *RepositoryRoot) inlineMetadata(name string, dev bool) (*PackageMetadata, error) {
+	var versions []Package
+	for n, vs := range r.Packages {
+		if strings.ToLower(n) != name {
+			continue
+		}
+		for _, v := range vs {
+			if isDevVersion(v.Version) == dev {
+				versions = append(versions, v)
+			}
+		}
+	}
+	if len(versions) == 0 {
+		return nil, ErrPackageNotFound
+	}
+	return &PackageMetadata{Packages: map[string][]Package{name: versions}}, nil
+}
+
+func (c *RepositoryClient) resolve(ref string) string {
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	base := *c.baseURL
+	base.Path = strings.TrimRight(base.Path, "/") + "/"
+	return base.ResolveReference(r).String()
+}
+
+// fetch download a document, sending If-Modified-Since when a cached copy exists.
+// A cached copy is used when the server answers 304 Not Modified or cannot be reached
+func (c *RepositoryClient) fetch(ctx context.Context, target string, cacheKey string) ([]byte, error) {
+	cached, lastModified := c.readCache(cacheKey)
+
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	if cached != nil && lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+	c.authenticate(req)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		if cached != nil {
+			return cached, nil
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		return cached, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrPackageNotFound
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, fmt.Errorf("authentication required for %s: %s", target, resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("cannot fetch %s: %s", target, resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.writeCache(cacheKey, body, resp.Header.Get("Last-Modified"))
+	return body, nil
+}
+
+// authenticate apply credentials of the config matching the host of a request
+func (c *RepositoryClient) authenticate(req *http.Request) {
+	if req.URL.User != nil {
+		password, _ := req.URL.User.Password()
+		req.SetBasicAuth(req.URL.User.Username(), password)
+		req.URL.User = nil
+		return
+	}
+
+	host := req.URL.Hostname()
+	if basic, ok := c.Config.HttpBasic[host]; ok {
+		req.SetBasicAuth(basic.Username, basic.Password)
+		return
+	}
+	if token, ok := c.Config.Bearer[host]; ok {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return
+	}
+	if token, ok := c.Config.GithubOauth[host]; ok {
+		req.Header.Set("Authorization", "token "+token)
+		return
+	}
+	if token, ok := c.Config.GitlabOauth[host]; ok {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return
+	}
+	if token, ok := c.Config.GitlabToken[host]; ok {
+		req.Header.Set("PRIVATE-TOKEN", token)
+	}
+}
+
+// cacheDir is the directory of this repository inside cache-repo-dir, empty when caching is disabled
+func (c *RepositoryClient) cacheDir() string {
+	dir := c.Config.CacheRepoDir
+	if dir == "" && c.Config.CacheDir != "" {
+		dir = filepath.Join(c.Config.CacheDir, "repo")
+	}
+	if dir == "" {
+		return ""
+	}
+	sanitized := *c.baseURL
+	sanitized.User = nil
+	return filepath.Join(dir, cacheDirName.ReplaceAllString(sanitized.String(), "-"))
+}
+
+var cacheDirName = regexp.MustCompile(`(?i)[^a-z0-9.]`)
+
+func (c *RepositoryClient) cacheTtl() time.Duration {
+	ttl := c.Config.CacheTtl
+	if ttl <= 0 {
+		ttl = DefaultCacheTtl
+	}
+	return time.Duration(ttl) * time.Second
+}
+
+// readCache return a cached document and its Last-Modified header, entries older than cache-ttl are ignored
+func (c *RepositoryClient) readCache(key string) ([]byte, string) {
+	dir := c.cacheDir()
+	if dir == "" {
+		return nil, ""
+	}
+	file := filepath.Join(dir, key)
+	info, err := os.Stat(file)
+	if err != nil || c.Now().Sub(info.ModTime()) > c.cacheTtl() {
+		return nil, ""
+	}
+	body, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, ""
+	}
+
+	var meta struct {
+		LastModified string `json:"last-modified"`
+	}
+	_ = json.Unmarshal(body, &meta)
+	return body, meta.LastModified
+}
+
+// writeCache store a document with its Last-Modified header embedded the way Composer does
+func (c *RepositoryClient) writeCache(key string, body []byte, lastModified string) {
+	dir := c.cacheDir()
+	if dir == "" || c.Config.CacheReadOnly {
+		return
+	}
+	if lastModified != "" {
+		var doc map[string]json.RawMessage
+		if err := json.Unmarshal(body, &doc); err == nil {
+			doc["last-modified"], _ = json.Marshal(lastModified)
+			if b, err := json.Marshal(doc); err == nil {
+				body = b
+			}
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return
+	}
+	tmp, err := ioutil.TempFile(dir, key+".tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(body)
+	tmp.Close()
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, key)); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// packageNamesToRegexp convert package names with * wildcards into a case insensitive regexp
+func packageNamesToRegexp(names []string) *regexp.Regexp {
+	patterns := make([]string, len(names))
+	for i, n := range names {
+		patterns[i] = strings.Replace(regexp.QuoteMeta(n), `\*`, ".*", -1)
+	}
+	return regexp.MustCompile(`(?i)^(?:` + strings.Join(patterns, "|") + `)$`)
+}
+
+// isDevVersion reports whether a version is a branch, those are served in ~dev files
+func isDevVersion(version string) bool {
+	v := strings.ToLower(version)
+	return strings.HasPrefix(v, "dev-") || strings.HasSuffix(v, "-dev")
+}
@@ -0,0 +1,182 @@
+package composer_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	composer "github.com/vova-tarasov/go-composer-json"
+	"github.com/vova-tarasov/go-composer-json/composertest"
+)
+
+func testPackage(name, version string) composer.Package {
+	return composer.Package{Manifest: composer.Manifest{Name: name, Version: version, Require: map[string]string{"php": ">=7.2"}}}
+}
+
+func TestRepositoryClient_Versions(t *testing.T) {
+	repo := composertest.NewRepository(
+		testPackage("monolog/monolog", "2.1.0"),
+		testPackage("monolog/monolog", "2.0.0"),
+		testPackage("monolog/monolog", "dev-main"),
+		testPackage("psr/log", "1.1.4"),
+	)
+	defer repo.Close()
+
+	tests := []struct {
+		name       string
+		repository composer.Repository
+		pkg        string
+		want       []string
+		wantErr    error
+	}{
+		{"tagged and dev", repo.Repository(), "monolog/monolog", []string{"2.1.0", "2.0.0", "dev-main"}, nil},
+		{"case insensitive", repo.Repository(), "Monolog/Monolog", []string{"2.1.0", "2.0.0", "dev-main"}, nil},
+		{"not found", repo.Repository(), "symfony/console", nil, composer.ErrPackageNotFound},
+		{"only", composer.Repository{Type: "composer", Url: repo.URL, Only: []string{"psr/*"}}, "monolog/monolog", nil, composer.ErrPackageNotFound},
+		{"only matching", composer.Repository{Type: "composer", Url: repo.URL, Only: []string{"psr/*"}}, "psr/log", []string{"1.1.4"}, nil},
+		{"exclude", composer.Repository{Type: "composer", Url: repo.URL, Exclude: []string{"monolog/monolog"}}, "monolog/monolog", nil, composer.ErrPackageNotFound},
+		{"packages.json url", composer.Repository{Type: "composer", Url: repo.URL + "/packages.json"}, "psr/log", []string{"1.1.4"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := composer.NewRepositoryClient(tt.repository, composer.Config{})
+			if err != nil {
+				t.Fatalf("NewRepositoryClient() error = %v", err)
+			}
+			versions, err := c.Versions(context.Background(), tt.pkg)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Versions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(versions) != len(tt.want) {
+				t.Fatalf("Versions() got %d versions, want %d", len(versions), len(tt.want))
+			}
+			for i, v := range versions {
+				if v.Version != tt.want[i] {
+					t.Errorf("Versions() got = %v, want %v", v.Version, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRepositoryClient_Cache(t *testing.T) {
+	repo := composertest.NewRepository(testPackage("psr/log", "1.1.3"))
+	defer repo.Close()
+	config := composer.Config{CacheRepoDir: t.TempDir(), CacheTtl: 3600}
+
+	fetch := func(now time.Time) []composer.Package {
+		c, err := composer.NewRepositoryClient(repo.Repository(), config)
+		if err != nil {
+			t.Fatalf("NewRepositoryClient() error = %v", err)
+		}
+		c.Now = func() time.Time { return now }
+		versions, err := c.Versions(context.Background(), "psr/log")
+		if err != nil {
+			t.Fatalf("Versions() error = %v", err)
+		}
+		return versions
+	}
+
+	if got := fetch(time.Now()); len(got) != 1 {
+		t.Fatalf("Versions() got %d versions, want 1", len(got))
+	}
+
+	// Not modified since the last request, the cached copy without the new version is used
+	repo.Add(testPackage("psr/log", "1.1.4"))
+	if got := fetch(time.Now()); len(got) != 1 {
+		t.Errorf("Versions() got %d versions from cache, want 1", len(got))
+	}
+	if got := repo.Requests("/p2/psr/log.json"); got != 2 {
+		t.Errorf("Requests() got = %d, want 2", got)
+	}
+
+	// Modified on the server, the new document replaces the cached one
+	repo.LastModified = repo.LastModified.Add(time.Hour)
+	if got := fetch(time.Now()); len(got) != 2 {
+		t.Errorf("Versions() got %d versions, want 2", len(got))
+	}
+
+	// Expired cache entries are not sent as conditional requests
+	repo.Add(testPackage("psr/log", "1.1.5"))
+	if got := fetch(time.Now().Add(2 * time.Hour)); len(got) != 3 {
+		t.Errorf("Versions() got %d versions after expiry, want 3", len(got))
+	}
+}
+
+func TestRepositoryClient_Concurrent(t *testing.T) {
+	repo := composertest.NewRepository(testPackage("psr/log", "1.1.4"), testPackage("psr/container", "2.0.2"))
+	defer repo.Close()
+	c, err := composer.NewRepositoryClient(repo.Repository(), composer.Config{})
+	if err != nil {
+		t.Fatalf("NewRepositoryClient() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			if _, err := c.Versions(context.Background(), name); err != nil {
+				t.Errorf("Versions() error = %v", err)
+			}
+		}([]string{"psr/log", "psr/container"}[i%2])
+	}
+	wg.Wait()
+	if got := repo.Requests("/packages.json"); got != 1 {
+		t.Errorf("Requests() got = %d, want 1", got)
+	}
+}
+
+func TestRepositoryClient_Authentication(t *testing.T) {
+	basic := composertest.NewRepository(testPackage("psr/log", "1.1.4"))
+	defer basic.Close()
+	basic.RequireBasicAuth("user", "secret")
+
+	bearer := composertest.NewRepository(testPackage("psr/log", "1.1.4"))
+	defer bearer.Close()
+	bearer.RequireBearer("token")
+
+	tests := []struct {
+		name    string
+		repo    *composertest.Repository
+		config  composer.Config
+		wantErr bool
+	}{
+		{"http-basic", basic, composer.Config{HttpBasic: composer.HttpBasic{"127.0.0.1": {Username: "user", Password: "secret"}}}, false},
+		{"wrong http-basic", basic, composer.Config{HttpBasic: composer.HttpBasic{"127.0.0.1": {Username: "user", Password: "wrong"}}}, true},
+		{"missing http-basic", basic, composer.Config{}, true},
+		{"bearer", bearer, composer.Config{Bearer: map[string]string{"127.0.0.1": "token"}}, false},
+		{"bearer of another host", bearer, composer.Config{Bearer: map[string]string{"example.com": "token"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := composer.NewRepositoryClient(tt.repo.Repository(), tt.config)
+			if err != nil {
+				t.Fatalf("NewRepositoryClient() error = %v", err)
+			}
+			if _, err := c.Versions(context.Background(), "psr/log"); (err != nil) != tt.wantErr {
+				t.Errorf("Versions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewRepositoryClient(t *testing.T) {
+	tests := []struct {
+		name       string
+		repository composer.Repository
+		wantErr    bool
+	}{
+		{"composer", composer.Repository{Type: "composer", Url: "https://repo.packagist.org"}, false},
+		{"vcs", composer.Repository{Type: "vcs", Url: "https://github.com/Seldaek/monolog"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := composer.NewRepositoryClient(tt.repository, composer.Config{}); (err != nil) != tt.wantErr {
+				t.Errorf("NewRepositoryClient() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
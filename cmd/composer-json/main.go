@@ -0,0 +1,515 @@
+// Command composer-json inspects and formats composer.json files without PHP.
+//
+// Usage:
+//
+//	composer-json [--json] validate [--strict] [--no-check-lock] [file]
+//	composer-json [--json] normalize [--dry-run] [file]
+//	composer-json [--json] show <path> [file]
+//	composer-json [--json] diff <old> <new>
+//	composer-json [--json] hash [--check] [file]
+//
+// The file defaults to composer.json. With --json results are printed as JSON.
+// The exit status is 0 on success, 1 when a check fails (invalid manifest, file not normalized,
+// files differ, outdated lock file) and 2 on usage or input errors.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	composer "github.com/vova-tarasov/go-composer-json"
+)
+
+const (
+	exitOK      = 0
+	exitFailed  = 1
+	exitUsage   = 2
+	defaultFile = "composer.json"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+type command struct {
+	stdout  io.Writer
+	stderr  io.Writer
+	jsonOut bool
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("composer-json", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	jsonOut := flags.Bool("json", false, "print machine-readable JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: composer-json [--json] validate|normalize|show|diff|hash [arguments]")
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	c := &command{stdout: stdout, stderr: stderr, jsonOut: *jsonOut}
+	rest := flags.Args()[1:]
+	switch flags.Arg(0) {
+	case "validate":
+		return c.validate(rest)
+	case "normalize":
+		return c.normalize(rest)
+	case "show":
+		return c.show(rest)
+	case "diff":
+		return c.diff(rest)
+	case "hash":
+		return c.hash(rest)
+	}
+	fmt.Fprintln(stderr, "unknown command "+flags.Arg(0))
+	flags.Usage()
+	return exitUsage
+}
+
+// fail print an input error and return the usage exit status
+func (c *command) fail(err error) int {
+	fmt.Fprintln(c.stderr, err)
+	return exitUsage
+}
+
+func (c *command) printJSON(v interface{}) {
+	e := json.NewEncoder(c.stdout)
+	e.SetEscapeHTML(false)
+	e.SetIndent("", "    ")
+	_ = e.Encode(v)
+}
+
+// parseFlags parse the flags of a command before and after its arguments, the flag package stops at the first
+// argument and "validate composer.json --strict" would ignore --strict. More than max arguments is an error
+func parseFlags(flags *flag.FlagSet, args []string, max int) error {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		// the arguments after -- are never flags
+		if n := len(args) - flags.NArg(); n > 0 && args[n-1] == "--" {
+			positional = append(positional, flags.Args()...)
+			break
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+	if len(positional) > max {
+		err := errors.New(fmt.Sprintf("unexpected arguments: %s", strings.Join(positional[max:], " ")))
+		fmt.Fprintln(flags.Output(), err)
+		return err
+	}
+	return flags.Parse(append([]string{"--"}, positional...))
+}
+
+func fileArg(flags *flag.FlagSet, index int) string {
+	if flags.NArg() > index {
+		return flags.Arg(index)
+	}
+	return defaultFile
+}
+
+func readManifest(file string) ([]byte, *composer.Manifest, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	var m composer.Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return data, nil, errors.New(file + ": " + err.Error())
+	}
+	return data, &m, nil
+}
+
+type validation struct {
+	File     string   `json:"file"`
+	Valid    bool     `json:"valid"`
+	Errors   []string `json:"errors"`
+	Warnings []string `json:"warnings"`
+}
+
+var packageNameRegex = regexp.MustCompile(`^[a-z0-9]([_.-]?[a-z0-9]+)*/[a-z0-9](([_.]?|-{0,2})[a-z0-9]+)*$`)
+
+func (c *command) validate(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	strict := flags.Bool("strict", false, "fail on warnings too")
+	noCheckLock := flags.Bool("no-check-lock", false, "do not check that composer.lock is up to date")
+	if err := parseFlags(flags, args, 1); err != nil {
+		return exitUsage
+	}
+	file := fileArg(flags, 0)
+	result := validation{File: file, Errors: []string{}, Warnings: []string{}}
+
+	data, m, err := readManifest(file)
+	switch {
+	case data == nil:
+		return c.fail(err)
+	case err != nil:
+		result.Errors = append(result.Errors, err.Error())
+	default:
+		result.Errors, result.Warnings = validateManifest(m)
+		if !*noCheckLock {
+			result.Errors = append(result.Errors, checkLock(file, data, false)...)
+		}
+	}
+	result.Valid = len(result.Errors) == 0 && (!*strict || len(result.Warnings) == 0)
+
+	if c.jsonOut {
+		c.printJSON(result)
+	} else {
+		for _, e := range result.Errors {
+			fmt.Fprintln(c.stdout, "error: "+e)
+		}
+		for _, w := range result.Warnings {
+			fmt.Fprintln(c.stdout, "warning: "+w)
+		}
+		if result.Valid {
+			fmt.Fprintln(c.stdout, file+" is valid")
+		} else {
+			fmt.Fprintln(c.stdout, file+" is invalid")
+		}
+	}
+	if !result.Valid {
+		return exitFailed
+	}
+	return exitOK
+}
+
+// validateManifest run the checks of composer validate which do not need the network
+func validateManifest(m *composer.Manifest) (errs []string, warnings []string) {
+	errs, warnings = []string{}, []string{}
+	if m.Name == "" {
+		warnings = append(warnings, "name: the property name is required to publish the package")
+	} else if !packageNameRegex.MatchString(m.Name) {
+		errs = append(errs, "name: "+m.Name+" does not match vendor/package in lowercase")
+	}
+	if m.MinimumStability != "" {
+		stability := strings.ToLower(m.MinimumStability)
+		if stability == "rc" {
+			stability = "RC"
+		}
+		if _, ok := composer.Stabilities[stability]; !ok {
+			errs = append(errs, "minimum-stability: "+m.MinimumStability+" is not a valid stability")
+		}
+	}
+
+	for _, section := range []struct {
+		name  string
+		links map[string]string
+	}{
+		{"require", m.Require},
+		{"require-dev", m.RequireDev},
+		{"conflict", m.Conflict},
+		{"provide", m.Provide},
+		{"replace", m.Replace},
+	} {
+		for _, name := range sortedKeys(section.links) {
+			constraint := section.links[name]
+			if strings.TrimSpace(constraint) == "self.version" {
+				continue
+			}
+			parsed, err := composer.ParseConstraints(constraint)
+			if err != nil {
+				errs = append(errs, section.name+"."+name+": invalid version constraint ("+constraint+")")
+				continue
+			}
+			if (section.name == "require" || section.name == "require-dev") && parsed.Matches("9999999.0.0.0") {
+				warnings = append(warnings, section.name+"."+name+": unbound version constraints ("+constraint+") should be avoided")
+			}
+		}
+	}
+	for _, name := range sortedKeys(m.RequireDev) {
+		if _, ok := m.Require[name]; ok {
+			warnings = append(warnings, "require-dev."+name+": the package is also required in require")
+		}
+	}
+	return errs, warnings
+}
+
+// checkLock compare the content-hash of the composer.lock next to the manifest, a missing lock is an error
+// only when requireLock is set
+func checkLock(file string, data []byte, requireLock bool) []string {
+	lockFile := strings.TrimSuffix(file, filepath.Ext(file)) + ".lock"
+	lockData, err := ioutil.ReadFile(lockFile)
+	switch {
+	case os.IsNotExist(err) && !requireLock:
+		return nil
+	case os.IsNotExist(err):
+		return []string{"the lock file " + lockFile + " does not exist"}
+	case err != nil:
+		return []string{err.Error()}
+	}
+	var lock composer.Lock
+	if err := json.Unmarshal(lockData, &lock); err != nil {
+		return []string{lockFile + ": " + err.Error()}
+	}
+	hash, err := composer.ContentHash(data)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if hash != lock.ContentHash {
+		return []string{"the lock file is not up to date with the latest changes in " + file}
+	}
+	return nil
+}
+
+func (c *command) normalize(args []string) int {
+	flags := flag.NewFlagSet("normalize", flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	dryRun := flags.Bool("dry-run", false, "only report whether the file is normalized")
+	if err := parseFlags(flags, args, 1); err != nil {
+		return exitUsage
+	}
+	file := fileArg(flags, 0)
+	data, _, err := readManifest(file)
+	if err != nil {
+		return c.fail(err)
+	}
+	normalized, err := composer.NormalizeJSON(data)
+	if err != nil {
+		return c.fail(err)
+	}
+
+	changed := string(normalized) != string(data)
+	if changed && !*dryRun {
+		if err := ioutil.WriteFile(file, normalized, 0644); err != nil {
+			return c.fail(err)
+		}
+	}
+	if c.jsonOut {
+		c.printJSON(map[string]interface{}{"file": file, "normalized": !changed, "written": changed && !*dryRun})
+	} else {
+		switch {
+		case !changed:
+			fmt.Fprintln(c.stdout, file+" is already normalized")
+		case *dryRun:
+			fmt.Fprintln(c.stdout, file+" is not normalized")
+		default:
+			fmt.Fprintln(c.stdout, file+" has been normalized")
+		}
+	}
+	if changed && *dryRun {
+		return exitFailed
+	}
+	return exitOK
+}
+
+func (c *command) show(args []string) int {
+	flags := flag.NewFlagSet("show", flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	if err := parseFlags(flags, args, 2); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(c.stderr, "usage: composer-json show <path> [file]")
+		return exitUsage
+	}
+	data, _, err := readManifest(fileArg(flags, 1))
+	if err != nil {
+		return c.fail(err)
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return c.fail(err)
+	}
+	value, err := lookup(doc, flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(c.stderr, err)
+		return exitFailed
+	}
+	if s, ok := value.(string); ok && !c.jsonOut {
+		fmt.Fprintln(c.stdout, s)
+		return exitOK
+	}
+	out, err := composer.EncodeJSON(value)
+	if err != nil {
+		return c.fail(err)
+	}
+	fmt.Fprint(c.stdout, string(out))
+	return exitOK
+}
+
+var pathSegmentRegex = regexp.MustCompile(`^(?:\.?([^.\[\]]+)|\[(\d+)\]|\["((?:[^"\\]|\\.)*)"\]|\['([^']*)'\])`)
+
+// lookup follow a JSON path like $.require.php, autoload.psr-4["App\\"] or authors[0].name
+func lookup(doc interface{}, path string) (interface{}, error) {
+	rest := strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	value := doc
+	for rest != "" {
+		m := pathSegmentRegex.FindStringSubmatch(rest)
+		if m == nil {
+			return nil, errors.New("invalid path " + path)
+		}
+		rest = rest[len(m[0]):]
+
+		key, index := m[1]+m[4], -1
+		switch {
+		case m[2] != "":
+			index, _ = strconv.Atoi(m[2])
+		case m[3] != "":
+			_ = json.Unmarshal([]byte(`"`+m[3]+`"`), &key)
+		}
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if index != -1 {
+				key = m[2]
+			}
+			next, ok := v[key]
+			if !ok {
+				return nil, errors.New(path + " not found")
+			}
+			value = next
+		case []interface{}:
+			if index == -1 {
+				index, _ = strconv.Atoi(key)
+				if strconv.Itoa(index) != key {
+					return nil, errors.New(path + " not found")
+				}
+			}
+			if index >= len(v) {
+				return nil, errors.New(path + " not found")
+			}
+			value = v[index]
+		default:
+			return nil, errors.New(path + " not found")
+		}
+	}
+	return value, nil
+}
+
+func (c *command) diff(args []string) int {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	if err := parseFlags(flags, args, 2); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() != 2 {
+		fmt.Fprintln(c.stderr, "usage: composer-json diff <old> <new>")
+		return exitUsage
+	}
+
+	var changes interface{ String() string }
+	var count int
+	if isLockFile(flags.Arg(0)) && isLockFile(flags.Arg(1)) {
+		var locks [2]composer.Lock
+		for i := range locks {
+			data, err := ioutil.ReadFile(flags.Arg(i))
+			if err != nil {
+				return c.fail(err)
+			}
+			if err := json.Unmarshal(data, &locks[i]); err != nil {
+				return c.fail(errors.New(flags.Arg(i) + ": " + err.Error()))
+			}
+		}
+		d := composer.DiffLocks(&locks[0], &locks[1])
+		changes, count = d, len(d)
+	} else {
+		_, a, err := readManifest(flags.Arg(0))
+		if err != nil {
+			return c.fail(err)
+		}
+		_, b, err := readManifest(flags.Arg(1))
+		if err != nil {
+			return c.fail(err)
+		}
+		d := composer.Diff(a, b)
+		changes, count = d, len(d)
+	}
+
+	if c.jsonOut {
+		if count == 0 {
+			fmt.Fprintln(c.stdout, "[]")
+		} else {
+			c.printJSON(changes)
+		}
+	} else {
+		fmt.Fprint(c.stdout, changes.String())
+	}
+	if count > 0 {
+		return exitFailed
+	}
+	return exitOK
+}
+
+// isLockFile reports whether a file is a composer.lock, which has a content-hash
+func isLockFile(file string) bool {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return false
+	}
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return false
+	}
+	_, ok := keys["content-hash"]
+	return ok
+}
+
+func (c *command) hash(args []string) int {
+	flags := flag.NewFlagSet("hash", flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	check := flags.Bool("check", false, "compare with the content-hash of composer.lock, a missing lock fails")
+	if err := parseFlags(flags, args, 1); err != nil {
+		return exitUsage
+	}
+	file := fileArg(flags, 0)
+	data, _, err := readManifest(file)
+	if err != nil {
+		return c.fail(err)
+	}
+	hash, err := composer.ContentHash(data)
+	if err != nil {
+		return c.fail(err)
+	}
+	if !*check {
+		if c.jsonOut {
+			c.printJSON(map[string]string{"file": file, "content-hash": hash})
+		} else {
+			fmt.Fprintln(c.stdout, hash)
+		}
+		return exitOK
+	}
+
+	errs := checkLock(file, data, true)
+	if c.jsonOut {
+		c.printJSON(map[string]interface{}{"file": file, "content-hash": hash, "up-to-date": len(errs) == 0})
+	} else if len(errs) == 0 {
+		fmt.Fprintln(c.stdout, hash+" the lock file is up to date")
+	} else {
+		fmt.Fprintln(c.stdout, hash+" "+errs[0])
+	}
+	if len(errs) > 0 {
+		return exitFailed
+	}
+	return exitOK
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	doc := map[string]interface{}{
+		"require":  map[string]interface{}{"php": "^8.1"},
+		"autoload": map[string]interface{}{"psr-4": map[string]interface{}{`App\`: "src/"}},
+		"authors":  []interface{}{map[string]interface{}{"name": "Jane"}},
+	}
+	tests := []struct {
+		path    string
+		want    interface{}
+		wantErr bool
+	}{
+		{path: "$.require.php", want: "^8.1"},
+		{path: "require.php", want: "^8.1"},
+		{path: `autoload.psr-4["App\\"]`, want: "src/"},
+		{path: `$['require']['php']`, want: "^8.1"},
+		{path: "authors[0].name", want: "Jane"},
+		{path: "authors.0.name", want: "Jane"},
+		{path: "$", want: doc},
+		{path: "require.psr/log", wantErr: true},
+		{path: "authors[1]", wantErr: true},
+		{path: "require.php.x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := lookup(doc, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("lookup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lookup() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"valid.json":      `{"name": "acme/app", "require": {"php": "^8.1", "monolog/monolog": "^2.0"}}`,
+		"unbound.json":    `{"name": "acme/app", "require": {"monolog/monolog": "*"}}`,
+		"invalid.json":    `{"name": "Acme/App", "require": {"monolog/monolog": "not a version"}}`,
+		"normalized.json": "{\n    \"name\": \"acme/app\",\n    \"require\": {\n        \"php\": \"^8.1\"\n    }\n}\n",
+		"changed.json":    `{"name": "acme/app", "require": {"php": "^8.1", "monolog/monolog": "^3.0"}}`,
+		"outdated.json":   `{"name": "acme/app"}`,
+		"outdated.lock":   `{"content-hash": "0123456789abcdef0123456789abcdef", "packages": []}`,
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantOutput string
+	}{
+		{name: "no command", args: nil, wantCode: exitUsage},
+		{name: "unknown command", args: []string{"install"}, wantCode: exitUsage},
+		{name: "validate", args: []string{"validate", path("valid.json")}, wantCode: exitOK, wantOutput: "is valid"},
+		{name: "validate warning", args: []string{"validate", path("unbound.json")}, wantCode: exitOK, wantOutput: "unbound version constraints (*)"},
+		{name: "validate strict", args: []string{"validate", "--strict", path("unbound.json")}, wantCode: exitFailed},
+		{name: "validate strict after the file", args: []string{"validate", path("unbound.json"), "--strict"}, wantCode: exitFailed},
+		{name: "validate extra argument", args: []string{"validate", path("valid.json"), path("unbound.json")}, wantCode: exitUsage},
+		{name: "validate errors", args: []string{"--json", "validate", path("invalid.json")}, wantCode: exitFailed, wantOutput: `"valid": false`},
+		{name: "validate outdated lock", args: []string{"validate", path("outdated.json")}, wantCode: exitFailed, wantOutput: "not up to date"},
+		{name: "validate missing file", args: []string{"validate", path("missing.json")}, wantCode: exitUsage},
+		{name: "normalize dry run", args: []string{"normalize", "--dry-run", path("valid.json")}, wantCode: exitFailed, wantOutput: "is not normalized"},
+		{name: "already normalized", args: []string{"--json", "normalize", "--dry-run", path("normalized.json")}, wantCode: exitOK, wantOutput: `"normalized": true`},
+		{name: "show", args: []string{"show", "require.php", path("valid.json")}, wantCode: exitOK, wantOutput: "^8.1\n"},
+		{name: "show json", args: []string{"--json", "show", "require.php", path("valid.json")}, wantCode: exitOK, wantOutput: "\"^8.1\"\n"},
+		{name: "show missing", args: []string{"show", "require.psr/log", path("valid.json")}, wantCode: exitFailed},
+		{name: "diff", args: []string{"diff", path("valid.json"), path("changed.json")}, wantCode: exitFailed, wantOutput: "monolog/monolog"},
+		{name: "diff same", args: []string{"--json", "diff", path("valid.json"), path("valid.json")}, wantCode: exitOK, wantOutput: "[]\n"},
+		{name: "hash", args: []string{"hash", path("valid.json")}, wantCode: exitOK},
+		{name: "hash check", args: []string{"--json", "hash", "--check", path("outdated.json")}, wantCode: exitFailed, wantOutput: `"up-to-date": false`},
+		{name: "hash check after the file", args: []string{"--json", "hash", path("outdated.json"), "--check"}, wantCode: exitFailed, wantOutput: `"up-to-date": false`},
+		{name: "hash check missing lock", args: []string{"hash", "--check", path("valid.json")}, wantCode: exitFailed, wantOutput: "valid.lock does not exist"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, &stdout, &stderr); code != tt.wantCode {
+				t.Errorf("run() = %d, want %d, stdout %q, stderr %q", code, tt.wantCode, stdout.String(), stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.wantOutput) {
+				t.Errorf("run() output = %q, want it to contain %q", stdout.String(), tt.wantOutput)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+// Command composer-lock-merge is a git merge driver for composer.lock.
+//
+// It merges the locked packages of both branches one by one and computes the content-hash
+// from the composer.json next to the lock file. Configure it with
+//
+//	git config merge.composer-lock.name "composer.lock merge driver"
+//	git config merge.composer-lock.driver "composer-lock-merge %O %A %B %P"
+//	echo "composer.lock merge=composer-lock" >> .gitattributes
+//
+// The merged lock is written to %A. Conflicting packages keep the version of the current branch,
+// they are printed on stderr and the exit status is 1 so git reports the conflict.
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	composer "github.com/vova-tarasov/go-composer-json"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stderr))
+}
+
+// run merge the lock files named by the arguments <base> <ours> <theirs> [<path>] and return the exit status
+func run(args []string, stderr io.Writer) int {
+	if len(args) < 3 {
+		fmt.Fprintln(stderr, "usage: composer-lock-merge <base> <ours> <theirs> [<path>]")
+		return 2
+	}
+	oursPath, lockPath := args[1], "composer.lock"
+	if len(args) > 3 {
+		lockPath = args[3]
+	}
+
+	var docs [3][]byte
+	for i, path := range args[:3] {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		docs[i] = data
+	}
+
+	// composer.json may not be merged yet or may hold conflict markers, the content-hash is merged like any value then
+	composerJSON, err := ioutil.ReadFile(filepath.Join(filepath.Dir(lockPath), "composer.json"))
+	if err == nil {
+		if _, err := composer.ContentHash(composerJSON); err != nil {
+			composerJSON = nil
+		}
+	}
+
+	merged, conflicts, err := composer.MergeLocks(docs[0], docs[1], docs[2], composerJSON)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if err := ioutil.WriteFile(oursPath, merged, 0644); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	for _, c := range conflicts {
+		fmt.Fprintln(stderr, "conflict in "+lockPath+": "+c.String())
+	}
+	if len(conflicts) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	composer "github.com/vova-tarasov/go-composer-json"
+)
+
+// lock render a composer.lock with packages given as name:version
+func lock(hash string, packages ...string) string {
+	var list []string
+	for _, p := range packages {
+		nv := strings.SplitN(p, ":", 2)
+		list = append(list, fmt.Sprintf(`{"name": %q, "version": %q}`, nv[0], nv[1]))
+	}
+	return fmt.Sprintf(`{"content-hash": %q, "packages": [%s]}`, hash, strings.Join(list, ", "))
+}
+
+func TestRun(t *testing.T) {
+	const manifest = `{"name": "acme/app", "require": {"acme/a": "^1.0", "acme/b": "^1.0", "acme/c": "^1.0"}}`
+	hash, err := composer.ContentHash([]byte(manifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := lock("base", "acme/c:1.0.0")
+
+	tests := []struct {
+		name         string
+		args         []string
+		composerJSON string
+		ours         string
+		theirs       string
+		wantCode     int
+		wantStderr   string
+		wantPackages []string
+		wantHash     string
+	}{
+		{
+			name:       "usage",
+			args:       []string{"base.lock"},
+			wantCode:   2,
+			wantStderr: "usage: composer-lock-merge",
+		},
+		{
+			name:       "missing file",
+			args:       []string{"missing.lock", "ours.lock", "theirs.lock"},
+			ours:       base,
+			theirs:     base,
+			wantCode:   2,
+			wantStderr: "missing.lock",
+		},
+		{
+			name:     "invalid lock",
+			ours:     `{"packages": [`,
+			theirs:   base,
+			wantCode: 2,
+		},
+		{
+			name:         "merged",
+			composerJSON: manifest,
+			ours:         lock("ours", "acme/a:1.0.0", "acme/c:1.0.0"),
+			theirs:       lock("theirs", "acme/b:1.0.0", "acme/c:1.0.0"),
+			wantCode:     0,
+			wantPackages: []string{"acme/a:1.0.0", "acme/b:1.0.0", "acme/c:1.0.0"},
+			wantHash:     hash,
+		},
+		{
+			name:         "conflict",
+			composerJSON: manifest,
+			ours:         lock("ours", "acme/c:1.1.0"),
+			theirs:       lock("theirs", "acme/c:1.2.0"),
+			wantCode:     1,
+			wantStderr:   "composer.lock: packages.acme/c: base 1.0.0, ours 1.1.0, theirs 1.2.0",
+			wantPackages: []string{"acme/c:1.1.0"},
+			wantHash:     hash,
+		},
+		{
+			name:         "missing composer.json",
+			ours:         lock("ours", "acme/a:1.0.0", "acme/c:1.0.0"),
+			theirs:       lock("base", "acme/c:1.0.0"),
+			wantCode:     0,
+			wantPackages: []string{"acme/a:1.0.0", "acme/c:1.0.0"},
+			wantHash:     "ours",
+		},
+		{
+			name:         "invalid composer.json",
+			composerJSON: `{"require": {<<<<<<< HEAD`,
+			ours:         lock("base", "acme/c:1.0.0"),
+			theirs:       lock("theirs", "acme/b:1.0.0", "acme/c:1.0.0"),
+			wantCode:     0,
+			wantPackages: []string{"acme/b:1.0.0", "acme/c:1.0.0"},
+			wantHash:     "theirs",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := func(name string) string { return filepath.Join(dir, name) }
+			files := map[string]string{"base.lock": base, "ours.lock": tt.ours, "theirs.lock": tt.theirs}
+			if tt.composerJSON != "" {
+				files["composer.json"] = tt.composerJSON
+			}
+			for name, content := range files {
+				if err := ioutil.WriteFile(path(name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			names := tt.args
+			if names == nil {
+				names = []string{"base.lock", "ours.lock", "theirs.lock", "composer.lock"}
+			}
+			args := make([]string, len(names))
+			for i, name := range names {
+				args[i] = path(name)
+			}
+
+			var stderr bytes.Buffer
+			if code := run(args, &stderr); code != tt.wantCode {
+				t.Errorf("run() = %d, want %d, stderr %q", code, tt.wantCode, stderr.String())
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("run() stderr = %q, want it to contain %q", stderr.String(), tt.wantStderr)
+			}
+			if tt.wantPackages == nil {
+				return
+			}
+
+			data, err := ioutil.ReadFile(path("ours.lock"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var merged struct {
+				ContentHash string `json:"content-hash"`
+				Packages    []struct {
+					Name    string `json:"name"`
+					Version string `json:"version"`
+				} `json:"packages"`
+			}
+			if err := json.Unmarshal(data, &merged); err != nil {
+				t.Fatalf("merged lock %s: %v", data, err)
+			}
+			var packages []string
+			for _, p := range merged.Packages {
+				packages = append(packages, p.Name+":"+p.Version)
+			}
+			if !reflect.DeepEqual(packages, tt.wantPackages) {
+				t.Errorf("run() packages = %v, want %v", packages, tt.wantPackages)
+			}
+			if merged.ContentHash != tt.wantHash {
+				t.Errorf("run() content-hash = %s, want %s", merged.ContentHash, tt.wantHash)
+			}
+		})
+	}
+}
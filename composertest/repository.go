@@ -0,0 +1,161 @@
+// Package composertest provides a fake Composer repository for tests that must run offline.
+package composertest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	composer "github.com/vova-tarasov/go-composer-json"
+)
+
+// Repository is a Composer repository served by an httptest.Server.
+// It serves packages.json with a metadata-url and minified p2 files for its packages
+type Repository struct {
+	*httptest.Server
+
+	// LastModified is sent with every response and compared with If-Modified-Since
+	LastModified time.Time
+
+	mu       sync.Mutex
+	packages map[string][]composer.Package
+	requests map[string]int
+	username string
+	password string
+	bearer   string
+}
+
+// NewRepository start a repository serving the given package versions
+func NewRepository(packages ...composer.Package) *Repository {
+	r := &Repository{
+		LastModified: time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC),
+		packages:     map[string][]composer.Package{},
+		requests:     map[string]int{},
+	}
+	r.Add(packages...)
+	r.Server = httptest.NewServer(http.HandlerFunc(r.serveHTTP))
+	return r
+}
+
+// Add publish more package versions
+func (r *Repository) Add(packages ...composer.Package) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range packages {
+		name := strings.ToLower(p.Name)
+		r.packages[name] = append(r.packages[name], p)
+	}
+}
+
+// RequireBasicAuth reject requests without these http-basic credentials
+func (r *Repository) RequireBasicAuth(username, password string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.username, r.password = username, password
+}
+
+// RequireBearer reject requests without this bearer token
+func (r *Repository) RequireBearer(token string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bearer = token
+}
+
+// Requests count the requests received for a path, including the ones answered with 304
+func (r *Repository) Requests(path string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.requests[path]
+}
+
+// Repository describe the fake server as a repository of a manifest
+func (r *Repository) Repository() composer.Repository {
+	return composer.Repository{Type: "composer", Url: r.URL}
+}
+
+func (r *Repository) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests[req.URL.Path]++
+
+	if !r.authorized(req) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var doc interface{}
+	switch {
+	case req.URL.Path == "/packages.json":
+		doc = r.root()
+	case strings.HasPrefix(req.URL.Path, "/p2/") && strings.HasSuffix(req.URL.Path, ".json"):
+		name := strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/p2/"), ".json")
+		dev := strings.HasSuffix(name, "~dev")
+		metadata, ok := r.metadata(strings.TrimSuffix(name, "~dev"), dev)
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		doc = metadata
+	default:
+		http.NotFound(w, req)
+		return
+	}
+
+	if since, err := http.ParseTime(req.Header.Get("If-Modified-Since")); err == nil && !r.LastModified.After(since) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	body, err := json.Marshal(doc)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Last-Modified", r.LastModified.UTC().Format(http.TimeFormat))
+	w.Write(body)
+}
+
+func (r *Repository) authorized(req *http.Request) bool {
+	if r.username != "" {
+		username, password, ok := req.BasicAuth()
+		return ok && username == r.username && password == r.password
+	}
+	if r.bearer != "" {
+		return req.Header.Get("Authorization") == "Bearer "+r.bearer
+	}
+	return true
+}
+
+func (r *Repository) root() composer.RepositoryRoot {
+	names := make([]string, 0, len(r.packages))
+	for name := range r.packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return composer.RepositoryRoot{
+		MetadataUrl:       "/p2/%package%.json",
+		AvailablePackages: names,
+	}
+}
+
+func (r *Repository) metadata(name string, dev bool) (composer.PackageMetadata, bool) {
+	var versions []composer.Package
+	for _, p := range r.packages[name] {
+		v := strings.ToLower(p.Version)
+		if (strings.HasPrefix(v, "dev-") || strings.HasSuffix(v, "-dev")) == dev {
+			versions = append(versions, p)
+		}
+	}
+	if len(versions) == 0 {
+		return composer.PackageMetadata{}, false
+	}
+	return composer.PackageMetadata{
+		Packages: map[string][]composer.Package{name: versions},
+		Minified: composer.MinifiedFormat,
+	}, true
+}
@@ -0,0 +1,249 @@
+package composer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Kinds of an AutoloadConflict
+const (
+	// ConflictSamePrefix is a PSR prefix mapped by several packages
+	ConflictSamePrefix = "same-prefix"
+	// ConflictOverlappingPrefix is a PSR prefix of a package within the prefix of another package
+	ConflictOverlappingPrefix = "overlapping-prefix"
+	// ConflictDuplicateClass is a class declared in several files, the class map uses the first one
+	ConflictDuplicateClass = "duplicate-class"
+	// ConflictShadowedFallback is a class of a fallback directory which the autoloader finds elsewhere first
+	ConflictShadowedFallback = "shadowed-fallback"
+)
+
+// AutoloadConflict is a namespace prefix or a class several autoload rules compete for
+type AutoloadConflict struct {
+	Kind string `json:"kind"`
+	// Key is the namespace prefix or the class
+	Key string `json:"key"`
+	// Rules are the competing rules, the one the autoloader uses first. Files are set for classes only
+	Rules []ClassCandidate `json:"rules"`
+}
+
+// String describe the conflict, the root package is named after RootPackageName
+func (c AutoloadConflict) String() string {
+	var rules []string
+	for _, r := range c.Rules {
+		place := r.File
+		if place == "" {
+			place = r.Dir
+		}
+		name := r.Package
+		if name == "" {
+			name = RootPackageName
+		}
+		if r.Prefix != "" && c.Kind == ConflictOverlappingPrefix {
+			rules = append(rules, fmt.Sprintf(`%s "%s" of %s (%s)`, r.Rule, r.Prefix, name, place))
+		} else {
+			rules = append(rules, fmt.Sprintf("%s of %s (%s)", r.Rule, name, place))
+		}
+	}
+	switch c.Kind {
+	case ConflictSamePrefix:
+		return fmt.Sprintf(`Prefix "%s" is mapped by %s`, c.Key, strings.Join(rules, " and "))
+	case ConflictOverlappingPrefix:
+		return fmt.Sprintf(`Prefix "%s" overlaps: %s`, c.Key, strings.Join(rules, " and "))
+	case ConflictShadowedFallback:
+		return fmt.Sprintf("Class %s of %s is shadowed by %s", c.Key, rules[len(rules)-1], strings.Join(rules[:len(rules)-1], " and "))
+	}
+	return fmt.Sprintf("Class %s is declared by %s, the first will be used", c.Key, strings.Join(rules, " and "))
+}
+
+// FindAutoloadConflicts check the autoload sections of the root package and the installed packages, in the
+// order of the autoloader like AutoloadDumper.AutoloadSources, for namespace prefixes mapped by several packages,
+// duplicate class definitions and classes of fallback directories found through other rules first.
+// Duplicate classes in tests and fixtures are skipped like the Composer ambiguous class warning, and so are
+// duplicate classes already reported as shadowed fallbacks
+func FindAutoloadConflicts(sources []AutoloadSource) ([]AutoloadConflict, error) {
+	r, err := NewClassResolver(sources)
+	if err != nil {
+		return nil, err
+	}
+	conflicts := prefixConflicts(r)
+
+	m, err := ClassMapGenerator{}.Generate(sources)
+	if err != nil {
+		return nil, err
+	}
+	shadowed, err := fallbackConflicts(r, m)
+	if err != nil {
+		return nil, err
+	}
+	conflicts = append(conflicts, shadowed...)
+
+	for class, files := range m.AmbiguousClasses() {
+		c := AutoloadConflict{Kind: ConflictDuplicateClass, Key: class}
+		for _, file := range append([]string{m.Classes[class]}, files...) {
+			s := m.scans[file]
+			c.Rules = append(c.Rules, ClassCandidate{File: file, Rule: scanRule(s), Prefix: s.namespace, Dir: s.dir, Package: packageOf(sources, file), Exists: true})
+		}
+		if !reportedAsShadowed(shadowed, c) {
+			conflicts = append(conflicts, c)
+		}
+	}
+
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		if conflicts[i].Kind != conflicts[j].Kind {
+			return conflicts[i].Kind < conflicts[j].Kind
+		}
+		return conflicts[i].Key < conflicts[j].Key
+	})
+	return conflicts, nil
+}
+
+// prefixConflicts find the PSR-0 and PSR-4 prefixes of a package which are also, or within, the prefix
+// of another package, whatever the type of the rules. The longest prefix comes first like the autoloader
+// tries it, PSR-4 rules before PSR-0 rules
+func prefixConflicts(r *ClassResolver) []AutoloadConflict {
+	rules := map[string][]ClassCandidate{}
+	for _, typed := range []map[string][]ClassCandidate{r.psr4, r.psr0} {
+		for prefix, candidates := range typed {
+			rules[prefix] = append(rules[prefix], candidates...)
+		}
+	}
+	prefixes := make([]string, 0, len(rules))
+	for prefix := range rules {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	var conflicts []AutoloadConflict
+	for _, prefix := range prefixes {
+		if len(packagesOf(rules[prefix])) > 1 {
+			conflicts = append(conflicts, AutoloadConflict{Kind: ConflictSamePrefix, Key: prefix, Rules: rules[prefix]})
+		}
+		for _, outer := range prefixes {
+			if outer == prefix || !strings.HasPrefix(prefix, outer) {
+				continue
+			}
+			overlapping := append(append([]ClassCandidate{}, rules[prefix]...), rules[outer]...)
+			if len(packagesOf(overlapping)) > 1 {
+				conflicts = append(conflicts, AutoloadConflict{Kind: ConflictOverlappingPrefix, Key: prefix, Rules: overlapping})
+			}
+		}
+	}
+	return conflicts
+}
+
+// scanRule return the rule type of a class map scan
+func scanRule(s classMapScan) string {
+	switch {
+	case s.typ == "":
+		return RuleClassmap
+	case s.typ == "psr-4" && s.namespace == "":
+		return RulePsr4Fallback
+	case s.typ == "psr-0" && s.namespace == "":
+		return RulePsr0Fallback
+	}
+	return s.typ
+}
+
+// reportedAsShadowed reports whether the files of a duplicate class are all in a shadowed fallback conflict
+func reportedAsShadowed(shadowed []AutoloadConflict, duplicate AutoloadConflict) bool {
+	for _, c := range shadowed {
+		if c.Key != duplicate.Key {
+			continue
+		}
+		files := map[string]bool{}
+		for _, r := range c.Rules {
+			files[r.File] = true
+		}
+		reported := true
+		for _, r := range duplicate.Rules {
+			reported = reported && files[r.File]
+		}
+		if reported {
+			return true
+		}
+	}
+	return false
+}
+
+// fallbackConflicts find the classes of fallback directories which the autoloader finds in another file first,
+// the classes of a file are the ones the class map generator found in it
+func fallbackConflicts(r *ClassResolver, m *ClassMap) ([]AutoloadConflict, error) {
+	found := map[string][]string{}
+	for class, file := range m.Classes {
+		found[file] = append(found[file], class)
+	}
+	for class, files := range m.Ambiguous {
+		for _, file := range files {
+			found[file] = append(found[file], class)
+		}
+	}
+
+	var conflicts []AutoloadConflict
+	for _, fallback := range [][]ClassCandidate{r.psr4Fallback, r.psr0Fallback} {
+		for _, rule := range fallback {
+			if !isDir(rule.Dir) {
+				continue
+			}
+			files, err := classMapFiles(rule.Dir)
+			if err != nil {
+				return nil, err
+			}
+			for _, file := range files {
+				if filepath.Ext(file) != ".php" {
+					continue
+				}
+				classes := found[file]
+				sort.Strings(classes)
+				for _, class := range classes {
+					c := AutoloadConflict{Kind: ConflictShadowedFallback, Key: class}
+					for _, candidate := range r.Resolve(class) {
+						if candidate.File == file && candidate.Rule == rule.Rule {
+							break
+						}
+						if candidate.Exists && candidate.File != file {
+							c.Rules = append(c.Rules, candidate)
+						}
+					}
+					if len(c.Rules) > 0 {
+						rule.File, rule.Exists = file, true
+						c.Rules = append(c.Rules, rule)
+						conflicts = append(conflicts, c)
+					}
+				}
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+// packagesOf return the distinct packages of rules
+func packagesOf(rules []ClassCandidate) map[string]bool {
+	packages := map[string]bool{}
+	for _, r := range rules {
+		packages[r.Package] = true
+	}
+	return packages
+}
+
+// packageOf return the name of the package whose directory holds a file, the deepest one
+func packageOf(sources []AutoloadSource, file string) string {
+	name, longest := "", -1
+	for _, s := range sources {
+		dir, err := absolutePath(s.Dir)
+		if err != nil {
+			continue
+		}
+		if (file == dir || strings.HasPrefix(file, dir+"/")) && len(dir) > longest {
+			name, longest = s.Name, len(dir)
+		}
+	}
+	return name
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(filepath.FromSlash(path))
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,60 @@
+package composer
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindAutoloadConflicts(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"overrides/Client.php":                "<?php\nnamespace Acme\\Http;\nclass Client {}\n",
+		"fallback/Acme/Http/Request.php":      "<?php\nnamespace Acme\\Http;\nclass Request {}\n",
+		"fallback/Helper.php":                 "<?php\nclass Helper {}\n",
+		"fallback/Acme/Http/Client.php":       "<?php\nnamespace Other;\nclass Client {}\n",
+		"legacy/Twig/Node.php":                "<?php\nclass Twig_Node {}\n",
+		"vendor/acme/twig/lib/Twig/Node.php":  "<?php\nclass Twig_Node {}\n",
+		"vendor/acme/http/src/Client.php":     "<?php\nnamespace Acme\\Http;\nclass Client {}\n",
+		"vendor/acme/http/src/Request.php":    "<?php\nnamespace Acme\\Http;\nclass Request {}\n",
+		"vendor/acme/other/lib/Response.php":  "<?php\nnamespace Acme\\Http;\nclass Response {}\n",
+		"vendor/acme/core/src/Kernel.php":     "<?php\nnamespace Acme;\nclass Kernel {}\n",
+		"vendor/acme/core/compat/Kernel.php":  "<?php\nnamespace Acme;\nclass Kernel {}\n",
+		"vendor/acme/single/src/Unique.php":   "<?php\nnamespace Single;\nclass Unique {}\n",
+		"vendor/acme/single/src/Sub/Deep.php": "<?php\nnamespace Single\\Sub;\nclass Deep {}\n",
+	})
+	abs, err := absolutePath(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vendor := filepath.Join(dir, "vendor", "acme")
+	sources := []AutoloadSource{
+		{Dir: dir, Autoload: Autoload{Classmap: []string{"overrides/"}, Psr4: Psr{"": {"fallback/"}}, Psr0: Psr{"": {"legacy/"}}}},
+		{Name: "acme/http", Dir: filepath.Join(vendor, "http"), Autoload: Autoload{Psr4: Psr{`Acme\Http\`: {"src/"}}}},
+		{Name: "acme/other", Dir: filepath.Join(vendor, "other"), Autoload: Autoload{Psr4: Psr{`Acme\Http\`: {"lib/"}}}},
+		{Name: "acme/core", Dir: filepath.Join(vendor, "core"), Autoload: Autoload{Psr4: Psr{`Acme\`: {"src/"}}, Classmap: []string{"compat/"}}},
+		{Name: "acme/legacy", Dir: filepath.Join(vendor, "legacy"), Autoload: Autoload{Psr0: Psr{`Acme\`: {"lib/"}}}},
+		{Name: "acme/twig", Dir: filepath.Join(vendor, "twig"), Autoload: Autoload{Psr0: Psr{"Twig_": {"lib/"}}}},
+		{Name: "acme/single", Dir: filepath.Join(vendor, "single"), Autoload: Autoload{Psr4: Psr{`Single\`: {"src/"}, `Single\Sub\`: {"src/Sub/"}}}},
+	}
+
+	conflicts, err := FindAutoloadConflicts(sources)
+	if err != nil {
+		t.Fatalf("FindAutoloadConflicts() error = %v", err)
+	}
+	var got []string
+	for _, c := range conflicts {
+		got = append(got, c.Kind+": "+strings.Replace(c.String(), abs, ".", -1))
+	}
+	want := []string{
+		`duplicate-class: Class Acme\Http\Client is declared by classmap of __root__ (./overrides/Client.php) and psr-4 of acme/http (./vendor/acme/http/src/Client.php), the first will be used`,
+		`duplicate-class: Class Acme\Kernel is declared by classmap of acme/core (./vendor/acme/core/compat/Kernel.php) and psr-4 of acme/core (./vendor/acme/core/src/Kernel.php), the first will be used`,
+		`overlapping-prefix: Prefix "Acme\Http\" overlaps: psr-4 "Acme\Http\" of acme/http (./vendor/acme/http/src) and psr-4 "Acme\Http\" of acme/other (./vendor/acme/other/lib) and psr-4 "Acme\" of acme/core (./vendor/acme/core/src) and psr-0 "Acme\" of acme/legacy (./vendor/acme/legacy/lib)`,
+		`same-prefix: Prefix "Acme\" is mapped by psr-4 of acme/core (./vendor/acme/core/src) and psr-0 of acme/legacy (./vendor/acme/legacy/lib)`,
+		`same-prefix: Prefix "Acme\Http\" is mapped by psr-4 of acme/http (./vendor/acme/http/src) and psr-4 of acme/other (./vendor/acme/other/lib)`,
+		`shadowed-fallback: Class Acme\Http\Request of psr-4-fallback of __root__ (./fallback/Acme/Http/Request.php) is shadowed by psr-4 of acme/http (./vendor/acme/http/src/Request.php)`,
+		`shadowed-fallback: Class Twig_Node of psr-0-fallback of __root__ (./legacy/Twig/Node.php) is shadowed by psr-0 of acme/twig (./vendor/acme/twig/lib/Twig/Node.php)`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("FindAutoloadConflicts() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
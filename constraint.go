@@ -0,0 +1,594 @@
+package composer
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Constraint restricts the versions of a package, e.g. "^1.2 || ~2.0"
+type Constraint interface {
+	// Matches reports whether a normalized version satisfies the constraint
+	Matches(version string) bool
+	// String return the constraint in its normalized form
+	String() string
+
+	intervals() intervalSet
+}
+
+// ParseConstraints parse a version constraint the way Composer does.
+// Supported are exact versions, comparisons, ranges with "-", wildcards, "~", "^", "dev-" branches,
+// "@stability" flags and "#reference" suffixes, combined with "," or " " (and) and "||" (or)
+func ParseConstraints(constraints string) (Constraint, error) {
+	pretty := strings.TrimSpace(constraints)
+	if m := aliasRegex.FindStringSubmatch(pretty); m != nil {
+		pretty = m[1]
+	}
+
+	orParts := orSplitRegex.Split(pretty, -1)
+	or := make([]Constraint, 0, len(orParts))
+	for _, orPart := range orParts {
+		andParts := splitAndConstraints(orPart)
+		if len(andParts) == 0 {
+			return nil, errors.New("could not parse version constraint " + constraints)
+		}
+		and := make([]Constraint, 0, len(andParts))
+		for _, part := range andParts {
+			parsed, err := parseConstraint(part)
+			if err != nil {
+				return nil, errors.New("could not parse version constraint " + part + ": " + err.Error())
+			}
+			and = append(and, parsed...)
+		}
+		or = append(or, newMultiConstraint(and, true))
+	}
+	return newMultiConstraint(or, false), nil
+}
+
+// MustParseConstraints is like ParseConstraints but panics when the constraint is invalid
+func MustParseConstraints(constraints string) Constraint {
+	c, err := ParseConstraints(constraints)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Intersects reports whether at least one version satisfies both constraints
+func Intersects(a, b Constraint) bool {
+	return !a.intervals().intersect(b.intervals()).empty()
+}
+
+// StabilityFlag return the stability a root requirement explicitly allows, e.g. "dev" for "1.0@dev" or "dev-main".
+// It returns an empty string when the constraint does not lower the minimum stability
+func StabilityFlag(constraints string) string {
+	flag := ""
+	constraints = strings.TrimSpace(constraints)
+	if m := aliasRegex.FindStringSubmatch(constraints); m != nil {
+		constraints = m[1]
+	}
+	for _, part := range orSplitRegex.Split(constraints, -1) {
+		stability := ""
+		if m := stabilityFlagRegex.FindStringSubmatch(part); m != nil {
+			stability = normalizeStabilityName(m[1])
+		} else if singleConstraintRegex.MatchString(part) {
+			if s := VersionStability(strings.TrimLeft(part, "=v")); s != "stable" {
+				stability = s
+			}
+		}
+		if stability != "" && (flag == "" || Stabilities[stability] > Stabilities[flag]) {
+			flag = stability
+		}
+	}
+	return flag
+}
+
+func normalizeStabilityName(stability string) string {
+	switch strings.ToLower(stability) {
+	case "rc":
+		return "RC"
+	default:
+		return strings.ToLower(stability)
+	}
+}
+
+var (
+	orSplitRegex             = regexp.MustCompile(`\s*\|\|?\s*`)
+	singleConstraintRegex    = regexp.MustCompile(`^[^,\s@]+$`)
+	constraintStabilityRegex = regexp.MustCompile(`(?i)^([^,\s]*?)@(stable|RC|beta|alpha|dev)$`)
+	referenceRegex           = regexp.MustCompile(`(?i)^(dev-[^,\s@]+?|[^,\s@]+?\.x-dev)#.+$`)
+	matchAllRegex            = regexp.MustCompile(`^(v)?[xX*](\.[xX*])*$`)
+	versionPartsRegex        = `v?(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:\.(\d+))?` + modifierRegex
+	tildeRegex               = regexp.MustCompile(`(?i)^~>?` + versionPartsRegex + `$`)
+	caretRegex               = regexp.MustCompile(`(?i)^\^` + versionPartsRegex + `$`)
+	wildcardRegex            = regexp.MustCompile(`^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:\.[xX*])+$`)
+	hyphenRegex              = regexp.MustCompile(`(?i)^(` + versionPartsRegex + `) +- +(` + versionPartsRegex + `)$`)
+	comparatorRegex          = regexp.MustCompile(`^(<>|!=|>=?|<=?|==?)?\s*(.*)`)
+	devConstraintRegex       = regexp.MustCompile(`^[0-9a-zA-Z-./]+$`)
+	modifierSuffixRegex      = regexp.MustCompile(`-` + modifierRegex + `$`)
+)
+
+// splitAndConstraints split on commas and spaces, keeping "1 - 2" ranges and operators followed by spaces together
+func splitAndConstraints(constraints string) []string {
+	fields := strings.FieldsFunc(constraints, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+	var parts []string
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		switch {
+		case f == "-" && len(parts) > 0 && i+1 < len(fields):
+			parts[len(parts)-1] += " - " + fields[i+1]
+			i++
+		case strings.Trim(f, "<>=!") == "" && i+1 < len(fields):
+			parts = append(parts, f+fields[i+1])
+			i++
+		default:
+			parts = append(parts, f)
+		}
+	}
+	return parts
+}
+
+// parseConstraint parse a single constraint which can expand into a lower and an upper bound
+func parseConstraint(constraint string) ([]Constraint, error) {
+	stabilityModifier := ""
+	if m := constraintStabilityRegex.FindStringSubmatch(constraint); m != nil {
+		constraint = m[1]
+		if constraint == "" {
+			constraint = "*"
+		}
+		if !strings.EqualFold(m[2], "stable") {
+			stabilityModifier = normalizeStabilityName(m[2])
+		}
+	}
+	if m := referenceRegex.FindStringSubmatch(constraint); m != nil {
+		constraint = m[1]
+	}
+
+	if matchAllRegex.MatchString(constraint) {
+		return []Constraint{matchAll{}}, nil
+	}
+
+	if m := tildeRegex.FindStringSubmatch(constraint); m != nil {
+		if strings.HasPrefix(constraint, "~>") {
+			return nil, errors.New("invalid operator \"~>\", you probably meant to use the \"~\" operator")
+		}
+		position := 1
+		switch {
+		case m[4] != "":
+			position = 4
+		case m[3] != "":
+			position = 3
+		case m[2] != "":
+			position = 2
+		}
+		suffix := ""
+		if m[5] == "" && m[7] == "" {
+			suffix = "-dev"
+		}
+		low, err := NormalizeVersion(constraint[1:] + suffix)
+		if err != nil {
+			return nil, err
+		}
+		highPosition := position - 1
+		if highPosition < 1 {
+			highPosition = 1
+		}
+		return []Constraint{
+			&versionConstraint{">=", low},
+			&versionConstraint{"<", manipulateVersion(m[1:5], highPosition, 1) + "-dev"},
+		}, nil
+	}
+
+	if m := caretRegex.FindStringSubmatch(constraint); m != nil {
+		position := 3
+		switch {
+		case m[1] != "0" || m[2] == "":
+			position = 1
+		case m[2] != "0" || m[3] == "":
+			position = 2
+		}
+		suffix := ""
+		if m[5] == "" && m[7] == "" {
+			suffix = "-dev"
+		}
+		low, err := NormalizeVersion(constraint[1:] + suffix)
+		if err != nil {
+			return nil, err
+		}
+		return []Constraint{
+			&versionConstraint{">=", low},
+			&versionConstraint{"<", manipulateVersion(m[1:5], position, 1) + "-dev"},
+		}, nil
+	}
+
+	if m := wildcardRegex.FindStringSubmatch(constraint); m != nil {
+		position := 1
+		switch {
+		case m[3] != "":
+			position = 3
+		case m[2] != "":
+			position = 2
+		}
+		parts := []string{m[1], m[2], m[3], ""}
+		low := manipulateVersion(parts, position, 0) + "-dev"
+		high := manipulateVersion(parts, position, 1) + "-dev"
+		if low == "0.0.0.0-dev" {
+			return []Constraint{&versionConstraint{"<", high}}, nil
+		}
+		return []Constraint{&versionConstraint{">=", low}, &versionConstraint{"<", high}}, nil
+	}
+
+	if m := hyphenRegex.FindStringSubmatch(constraint); m != nil {
+		// groups: 1 from, 2-5 from numbers, 6-8 from modifiers, 9 to, 10-13 to numbers, 14-16 to modifiers
+		lowSuffix := ""
+		if m[6] == "" && m[8] == "" {
+			lowSuffix = "-dev"
+		}
+		low, err := NormalizeVersion(m[1])
+		if err != nil {
+			return nil, err
+		}
+		lower := &versionConstraint{">=", low + lowSuffix}
+
+		high, err := NormalizeVersion(m[9])
+		if err != nil {
+			return nil, err
+		}
+		if (m[11] != "" && m[12] != "") || m[14] != "" || m[16] != "" {
+			return []Constraint{lower, &versionConstraint{"<=", high}}, nil
+		}
+		position := 2
+		if m[11] == "" {
+			position = 1
+		}
+		return []Constraint{lower, &versionConstraint{"<", manipulateVersion(m[10:14], position, 1) + "-dev"}}, nil
+	}
+
+	if m := comparatorRegex.FindStringSubmatch(constraint); m != nil {
+		version, err := NormalizeVersion(m[2])
+		if err != nil {
+			// recover from an invalid constraint like foobar-dev which should be dev-foobar
+			if strings.HasSuffix(m[2], "-dev") && devConstraintRegex.MatchString(m[2]) {
+				version, err = NormalizeVersion("dev-" + strings.TrimSuffix(m[2], "-dev"))
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		op := m[1]
+		if op == "" {
+			op = "="
+		}
+		if op != "==" && op != "=" && stabilityModifier != "" && VersionStability(version) == "stable" {
+			version += "-" + stabilityModifier
+		} else if op == "<" || op == ">=" {
+			if !modifierSuffixRegex.MatchString(strings.ToLower(m[2])) && !strings.HasPrefix(m[2], "dev-") {
+				version += "-dev"
+			}
+		}
+		return []Constraint{&versionConstraint{op, version}}, nil
+	}
+
+	return nil, errors.New("could not parse version constraint " + constraint)
+}
+
+// manipulateVersion pad the parts after position with zeros and increment the part at position
+func manipulateVersion(parts []string, position int, increment int) string {
+	p := make([]int, 4)
+	for i := 0; i < 4; i++ {
+		if i < len(parts) && parts[i] != "" {
+			p[i], _ = strconv.Atoi(parts[i])
+		}
+	}
+	for i := 4; i > 0; i-- {
+		if i > position {
+			p[i-1] = 0
+		} else if i == position && increment != 0 {
+			p[i-1] += increment
+		}
+	}
+	return strconv.Itoa(p[0]) + "." + strconv.Itoa(p[1]) + "." + strconv.Itoa(p[2]) + "." + strconv.Itoa(p[3])
+}
+
+// matchAll matches any version, the "*" constraint
+type matchAll struct{}
+
+func (matchAll) Matches(string) bool { return true }
+
+func (matchAll) String() string { return "*" }
+
+func (matchAll) intervals() intervalSet { return allIntervals() }
+
+// versionConstraint compares versions with a single operator
+type versionConstraint struct {
+	operator string
+	version  string
+}
+
+func (c *versionConstraint) Matches(version string) bool {
+	cBranch, vBranch := strings.HasPrefix(c.version, "dev-"), strings.HasPrefix(version, "dev-")
+	if cBranch || vBranch {
+		equal := c.version == version
+		switch c.operator {
+		case "=", "==":
+			return equal
+		case "!=", "<>":
+			return !equal
+		}
+		return false
+	}
+
+	cmp := CompareVersions(version, c.version)
+	switch c.operator {
+	case "=", "==":
+		return cmp == 0
+	case "!=", "<>":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+func (c *versionConstraint) String() string {
+	op := c.operator
+	switch op {
+	case "=":
+		op = "=="
+	case "<>":
+		op = "!="
+	}
+	return op + " " + c.version
+}
+
+func (c *versionConstraint) intervals() intervalSet {
+	if strings.HasPrefix(c.version, "dev-") {
+		switch c.operator {
+		case "=", "==":
+			return intervalSet{branches: branchSet{names: map[string]bool{c.version: true}}}
+		case "!=", "<>":
+			all := allIntervals()
+			all.branches = branchSet{names: map[string]bool{c.version: true}, exclude: true}
+			return all
+		}
+		return intervalSet{}
+	}
+
+	v := bound{version: c.version, inclusive: true}
+	switch c.operator {
+	case "=", "==":
+		return intervalSet{ranges: []interval{{low: v, high: v}}}
+	case "!=", "<>":
+		return intervalSet{
+			ranges: []interval{
+				{low: minBound(), high: bound{version: c.version}},
+				{low: bound{version: c.version}, high: maxBound()},
+			},
+			branches: branchSet{exclude: true},
+		}
+	case "<":
+		return intervalSet{ranges: []interval{{low: minBound(), high: bound{version: c.version}}}}
+	case "<=":
+		return intervalSet{ranges: []interval{{low: minBound(), high: v}}}
+	case ">":
+		return intervalSet{ranges: []interval{{low: bound{version: c.version}, high: maxBound()}}}
+	case ">=":
+		return intervalSet{ranges: []interval{{low: v, high: maxBound()}}}
+	}
+	return intervalSet{}
+}
+
+// multiConstraint combines constraints with and (conjunctive) or with or
+type multiConstraint struct {
+	constraints []Constraint
+	conjunctive bool
+}
+
+func newMultiConstraint(constraints []Constraint, conjunctive bool) Constraint {
+	if len(constraints) == 1 {
+		return constraints[0]
+	}
+	return &multiConstraint{constraints, conjunctive}
+}
+
+func (c *multiConstraint) Matches(version string) bool {
+	for _, constraint := range c.constraints {
+		if constraint.Matches(version) != c.conjunctive {
+			return !c.conjunctive
+		}
+	}
+	return c.conjunctive
+}
+
+func (c *multiConstraint) String() string {
+	parts := make([]string, len(c.constraints))
+	for i, constraint := range c.constraints {
+		parts[i] = constraint.String()
+	}
+	if c.conjunctive {
+		return "[" + strings.Join(parts, " ") + "]"
+	}
+	return "[" + strings.Join(parts, " || ") + "]"
+}
+
+func (c *multiConstraint) intervals() intervalSet {
+	result := c.constraints[0].intervals()
+	for _, constraint := range c.constraints[1:] {
+		if c.conjunctive {
+			result = result.intersect(constraint.intervals())
+		} else {
+			result = result.union(constraint.intervals())
+		}
+	}
+	return result
+}
+
+// bound of an interval, an empty version is unbounded
+type bound struct {
+	version   string
+	inclusive bool
+	unbounded bool
+}
+
+func minBound() bound { return bound{unbounded: true} }
+
+func maxBound() bound { return bound{unbounded: true} }
+
+type interval struct {
+	low, high bound
+}
+
+func (i interval) empty() bool {
+	if i.low.unbounded || i.high.unbounded {
+		return false
+	}
+	c := CompareVersions(i.low.version, i.high.version)
+	return c > 0 || c == 0 && !(i.low.inclusive && i.high.inclusive)
+}
+
+// branchSet is either a set of dev branches or every branch except some
+type branchSet struct {
+	names   map[string]bool
+	exclude bool
+}
+
+func (b branchSet) empty() bool {
+	return !b.exclude && len(b.names) == 0
+}
+
+func (b branchSet) intersect(o branchSet) branchSet {
+	result := branchSet{names: map[string]bool{}}
+	switch {
+	case b.exclude && o.exclude:
+		result.exclude = true
+		for n := range b.names {
+			result.names[n] = true
+		}
+		for n := range o.names {
+			result.names[n] = true
+		}
+	case b.exclude:
+		for n := range o.names {
+			if !b.names[n] {
+				result.names[n] = true
+			}
+		}
+	case o.exclude:
+		return o.intersect(b)
+	default:
+		for n := range b.names {
+			if o.names[n] {
+				result.names[n] = true
+			}
+		}
+	}
+	return result
+}
+
+func (b branchSet) union(o branchSet) branchSet {
+	result := branchSet{names: map[string]bool{}}
+	switch {
+	case b.exclude && o.exclude:
+		result.exclude = true
+		for n := range b.names {
+			if o.names[n] {
+				result.names[n] = true
+			}
+		}
+	case b.exclude:
+		result.exclude = true
+		for n := range b.names {
+			if !o.names[n] {
+				result.names[n] = true
+			}
+		}
+	case o.exclude:
+		return o.union(b)
+	default:
+		for n := range b.names {
+			result.names[n] = true
+		}
+		for n := range o.names {
+			result.names[n] = true
+		}
+	}
+	return result
+}
+
+// intervalSet is the set of versions a constraint matches, as numeric ranges plus dev branches
+type intervalSet struct {
+	ranges   []interval
+	branches branchSet
+}
+
+func allIntervals() intervalSet {
+	return intervalSet{ranges: []interval{{low: minBound(), high: maxBound()}}, branches: branchSet{exclude: true}}
+}
+
+func (s intervalSet) empty() bool {
+	for _, r := range s.ranges {
+		if !r.empty() {
+			return false
+		}
+	}
+	return s.branches.empty()
+}
+
+func (s intervalSet) intersect(o intervalSet) intervalSet {
+	var result intervalSet
+	for _, a := range s.ranges {
+		for _, b := range o.ranges {
+			r := interval{low: maxLow(a.low, b.low), high: minHigh(a.high, b.high)}
+			if !r.empty() {
+				result.ranges = append(result.ranges, r)
+			}
+		}
+	}
+	result.branches = s.branches.intersect(o.branches)
+	return result
+}
+
+func (s intervalSet) union(o intervalSet) intervalSet {
+	result := intervalSet{ranges: append(append([]interval{}, s.ranges...), o.ranges...)}
+	result.branches = s.branches.union(o.branches)
+	return result
+}
+
+func maxLow(a, b bound) bound {
+	if a.unbounded {
+		return b
+	}
+	if b.unbounded {
+		return a
+	}
+	switch c := CompareVersions(a.version, b.version); {
+	case c > 0:
+		return a
+	case c < 0:
+		return b
+	}
+	return bound{version: a.version, inclusive: a.inclusive && b.inclusive}
+}
+
+func minHigh(a, b bound) bound {
+	if a.unbounded {
+		return b
+	}
+	if b.unbounded {
+		return a
+	}
+	switch c := CompareVersions(a.version, b.version); {
+	case c < 0:
+		return a
+	case c > 0:
+		return b
+	}
+	return bound{version: a.version, inclusive: a.inclusive && b.inclusive}
+}
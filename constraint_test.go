@@ -0,0 +1,140 @@
+package composer
+
+import "testing"
+
+func TestParseConstraints(t *testing.T) {
+	tests := []struct {
+		constraint string
+		want       string
+		wantErr    bool
+	}{
+		{"*", "*", false},
+		{"x.x", "*", false},
+		{"1.0", "== 1.0.0.0", false},
+		{">=1.0", ">= 1.0.0.0-dev", false},
+		{">= 1.0", ">= 1.0.0.0-dev", false},
+		{"<2.0", "< 2.0.0.0-dev", false},
+		{"<=2.0", "<= 2.0.0.0", false},
+		{"!=1.0", "!= 1.0.0.0", false},
+		{">1.0.0-beta", "> 1.0.0.0-beta", false},
+		{"2.*", "[>= 2.0.0.0-dev < 3.0.0.0-dev]", false},
+		{"2.1.x", "[>= 2.1.0.0-dev < 2.2.0.0-dev]", false},
+		{"0.*", "< 1.0.0.0-dev", false},
+		{"~1.2", "[>= 1.2.0.0-dev < 2.0.0.0-dev]", false},
+		{"~1.2.3", "[>= 1.2.3.0-dev < 1.3.0.0-dev]", false},
+		{"~1.2-beta", "[>= 1.2.0.0-beta < 2.0.0.0-dev]", false},
+		{"~1", "[>= 1.0.0.0-dev < 2.0.0.0-dev]", false},
+		{"^1.2.3", "[>= 1.2.3.0-dev < 2.0.0.0-dev]", false},
+		{"^0.3", "[>= 0.3.0.0-dev < 0.4.0.0-dev]", false},
+		{"^0.0.3", "[>= 0.0.3.0-dev < 0.0.4.0-dev]", false},
+		{"^0", "[>= 0.0.0.0-dev < 1.0.0.0-dev]", false},
+		{"1.0 - 2.0", "[>= 1.0.0.0-dev < 2.1.0.0-dev]", false},
+		{"1.2.3 - 2.3.4", "[>= 1.2.3.0-dev <= 2.3.4.0]", false},
+		{"1 - 2", "[>= 1.0.0.0-dev < 3.0.0.0-dev]", false},
+		{">=1.0,<2.0", "[>= 1.0.0.0-dev < 2.0.0.0-dev]", false},
+		{">=1.0 <2.0", "[>= 1.0.0.0-dev < 2.0.0.0-dev]", false},
+		{"^1.0 || ^2.0", "[[>= 1.0.0.0-dev < 2.0.0.0-dev] || [>= 2.0.0.0-dev < 3.0.0.0-dev]]", false},
+		{"^1.0|^2.0", "[[>= 1.0.0.0-dev < 2.0.0.0-dev] || [>= 2.0.0.0-dev < 3.0.0.0-dev]]", false},
+		{"dev-master", "== dev-master", false},
+		{"dev-master#abc123", "== dev-master", false},
+		{"1.0.x-dev#abc123", "== 1.0.9999999.9999999-dev", false},
+		{"1.0@dev", "== 1.0.0.0", false},
+		{">=1.0@beta", ">= 1.0.0.0-beta", false},
+		{"@dev", "*", false},
+		{"foo-dev", "== dev-foo", false},
+		{"~>1.0", "", true},
+		{"foo bar", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			got, err := ParseConstraints(tt.constraint)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseConstraints() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.String() != tt.want {
+				t.Errorf("ParseConstraints() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConstraint_Matches(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{"^1.2", "1.2.0.0", true},
+		{"^1.2", "1.9.9.0", true},
+		{"^1.2", "2.0.0.0", false},
+		{"^1.2", "2.0.0.0-beta1", false},
+		{"^1.2", "1.1.0.0", false},
+		{"^1.2", "1.3.0.0-beta1", true},
+		{"~1.2.3", "1.2.9.0", true},
+		{"~1.2.3", "1.3.0.0", false},
+		{"1.0.*", "1.0.5.0", true},
+		{">=7.2", "8.1.0.0", true},
+		{"!=1.0", "1.0.0.0", false},
+		{"*", "dev-master", true},
+		{"^1.0", "dev-master", false},
+		{"dev-master", "dev-master", true},
+		{"dev-master", "dev-main", false},
+		{"^2.0@dev", "2.9999999.9999999.9999999-dev", true},
+		{"^1.0 || ^3.0", "2.0.0.0", false},
+		{"^1.0 || ^3.0", "3.1.0.0", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint+" "+tt.version, func(t *testing.T) {
+			if got := MustParseConstraints(tt.constraint).Matches(tt.version); got != tt.want {
+				t.Errorf("Matches() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIntersects(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"^1.0", "^1.5", true},
+		{"^1.0", "^2.0", false},
+		{"<2.0", ">=2.0", false},
+		{"<=2.0", ">=2.0", true},
+		{"1.0.0", "^1.0", true},
+		{"1.0.0", "!=1.0.0", false},
+		{"*", "dev-master", true},
+		{"^1.0", "dev-master", false},
+		{"dev-master", "dev-master", true},
+		{"^1.0 || ^3.0", "~2.0", false},
+		{"^1.0 || ^3.0", ">=3.5", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			if got := Intersects(MustParseConstraints(tt.a), MustParseConstraints(tt.b)); got != tt.want {
+				t.Errorf("Intersects() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStabilityFlag(t *testing.T) {
+	tests := []struct {
+		constraint string
+		want       string
+	}{
+		{"^1.0", ""},
+		{"1.0@dev", "dev"},
+		{"^1.0@beta", "beta"},
+		{"dev-master", "dev"},
+		{"1.0.0-RC1", "RC"},
+		{"^1.0 || 2.0@alpha", "alpha"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			if got := StabilityFlag(tt.constraint); got != tt.want {
+				t.Errorf("StabilityFlag() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package composer
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// contentHashKeys are the composer.json keys the content-hash of composer.lock depends on
+var contentHashKeys = []string{
+	"name", "version", "require", "require-dev", "conflict", "replace", "provide",
+	"minimum-stability", "prefer-stable", "repositories", "extra",
+}
+
+// ContentHash compute the content-hash Composer writes into composer.lock for a composer.json document.
+// It is the md5 of the relevant keys and config.platform, sorted by key and encoded like PHP json_encode
+// without flags, so the document is hashed as written rather than through Manifest
+func ContentHash(composerJSON []byte) (string, error) {
+	content, err := relevantContent(composerJSON)
+	if err != nil {
+		return "", err
+	}
+	e := &phpEncoder{phpArrays: true}
+	sum := md5.Sum(e.encode(content))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func relevantContent(composerJSON []byte) (*jsonObject, error) {
+	doc, err := decodeOrdered(composerJSON)
+	if err != nil {
+		return nil, err
+	}
+	root, ok := doc.(*jsonObject)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %s", composerJSON))
+	}
+
+	relevant := newJSONObject()
+	for _, key := range contentHashKeys {
+		if v, ok := root.get(key); ok {
+			relevant.set(key, v)
+		}
+	}
+	if config, ok := root.values["config"].(*jsonObject); ok {
+		if platform, ok := config.get("platform"); ok && string(rawOrEmpty(platform)) != "null" {
+			c := newJSONObject()
+			c.set("platform", platform)
+			relevant.set("config", c)
+		}
+	}
+	return sortedObject(relevant), nil
+}
+
+func rawOrEmpty(v interface{}) []byte {
+	raw, _ := v.(json.RawMessage)
+	return raw
+}
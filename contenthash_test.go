@@ -0,0 +1,67 @@
+package composer
+
+import "testing"
+
+func TestContentHash(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "relevant keys only",
+			json: `{
+    "name": "acme/app",
+    "description": "not part of the hash",
+    "require": {
+        "php": ">=7.4",
+        "monolog/monolog": "^2.0"
+    },
+    "require-dev": {},
+    "repositories": [{"type": "vcs", "url": "https://github.com/acme/lib"}],
+    "extra": {"branch-alias": {"dev-main": "1.0.x-dev"}, "note": "héllo"},
+    "config": {"sort-packages": true, "platform": {"php": "7.4.0"}}
+}`,
+			want: "d590d613de47325329c1c563313b3206",
+		},
+		{
+			name: "escaped slashes",
+			json: `{"require": {"monolog/monolog": "1.0.*"}, "autoload": {"psr-4": {"App\\": "src/"}}}`,
+			want: "bef20e1ca06eac6c027a5bc95193a923",
+		},
+		{
+			name:    "invalid",
+			json:    `{"require": `,
+			wantErr: true,
+		},
+		{
+			name:    "not an object",
+			json:    `[]`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ContentHash([]byte(tt.json))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ContentHash() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ContentHash() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_relevantContent(t *testing.T) {
+	content, err := relevantContent([]byte(`{"version": "1.0.0", "name": "a/b", "prefer-stable": true, "minimum-stability": "dev", "config": {"platform": null}, "extra": {"0": "x", "1": "y"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := &phpEncoder{phpArrays: true}
+	want := `{"extra":["x","y"],"minimum-stability":"dev","name":"a\/b","prefer-stable":true,"version":"1.0.0"}`
+	if got := string(e.encode(content)); got != want {
+		t.Errorf("relevantContent() got = %v, want %v", got, want)
+	}
+}
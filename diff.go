@@ -0,0 +1,247 @@
+package composer
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Kinds of a Change
+const (
+	ChangeAdded     = "added"
+	ChangeRemoved   = "removed"
+	ChangeChanged   = "changed"
+	ChangeMoved     = "moved"
+	ChangeReordered = "reordered"
+)
+
+// Change is one semantic difference between two manifests
+type Change struct {
+	// Section of composer.json, e.g. "require", "autoload.psr-4", "scripts", "config" or "repositories".
+	// Other top level keys are their own section, e.g. "minimum-stability"
+	Section string `json:"section"`
+	Kind    string `json:"kind"`
+	// Key within the section, e.g. a package name, a namespace, a script name, a config key or a repository url
+	Key string `json:"key,omitempty"`
+	Old string `json:"old,omitempty"`
+	New string `json:"new,omitempty"`
+}
+
+// String render the change for humans, e.g. "require: changed symfony/console from ^5.4 to ^6.0"
+func (c Change) String() string {
+	s := c.Section + ": " + c.Kind
+	if c.Key != "" {
+		s += " " + c.Key
+	}
+	switch c.Kind {
+	case ChangeAdded:
+		if c.New != "" {
+			s += " (" + c.New + ")"
+		}
+	case ChangeRemoved:
+		if c.Old != "" {
+			s += " (" + c.Old + ")"
+		}
+	default:
+		s += " from " + c.Old + " to " + c.New
+	}
+	return s
+}
+
+// ManifestDiff lists the changes between two manifests, grouped by section
+type ManifestDiff []Change
+
+// String render one change per line
+func (d ManifestDiff) String() string {
+	var b strings.Builder
+	for _, c := range d {
+		b.WriteString(c.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// diffedKeys are the composer.json keys with a dedicated comparison, any other key is compared as a whole
+var diffedKeys = map[string]bool{
+	"require": true, "require-dev": true, "conflict": true, "replace": true, "provide": true, "suggest": true,
+	"autoload": true, "autoload-dev": true, "scripts": true, "config": true, "repositories": true,
+}
+
+// Diff return the semantic changes from a to b: requirements added, removed or changed, autoload
+// namespaces and paths, scripts, config keys and repositories added, removed, changed or reordered
+func Diff(a, b *Manifest) ManifestDiff {
+	var d ManifestDiff
+	for _, s := range []struct {
+		section string
+		a, b    map[string]string
+	}{
+		{"require", a.Require, b.Require},
+		{"require-dev", a.RequireDev, b.RequireDev},
+		{"conflict", a.Conflict, b.Conflict},
+		{"replace", a.Replace, b.Replace},
+		{"provide", a.Provide, b.Provide},
+		{"suggest", a.Suggest, b.Suggest},
+	} {
+		d = append(d, diffMaps(s.section, s.a, s.b, ChangeChanged)...)
+	}
+	d = append(d, diffAutoload("autoload", a.Autoload, b.Autoload)...)
+	d = append(d, diffAutoload("autoload-dev", a.AutoloadDev, b.AutoloadDev)...)
+	d = append(d, diffMaps("scripts", joinedValues(a.Scripts), joinedValues(b.Scripts), ChangeChanged)...)
+	d = append(d, diffMaps("config", jsonFields(a.Config), jsonFields(b.Config), ChangeChanged)...)
+	d = append(d, diffRepositories(a.Repositories, b.Repositories)...)
+
+	fa, fb := jsonFields(a), jsonFields(b)
+	for _, c := range diffMaps("", fa, fb, ChangeChanged) {
+		if diffedKeys[c.Key] {
+			continue
+		}
+		c.Section, c.Key = c.Key, ""
+		d = append(d, c)
+	}
+	return d
+}
+
+// diffMaps compare two maps key by key, a key whose value differs is reported with the given kind
+func diffMaps(section string, a, b map[string]string, kind string) []Change {
+	keys := map[string]bool{}
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	names := make([]string, 0, len(keys))
+	for k := range keys {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var changes []Change
+	for _, k := range names {
+		before, inA := a[k]
+		after, inB := b[k]
+		switch {
+		case !inA:
+			changes = append(changes, Change{Section: section, Kind: ChangeAdded, Key: k, New: after})
+		case !inB:
+			changes = append(changes, Change{Section: section, Kind: ChangeRemoved, Key: k, Old: before})
+		case before != after:
+			changes = append(changes, Change{Section: section, Kind: kind, Key: k, Old: before, New: after})
+		}
+	}
+	return changes
+}
+
+// diffAutoload compare namespaces by their paths and classmap, files and exclude-from-classmap entries
+func diffAutoload(section string, a, b Autoload) []Change {
+	var changes []Change
+	changes = append(changes, diffMaps(section+".psr-0", joinedValues(a.Psr0), joinedValues(b.Psr0), ChangeMoved)...)
+	changes = append(changes, diffMaps(section+".psr-4", joinedValues(a.Psr4), joinedValues(b.Psr4), ChangeMoved)...)
+	changes = append(changes, diffMaps(section+".classmap", setOf(a.Classmap), setOf(b.Classmap), ChangeChanged)...)
+	changes = append(changes, diffMaps(section+".files", setOf(a.Files), setOf(b.Files), ChangeChanged)...)
+	changes = append(changes, diffMaps(section+".exclude-from-classmap", setOf(a.ExcludeFromClassmap), setOf(b.ExcludeFromClassmap), ChangeChanged)...)
+	return changes
+}
+
+// diffRepositories compare repositories by url, a repository without url is identified by its type.
+// Repositories sharing an identity are told apart by their occurrence, e.g. "package#2"
+func diffRepositories(a, b Repositories) []Change {
+	ka, kb := repositoryKeys(a), repositoryKeys(b)
+	ma, mb := map[string]string{}, map[string]string{}
+	for i, k := range ka {
+		ma[k] = marshalString(a[i])
+	}
+	for i, k := range kb {
+		mb[k] = marshalString(b[i])
+	}
+
+	var changes []Change
+	var orderA, orderB []string
+	for _, k := range ka {
+		if _, ok := mb[k]; !ok {
+			changes = append(changes, Change{Section: "repositories", Kind: ChangeRemoved, Key: k})
+		} else {
+			orderA = append(orderA, k)
+		}
+	}
+	for _, k := range kb {
+		if _, ok := ma[k]; !ok {
+			changes = append(changes, Change{Section: "repositories", Kind: ChangeAdded, Key: k})
+			continue
+		}
+		orderB = append(orderB, k)
+		if ma[k] != mb[k] {
+			changes = append(changes, Change{Section: "repositories", Kind: ChangeChanged, Key: k, Old: ma[k], New: mb[k]})
+		}
+	}
+	if strings.Join(orderA, "\n") != strings.Join(orderB, "\n") {
+		changes = append(changes, Change{Section: "repositories", Kind: ChangeReordered, Old: strings.Join(orderA, ", "), New: strings.Join(orderB, ", ")})
+	}
+	return changes
+}
+
+func repositoryKeys(repositories Repositories) []string {
+	keys := make([]string, len(repositories))
+	seen := map[string]int{}
+	for i, r := range repositories {
+		k := r.Url
+		if k == "" {
+			k = r.Type
+		}
+		seen[k]++
+		if seen[k] > 1 {
+			k += "#" + strconv.Itoa(seen[k])
+		}
+		keys[i] = k
+	}
+	return keys
+}
+
+// joinedValues flatten a map of lists, e.g. psr-4 paths or script commands, into comma separated strings
+func joinedValues(m interface{}) map[string]string {
+	result := map[string]string{}
+	switch v := m.(type) {
+	case Psr:
+		for k, paths := range v {
+			result[k] = strings.Join(paths, ", ")
+		}
+	case map[string]StringOrStrings:
+		for k, commands := range v {
+			result[k] = strings.Join(commands, ", ")
+		}
+	}
+	return result
+}
+
+func setOf(values []string) map[string]string {
+	result := make(map[string]string, len(values))
+	for _, v := range values {
+		result[v] = ""
+	}
+	return result
+}
+
+// jsonFields return the JSON encoding of every key of a struct, empty keys are omitted
+func jsonFields(v interface{}) map[string]string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil
+	}
+	result := make(map[string]string, len(raw))
+	for k, v := range raw {
+		if s := string(v); s != "{}" && s != "null" && s != `""` {
+			result[k] = s
+		}
+	}
+	return result
+}
+
+func marshalString(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
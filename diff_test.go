@@ -0,0 +1,144 @@
+package composer
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want ManifestDiff
+	}{
+		{
+			name: "requirements",
+			a:    `{"require": {"php": "^7.4", "psr/log": "^1.0", "symfony/console": "^5.4"}, "require-dev": {"phpunit/phpunit": "^9.5"}}`,
+			b:    `{"require": {"php": "^7.4", "monolog/monolog": "^2.0", "symfony/console": "^6.0"}, "require-dev": {"phpunit/phpunit": "^9.5"}}`,
+			want: ManifestDiff{
+				{Section: "require", Kind: ChangeAdded, Key: "monolog/monolog", New: "^2.0"},
+				{Section: "require", Kind: ChangeRemoved, Key: "psr/log", Old: "^1.0"},
+				{Section: "require", Kind: ChangeChanged, Key: "symfony/console", Old: "^5.4", New: "^6.0"},
+			},
+		},
+		{
+			name: "autoload",
+			a:    `{"autoload": {"psr-4": {"App\\": "src/", "Old\\": "old/"}, "files": ["a.php"]}}`,
+			b:    `{"autoload": {"psr-4": {"App\\": ["app/", "src/"]}, "files": ["a.php", "b.php"]}, "autoload-dev": {"psr-4": {"Tests\\": "tests/"}}}`,
+			want: ManifestDiff{
+				{Section: "autoload.psr-4", Kind: ChangeMoved, Key: "App\\", Old: "src/", New: "app/, src/"},
+				{Section: "autoload.psr-4", Kind: ChangeRemoved, Key: "Old\\", Old: "old/"},
+				{Section: "autoload.files", Kind: ChangeAdded, Key: "b.php"},
+				{Section: "autoload-dev.psr-4", Kind: ChangeAdded, Key: "Tests\\", New: "tests/"},
+			},
+		},
+		{
+			name: "scripts and config",
+			a:    `{"scripts": {"test": "phpunit", "lint": "phpcs"}, "config": {"sort-packages": true, "platform": {"php": "7.4.0"}}}`,
+			b:    `{"scripts": {"test": ["@lint", "phpunit"]}, "config": {"sort-packages": true, "platform": {"php": "8.1.0"}, "vendor-dir": "lib"}}`,
+			want: ManifestDiff{
+				{Section: "scripts", Kind: ChangeRemoved, Key: "lint", Old: "phpcs"},
+				{Section: "scripts", Kind: ChangeChanged, Key: "test", Old: "phpunit", New: "@lint, phpunit"},
+				{Section: "config", Kind: ChangeChanged, Key: "platform", Old: `{"php":"7.4.0"}`, New: `{"php":"8.1.0"}`},
+				{Section: "config", Kind: ChangeAdded, Key: "vendor-dir", New: `"lib"`},
+			},
+		},
+		{
+			name: "config set to false",
+			a:    `{"config": {"sort-packages": false, "platform-check": false}}`,
+			b:    `{"config": {"platform-check": false}}`,
+			want: ManifestDiff{
+				{Section: "config", Kind: ChangeRemoved, Key: "sort-packages", Old: "false"},
+			},
+		},
+		{
+			name: "repositories",
+			a:    `{"repositories": [{"type": "composer", "url": "https://a.example.com"}, {"type": "vcs", "url": "https://b.example.com"}, {"type": "composer", "url": "https://c.example.com"}]}`,
+			b:    `{"repositories": [{"type": "composer", "url": "https://c.example.com"}, {"type": "composer", "url": "https://a.example.com", "canonical": false}, {"type": "path", "url": "../d"}]}`,
+			want: ManifestDiff{
+				{Section: "repositories", Kind: ChangeRemoved, Key: "https://b.example.com"},
+				{Section: "repositories", Kind: ChangeAdded, Key: "../d"},
+				{Section: "repositories", Kind: ChangeReordered, Old: "https://a.example.com, https://c.example.com", New: "https://c.example.com, https://a.example.com"},
+			},
+		},
+		{
+			name: "other keys",
+			a:    `{"name": "acme/app", "minimum-stability": "dev"}`,
+			b:    `{"name": "acme/app", "minimum-stability": "stable", "prefer-stable": true}`,
+			want: ManifestDiff{
+				{Section: "minimum-stability", Kind: ChangeChanged, Old: `"dev"`, New: `"stable"`},
+				{Section: "prefer-stable", Kind: ChangeAdded, New: "true"},
+			},
+		},
+		{
+			name: "no changes",
+			a:    `{"require": {"php": "^8.0"}}`,
+			b:    `{"require": {"php": "^8.0"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var a, b Manifest
+			if err := json.Unmarshal([]byte(tt.a), &a); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.b), &b); err != nil {
+				t.Fatal(err)
+			}
+			if got := Diff(&a, &b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() got = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiff_objectRepositories(t *testing.T) {
+	data := []byte(`{"repositories": {
+		"e": {"type": "composer", "url": "https://e.example.com"},
+		"d": {"type": "composer", "url": "https://d.example.com"},
+		"c": {"type": "vcs", "url": "https://c.example.com"},
+		"b": {"type": "path", "url": "../b"},
+		"a": {"type": "composer", "url": "https://a.example.com"}
+	}}`)
+	for i := 0; i < 50; i++ {
+		var a, b Manifest
+		if err := json.Unmarshal(data, &a); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(data, &b); err != nil {
+			t.Fatal(err)
+		}
+		if got := Diff(&a, &b); len(got) != 0 {
+			t.Fatalf("Diff() got = %v, want no changes", got)
+		}
+	}
+}
+
+func TestManifestDiff_String(t *testing.T) {
+	d := ManifestDiff{
+		{Section: "require", Kind: ChangeAdded, Key: "monolog/monolog", New: "^2.0"},
+		{Section: "require", Kind: ChangeRemoved, Key: "psr/log", Old: "^1.0"},
+		{Section: "require", Kind: ChangeChanged, Key: "symfony/console", Old: "^5.4", New: "^6.0"},
+		{Section: "autoload.psr-4", Kind: ChangeMoved, Key: "App\\", Old: "src/", New: "app/"},
+		{Section: "repositories", Kind: ChangeAdded, Key: "https://a.example.com"},
+	}
+	want := `require: added monolog/monolog (^2.0)
+require: removed psr/log (^1.0)
+require: changed symfony/console from ^5.4 to ^6.0
+autoload.psr-4: moved App\ from src/ to app/
+repositories: added https://a.example.com
+`
+	if got := d.String(); got != want {
+		t.Errorf("String() got = %v, want %v", got, want)
+	}
+
+	data, err := json.Marshal(d[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"section":"require","kind":"added","key":"monolog/monolog","new":"^2.0"}]`; string(data) != want {
+		t.Errorf("MarshalJSON() got = %s, want %s", data, want)
+	}
+}
@@ -0,0 +1,177 @@
+package composer
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// JSONEncoder encode values the way Composer JsonFile::encode writes composer.json and composer.lock:
+// 4 spaces indentation, unescaped slashes and unicode, "<", ">" and "&" as is, and a trailing new line.
+// Values are encoded like encoding/json with these differences:
+//
+// - nil maps are written as {} and nil slices as [], not as null
+// - fields tagged omitempty are also omitted when they hold a zero struct, e.g. an empty Config
+type JSONEncoder struct {
+	// EmptyObjectsAsArrays write empty objects as [], the way PHP encodes empty arrays, e.g. the "platform"
+	// of lock files written by Composer 1
+	EmptyObjectsAsArrays bool
+}
+
+// EncodeJSON encode a value, e.g. a Manifest or a Lock, like Composer does
+func EncodeJSON(v interface{}) ([]byte, error) {
+	return JSONEncoder{}.Encode(v)
+}
+
+// Encode a value like Composer does
+func (e JSONEncoder) Encode(v interface{}) ([]byte, error) {
+	doc, err := orderedValue(reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+	encoder := *composerEncoder
+	encoder.phpArrays = e.EmptyObjectsAsArrays
+	return append(encoder.encode(doc), '\n'), nil
+}
+
+var marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// orderedValue convert a Go value into an ordered JSON document following the encoding/json rules
+func orderedValue(v reflect.Value) (interface{}, error) {
+	if !v.IsValid() {
+		return json.RawMessage("null"), nil
+	}
+	switch {
+	case v.Kind() == reflect.Map && v.IsNil():
+		return newJSONObject(), nil
+	case v.Kind() == reflect.Slice && v.IsNil():
+		return []interface{}{}, nil
+	case (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil():
+		return json.RawMessage("null"), nil
+	}
+
+	if v.Type().Implements(marshalerType) || v.CanAddr() && v.Addr().Type().Implements(marshalerType) {
+		m, ok := v.Interface().(json.Marshaler)
+		if !ok {
+			m = v.Addr().Interface().(json.Marshaler)
+		}
+		data, err := m.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		return decodeOrdered(data)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return orderedValue(v.Elem())
+	case reflect.Struct:
+		o := newJSONObject()
+		if err := addStructFields(o, v); err != nil {
+			return nil, err
+		}
+		return o, nil
+	case reflect.Map:
+		o := newJSONObject()
+		keys := v.MapKeys()
+		names := make([]string, len(keys))
+		values := map[string]reflect.Value{}
+		for i, k := range keys {
+			data, err := json.Marshal(k.Interface())
+			if err != nil {
+				return nil, err
+			}
+			name := strings.Trim(string(data), `"`)
+			names[i] = name
+			values[name] = v.MapIndex(k)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			item, err := orderedValue(values[name])
+			if err != nil {
+				return nil, err
+			}
+			o.set(name, item)
+		}
+		return o, nil
+	case reflect.Slice, reflect.Array:
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			item, err := orderedValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			list[i] = item
+		}
+		return list, nil
+	}
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(data), nil
+}
+
+// addStructFields add the exported fields of a struct, embedded structs without a name are flattened
+func addStructFields(o *jsonObject, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options := tag, ""
+		if comma := strings.Index(tag, ","); comma != -1 {
+			name, options = tag[:comma], tag[comma+1:]
+		}
+		value := v.Field(i)
+
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			if err := addStructFields(o, value); err != nil {
+				return err
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if strings.Contains(","+options+",", ",omitempty,") && isEmptyValue(value) {
+			continue
+		}
+		if _, exists := o.values[name]; exists {
+			continue
+		}
+		item, err := orderedValue(value)
+		if err != nil {
+			return err
+		}
+		o.set(name, item)
+	}
+	return nil
+}
+
+// isEmptyValue is the omitempty rule of encoding/json, extended to zero structs
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	case reflect.Struct:
+		return v.IsZero()
+	}
+	return false
+}
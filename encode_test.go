@@ -0,0 +1,125 @@
+package composer
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEncodeJSON(t *testing.T) {
+	m := &Manifest{
+		Name:        "acme/app",
+		Description: "Tools for <html> & more, café",
+		License:     StringOrStrings{"MIT"},
+		Require:     map[string]string{"php": "^8.1", "monolog/monolog": "^2.0"},
+		RequireDev:  map[string]string{},
+		Autoload:    Autoload{Psr4: Psr{"App\\": {"src/"}}},
+		Repositories: Repositories{
+			{Type: "composer", Url: "https://repo.example.com/"},
+		},
+		Extra: Extra{"branch-alias": map[string]interface{}{"dev-main": "1.0.x-dev"}},
+	}
+	want := `{
+    "name": "acme/app",
+    "description": "Tools for <html> & more, café",
+    "license": [
+        "MIT"
+    ],
+    "require": {
+        "monolog/monolog": "^2.0",
+        "php": "^8.1"
+    },
+    "autoload": {
+        "psr-4": {
+            "App\\": [
+                "src/"
+            ]
+        }
+    },
+    "repositories": [
+        {
+            "type": "composer",
+            "url": "https://repo.example.com/"
+        }
+    ],
+    "extra": {
+        "branch-alias": {
+            "dev-main": "1.0.x-dev"
+        }
+    }
+}
+`
+	got, err := EncodeJSON(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("EncodeJSON() got = %v, want %v", string(got), want)
+	}
+
+	var decoded Manifest
+	if err := json.Unmarshal(got, &decoded); err != nil || decoded.Description != m.Description {
+		t.Errorf("EncodeJSON() does not round trip, error = %v", err)
+	}
+}
+
+func TestJSONEncoder_Encode_lock(t *testing.T) {
+	lock := &Lock{
+		ContentHash:      "d590d613de47325329c1c563313b3206",
+		MinimumStability: "stable",
+		PluginApiVersion: "2.6.0",
+	}
+	tests := []struct {
+		name    string
+		encoder JSONEncoder
+		empty   string
+	}{
+		{"objects", JSONEncoder{}, "{}"},
+		{"php arrays", JSONEncoder{EmptyObjectsAsArrays: true}, "[]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := `{
+    "content-hash": "d590d613de47325329c1c563313b3206",
+    "packages": [],
+    "packages-dev": [],
+    "aliases": [],
+    "minimum-stability": "stable",
+    "stability-flags": ` + tt.empty + `,
+    "prefer-stable": false,
+    "prefer-lowest": false,
+    "platform": ` + tt.empty + `,
+    "platform-dev": ` + tt.empty + `,
+    "plugin-api-version": "2.6.0"
+}
+`
+			got, err := tt.encoder.Encode(lock)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != want {
+				t.Errorf("Encode() got = %v, want %v", string(got), want)
+			}
+		})
+	}
+}
+
+func TestEncodeJSON_package(t *testing.T) {
+	p := &Package{Manifest: Manifest{Name: "a/b", Version: "1.0.0"}, Dist: &Dist{Type: "zip", Url: "https://example.com/a.zip"}}
+	got, err := EncodeJSON(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// empty config, autoload and support structs are omitted, unlike with json.Marshal
+	want := `{
+    "name": "a/b",
+    "version": "1.0.0",
+    "dist": {
+        "type": "zip",
+        "url": "https://example.com/a.zip"
+    }
+}
+`
+	if string(got) != want {
+		t.Errorf("EncodeJSON() got = %v, want %v", string(got), want)
+	}
+}
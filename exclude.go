@@ -0,0 +1,87 @@
+package composer
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	slashesRegex = regexp.MustCompile(`/+`)
+	updirRegex   = regexp.MustCompile(`^((?:\\\.(?:\\\.)?/)+)`)
+)
+
+// ExcludeMatcher match files against the exclude-from-classmap paths of packages like Composer:
+// paths are relative to the package root, ** matches anything including slashes, * anything but a slash,
+// and a pattern matches a file below the path too. Leading ./ and ../ are resolved against the real
+// path of the package root, a pattern whose directory does not exist is ignored
+type ExcludeMatcher struct {
+	patterns []string
+	regex    *regexp.Regexp
+}
+
+// NewExcludeMatcher compile the exclude-from-classmap paths of autoload sections
+func NewExcludeMatcher(sources []AutoloadSource) (*ExcludeMatcher, error) {
+	m := &ExcludeMatcher{}
+	for _, s := range sources {
+		for _, p := range s.Autoload.ExcludeFromClassmap {
+			if pattern, ok := ExcludeFromClassmapRegex(s.Dir, p); ok {
+				m.patterns = append(m.patterns, pattern)
+			}
+		}
+	}
+	if len(m.patterns) == 0 {
+		return m, nil
+	}
+	regex, err := regexp.Compile("(" + strings.Join(m.patterns, "|") + ")")
+	if err != nil {
+		return nil, err
+	}
+	m.regex = regex
+	return m, nil
+}
+
+// ExcludeFromClassmapRegex turn an exclude-from-classmap path of a package installed in dir into the regular
+// expression Composer matches against absolute slash separated paths. It fails when the directory the path
+// goes up to does not exist
+func ExcludeFromClassmapRegex(dir, path string) (string, bool) {
+	path = slashesRegex.ReplaceAllString(regexp.QuoteMeta(strings.Trim(strings.Replace(path, `\`, "/", -1), "/")), "/")
+	path = strings.NewReplacer(`\*\*`, ".+?", `\*`, "[^/]+?").Replace(path)
+
+	updir := ""
+	if m := updirRegex.FindStringSubmatch(path); m != nil {
+		updir = strings.Replace(m[1], `\.`, ".", -1)
+		path = path[len(m[0]):]
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.FromSlash(strings.TrimRight(filepath.ToSlash(dir), "/") + "/" + updir))
+	if err != nil {
+		return "", false
+	}
+	resolved, err = absolutePath(resolved)
+	if err != nil {
+		return "", false
+	}
+	return regexp.QuoteMeta(resolved) + "/" + path + "($|/)", true
+}
+
+// Patterns return the regular expressions of the paths whose directory exists
+func (m *ExcludeMatcher) Patterns() []string {
+	return m.patterns
+}
+
+// Match reports whether a file is excluded, by its path or by its real path when it goes through a symlink
+func (m *ExcludeMatcher) Match(file string) bool {
+	if m.regex == nil {
+		return false
+	}
+	abs, err := absolutePath(file)
+	if err != nil {
+		return false
+	}
+	return m.regex.MatchString(abs) || m.regex.MatchString(realPath(abs))
+}
+
+// match reports whether a file found by a scan is excluded
+func (m *ExcludeMatcher) match(s classMapScan) bool {
+	return m.regex != nil && (m.regex.MatchString(s.realPath) || m.regex.MatchString(s.file))
+}
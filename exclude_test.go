@@ -0,0 +1,145 @@
+package composer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"testing"
+)
+
+func TestExcludeFromClassmapRegex(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"package/src/Foo.php": "<?php\n"})
+	abs, err := absolutePath(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quoted := regexp.QuoteMeta(abs)
+
+	tests := []struct {
+		dir    string
+		path   string
+		want   string
+		wantOk bool
+	}{
+		{dir, "/composersrc/foo/bar/", quoted + `/composersrc/foo/bar($|/)`, true},
+		{dir, "composersrc/ClassToExclude.php", quoted + `/composersrc/ClassToExclude\.php($|/)`, true},
+		{dir, "/composersrc/*/excluded/excsubpath", quoted + `/composersrc/[^/]+?/excluded/excsubpath($|/)`, true},
+		{dir, "**/excsubpath", quoted + `/.+?/excsubpath($|/)`, true},
+		{dir, `src\\Tests//Fixtures`, quoted + `/src/Tests/Fixtures($|/)`, true},
+		{dir + "/package", "./../classmap/excluded", quoted + `/classmap/excluded($|/)`, true},
+		{dir + "/package", "../../" + filepath.Base(dir) + "/package/src", quoted + `/package/src($|/)`, true},
+		{dir + "/package", ".../src", quoted + `/package/\.\.\./src($|/)`, true},
+		{dir, "../missing/../src", regexp.QuoteMeta(filepath.ToSlash(filepath.Dir(abs))) + `/missing/\.\./src($|/)`, true},
+		{dir + "/missing", "src", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, ok := ExcludeFromClassmapRegex(tt.dir, tt.path)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("ExcludeFromClassmapRegex() = %s, %v, want %s, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestExcludeMatcher_Match(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"vendor/acme/lib/src/Foo.php": "<?php\n"})
+	abs, err := absolutePath(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewExcludeMatcher([]AutoloadSource{
+		{Dir: dir, Autoload: Autoload{ExcludeFromClassmap: []string{"composers", "/src-ca/", "tests/*Test.php"}}},
+		{Dir: filepath.Join(dir, "vendor", "acme", "lib"), Autoload: Autoload{ExcludeFromClassmap: []string{"src/**/Fixtures/"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		file string
+		want bool
+	}{
+		{"/composers/Foo.php", true},
+		{"/composers", true},
+		{"/composersrc/Foo.php", false},
+		{"/src-ca/Foo.php", true},
+		{"/src-cake/Foo.php", false},
+		{"/tests/FooTest.php", true},
+		{"/tests/FooTest.php.dist", false},
+		{"/tests/Unit/FooTest.php", false},
+		{"/vendor/acme/lib/src/Foo/Fixtures/Bar.php", true},
+		{"/vendor/acme/lib/src/Fixtures/Bar.php", false},
+		{"/vendor/acme/lib/src/A/B/Fixtures", true},
+		{"/vendor/acme/lib/Fixtures/Bar.php", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			if got := m.Match(abs + tt.file); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestGenerateClassMap_exclude mirrors the fixtures of Composer AutoloadGeneratorTest::testExcludeFromClassmap
+func TestGenerateClassMap_exclude(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"composersrc/foo.php":                              "<?php class ClassMapFoo {}",
+		"composersrc/excludedTests/bar.php":                "<?php class ClassExcludeMapFoo {}",
+		"composersrc/ClassToExclude.php":                   "<?php class ClassClassToExclude {}",
+		"composersrc/long/excluded/excsubpath/foo.php":     "<?php class ClassExcludeMapFoo2 {}",
+		"composersrc/long/excluded/excsubpath/bar.php":     "<?php class ClassExcludeMapBar {}",
+		"composersrc/long/excluded/notexcluded/baz.php":    "<?php class ClassMapBaz {}",
+		"src-cake/ClassMapBar.php":                         "<?php namespace Acme\\Cake;\nclass ClassMapBar {}",
+		"forks/bar/src/exclude/FooExclClass.php":           "<?php class FooExclClass {}",
+		"forks/bar/src/included/FooIncluded.php":           "<?php class FooIncluded {}",
+		"forks/linked/src/exclude/LinkedExcludedClass.php": "<?php class LinkedExcludedClass {}",
+		"package/classmap/excluded/Excluded.php":           "<?php class UpLevelExcluded {}",
+		"package/classmap/Included.php":                    "<?php class UpLevelIncluded {}",
+		"package/lib/lib.php":                              "<?php\n",
+	})
+	// a symlinked directory excluded by its real path and one excluded by its path in the project
+	if err := os.Symlink(filepath.Join(dir, "forks", "bar", "src"), filepath.Join(dir, "composersrc", "bar")); err != nil {
+		t.Skip(err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "forks", "linked", "src"), filepath.Join(dir, "composersrc", "linked")); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := ClassMapGenerator{BaseDir: dir}.Generate([]AutoloadSource{
+		{Dir: dir, Autoload: Autoload{
+			Psr4:     Psr{`Acme\Cake\`: {"src-cake/"}},
+			Classmap: []string{"composersrc/"},
+			ExcludeFromClassmap: []string{
+				"/composersrc/foo/bar/",
+				"/composersrc/excludedTests/",
+				"/composersrc/ClassToExclude.php",
+				"/composersrc/*/excluded/excsubpath",
+				"**/excsubpath",
+				"composers",
+				"/src-ca/",
+				"/forks/bar/src/exclude",
+				"/composersrc/linked/exclude",
+			},
+		}},
+		{Name: "up/level", Dir: filepath.Join(dir, "package", "lib"), Autoload: Autoload{
+			Classmap:            []string{"../classmap"},
+			ExcludeFromClassmap: []string{"./../classmap/excluded"},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	var classes []string
+	for class := range m.Classes {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	want := []string{`Acme\Cake\ClassMapBar`, "ClassMapBaz", "ClassMapFoo", "FooIncluded", "UpLevelIncluded"}
+	if !reflect.DeepEqual(classes, want) {
+		t.Errorf("Generate() classes = %v, want %v", classes, want)
+	}
+}
@@ -0,0 +1,289 @@
+package composer
+
+import "strings"
+
+// DependencyGraph is the directed graph of the packages of a lock file, edges point from a package to its dependencies
+type DependencyGraph struct {
+	root     *poolPackage
+	packages map[string]*poolPackage
+	dev      map[string]bool
+	edges    map[string][]Edge
+}
+
+// Edge of a DependencyGraph. To is the installed package satisfying the requirement of From,
+// Requirement differs from To when To replaces or provides the required name
+type Edge struct {
+	From        string
+	To          string
+	Requirement string
+	Constraint  string
+	Dev         bool
+}
+
+// String render the edge the way composer depends does, e.g. "vendor/a requires vendor/b (^1.0)"
+func (e Edge) String() string {
+	verb := "requires"
+	if e.Dev {
+		verb = "requires (for development)"
+	}
+	return e.From + " " + verb + " " + e.Requirement + " (" + e.Constraint + ")"
+}
+
+// Blocker is a constraint which prevents a package version from being installed
+type Blocker struct {
+	// Source is the package putting the constraint
+	Source        string
+	SourceVersion string
+	// Target is the package the constraint applies to
+	Target     string
+	Constraint string
+	// Reason is either "requires", "requires (for development)" or "conflicts"
+	Reason string
+}
+
+// String render the blocker the way composer prohibits does, e.g. "vendor/a 1.0.0 requires vendor/b (^1.0)"
+func (b Blocker) String() string {
+	source := b.Source
+	if b.SourceVersion != "" {
+		source += " " + b.SourceVersion
+	}
+	return source + " " + b.Reason + " " + b.Target + " (" + b.Constraint + ")"
+}
+
+// NewDependencyGraph build the graph of a lock file, root is the manifest the lock was created from
+func NewDependencyGraph(root *Manifest, lock *Lock) *DependencyGraph {
+	rp := &Package{Manifest: *root}
+	if rp.Name == "" {
+		rp.Name = RootPackageName
+	}
+	rootPackage := &poolPackage{Package: rp, name: strings.ToLower(rp.Name), root: true}
+
+	g := &DependencyGraph{
+		root:     rootPackage,
+		packages: map[string]*poolPackage{},
+		dev:      map[string]bool{},
+		edges:    map[string][]Edge{},
+	}
+	for _, p := range lock.AllPackages() {
+		pp, ok := newPoolPackage(p)
+		if !ok {
+			pp = &poolPackage{Package: p, name: strings.ToLower(p.Name)}
+		}
+		g.packages[pp.name] = pp
+	}
+	for i := range lock.PackagesDev {
+		g.dev[strings.ToLower(lock.PackagesDev[i].Name)] = true
+	}
+
+	g.addEdges(rootPackage, rootPackage.Require, false)
+	g.addEdges(rootPackage, rootPackage.RequireDev, true)
+	for _, name := range g.names() {
+		g.addEdges(g.packages[name], g.packages[name].Require, false)
+	}
+	return g
+}
+
+// addEdges link a package to the installed packages named after, replacing or providing its requirements
+func (g *DependencyGraph) addEdges(from *poolPackage, requires map[string]string, dev bool) {
+	for _, target := range sortedKeys(requires) {
+		for _, to := range g.providers(strings.ToLower(target)) {
+			g.edges[from.name] = append(g.edges[from.name], Edge{From: from.name, To: to, Requirement: strings.ToLower(target), Constraint: requires[target], Dev: dev})
+		}
+	}
+}
+
+// providers return the installed packages named after, replacing or providing a name
+func (g *DependencyGraph) providers(name string) []string {
+	if _, ok := g.packages[name]; ok {
+		return []string{name}
+	}
+	var names []string
+	for _, n := range g.names() {
+		if g.packages[n].provides(name) {
+			names = append(names, n)
+		}
+	}
+	return names
+}
+
+func (g *DependencyGraph) names() []string {
+	return sortedPoolNames(g.packages)
+}
+
+// Dependencies return the direct dependencies of a package
+func (g *DependencyGraph) Dependencies(name string) []Edge {
+	return g.edges[strings.ToLower(name)]
+}
+
+// Dependents return the edges pointing to a package, the equivalent of composer depends without --recursive
+func (g *DependencyGraph) Dependents(name string) []Edge {
+	name = strings.ToLower(name)
+	var dependents []Edge
+	for _, from := range append([]string{g.root.name}, g.names()...) {
+		for _, e := range g.edges[from] {
+			if e.To == name {
+				dependents = append(dependents, e)
+			}
+		}
+	}
+	return dependents
+}
+
+// Why return the paths from the root package to a package, answering why it is installed. Each path starts with
+// an edge from the root and ends with an edge to the package. Packages like psr/log are reached through
+// exponentially many paths in real lock files: with a positive limit at most limit paths are returned and
+// truncated tells whether there are more. WhyEdges return every edge on these paths without enumerating them
+func (g *DependencyGraph) Why(name string, limit int) (paths [][]Edge, truncated bool) {
+	name = strings.ToLower(name)
+	reaches := g.reaching(name)
+	visiting := map[string]bool{g.root.name: true}
+	var walk func(from string, path []Edge)
+	walk = func(from string, path []Edge) {
+		for _, e := range g.edges[from] {
+			if truncated {
+				return
+			}
+			if visiting[e.To] || !reaches[e.To] {
+				continue
+			}
+			next := append(append([]Edge{}, path...), e)
+			if e.To == name {
+				if limit > 0 && len(paths) == limit {
+					truncated = true
+					return
+				}
+				paths = append(paths, next)
+				continue
+			}
+			visiting[e.To] = true
+			walk(e.To, next)
+			delete(visiting, e.To)
+		}
+	}
+	walk(g.root.name, nil)
+	return paths, truncated
+}
+
+// WhyEdges return the edges on the paths from the root package to a package, the predecessor edges
+// composer depends --tree shows. Its cost is linear in the size of the graph
+func (g *DependencyGraph) WhyEdges(name string) []Edge {
+	name = strings.ToLower(name)
+	reaches := g.reaching(name)
+	reached := map[string]bool{g.root.name: true}
+	queue := []string{g.root.name}
+	for len(queue) > 0 {
+		from := queue[0]
+		queue = queue[1:]
+		for _, e := range g.edges[from] {
+			if !reached[e.To] {
+				reached[e.To] = true
+				queue = append(queue, e.To)
+			}
+		}
+	}
+
+	var edges []Edge
+	for _, from := range append([]string{g.root.name}, g.names()...) {
+		if !reached[from] || from == name {
+			continue
+		}
+		for _, e := range g.edges[from] {
+			if reaches[e.To] {
+				edges = append(edges, e)
+			}
+		}
+	}
+	return edges
+}
+
+// reaching return the packages from which a package can be reached, the package included
+func (g *DependencyGraph) reaching(name string) map[string]bool {
+	dependents := map[string][]string{}
+	for from, edges := range g.edges {
+		for _, e := range edges {
+			dependents[e.To] = append(dependents[e.To], from)
+		}
+	}
+	reaches := map[string]bool{name: true}
+	queue := []string{name}
+	for len(queue) > 0 {
+		to := queue[0]
+		queue = queue[1:]
+		for _, from := range dependents[to] {
+			if !reaches[from] {
+				reaches[from] = true
+				queue = append(queue, from)
+			}
+		}
+	}
+	return reaches
+}
+
+// WhyNot return the constraints which prevent a package version from being installed, the equivalent
+// of composer prohibits. The candidate needs a name and a version, its own requirements are checked
+// against the installed packages when they are set
+func (g *DependencyGraph) WhyNot(candidate *Package) ([]Blocker, error) {
+	c, ok := newPoolPackage(candidate)
+	if !ok {
+		_, err := NormalizeVersion(candidate.Version)
+		return nil, err
+	}
+
+	var blockers []Blocker
+	for _, source := range append([]*poolPackage{g.root}, g.sortedPackages()...) {
+		if source.name == c.name {
+			continue
+		}
+		reasons := map[string][]link{"requires": source.links(source.Require)}
+		if source.root {
+			reasons["requires (for development)"] = source.links(source.RequireDev)
+		}
+		for _, reason := range []string{"requires", "requires (for development)"} {
+			for _, l := range reasons[reason] {
+				if l.target == c.name && !c.matches(l.constraint) {
+					blockers = append(blockers, g.blocker(source, l, reason))
+				}
+			}
+		}
+		for _, l := range source.links(source.Conflict) {
+			if l.target == c.name && c.matches(l.constraint) {
+				blockers = append(blockers, g.blocker(source, l, "conflicts"))
+			}
+		}
+	}
+
+	// the requirements and conflicts of the candidate itself against what is installed
+	for _, l := range c.links(c.Require) {
+		if installed, ok := g.packages[l.target]; ok && installed.version != "" && !installed.matches(l.constraint) {
+			blockers = append(blockers, g.blocker(c, l, "requires"))
+		}
+	}
+	for _, l := range c.links(c.Conflict) {
+		if installed, ok := g.packages[l.target]; ok && installed.version != "" && installed.matches(l.constraint) {
+			blockers = append(blockers, g.blocker(c, l, "conflicts"))
+		}
+	}
+	return blockers, nil
+}
+
+func (g *DependencyGraph) blocker(source *poolPackage, l link, reason string) Blocker {
+	b := Blocker{Source: source.name, Target: l.target, Constraint: l.pretty, Reason: reason}
+	if !source.root {
+		b.SourceVersion = source.Version
+	}
+	return b
+}
+
+func (g *DependencyGraph) sortedPackages() []*poolPackage {
+	names := g.names()
+	packages := make([]*poolPackage, len(names))
+	for i, n := range names {
+		packages[i] = g.packages[n]
+	}
+	return packages
+}
+
+// IsDev reports whether a package is only installed for development
+func (g *DependencyGraph) IsDev(name string) bool {
+	return g.dev[strings.ToLower(name)]
+}
@@ -0,0 +1,169 @@
+package composer
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func testGraph(t *testing.T) *DependencyGraph {
+	var lock Lock
+	if err := json.Unmarshal([]byte(testLock), &lock); err != nil {
+		t.Fatalf("UnmarshalJSON() error = %v", err)
+	}
+	root := &Manifest{
+		Name:       "acme/app",
+		Require:    map[string]string{"php": "^7.3", "monolog/monolog": "^2.0", "psr/log-implementation": "^1.0"},
+		RequireDev: map[string]string{"phpunit/phpunit": "^9.0"},
+		Conflict:   map[string]string{"psr/log": ">=2.0"},
+	}
+	return NewDependencyGraph(root, &lock)
+}
+
+func edgeStrings(edges []Edge) []string {
+	s := make([]string, len(edges))
+	for i, e := range edges {
+		s[i] = e.String()
+	}
+	return s
+}
+
+func TestDependencyGraph_Why(t *testing.T) {
+	g := testGraph(t)
+	tests := []struct {
+		name string
+		pkg  string
+		want [][]string
+	}{
+		{"direct and provided", "monolog/monolog", [][]string{
+			{"acme/app requires monolog/monolog (^2.0)"},
+			{"acme/app requires psr/log-implementation (^1.0)"},
+		}},
+		{"transitive", "psr/log", [][]string{
+			{"acme/app requires monolog/monolog (^2.0)", "monolog/monolog requires psr/log (^1.0.1)"},
+			{"acme/app requires psr/log-implementation (^1.0)", "monolog/monolog requires psr/log (^1.0.1)"},
+			{"acme/app requires (for development) phpunit/phpunit (^9.0)", "phpunit/phpunit requires psr/log (^1.0)"},
+		}},
+		{"not installed", "symfony/console", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths, truncated := g.Why(tt.pkg, 0)
+			var got [][]string
+			for _, path := range paths {
+				got = append(got, edgeStrings(path))
+			}
+			if truncated {
+				t.Errorf("Why() truncated without a limit")
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Why() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDependencyGraph_WhyEdges(t *testing.T) {
+	g := testGraph(t)
+	want := []string{
+		"acme/app requires monolog/monolog (^2.0)",
+		"acme/app requires psr/log-implementation (^1.0)",
+		"acme/app requires (for development) phpunit/phpunit (^9.0)",
+		"monolog/monolog requires psr/log (^1.0.1)",
+		"phpunit/phpunit requires psr/log (^1.0)",
+	}
+	if got := edgeStrings(g.WhyEdges("psr/log")); !reflect.DeepEqual(got, want) {
+		t.Errorf("WhyEdges() got = %q, want %q", got, want)
+	}
+}
+
+// TestDependencyGraph_diamonds checks a graph with 20^4 paths to psr/log is answered without enumerating them
+func TestDependencyGraph_diamonds(t *testing.T) {
+	const width, depth = 20, 4
+	root := &Manifest{Name: "acme/app", Require: map[string]string{}}
+	lock := &Lock{}
+	for i := 0; i < width; i++ {
+		root.Require[fmt.Sprintf("l0/p%d", i)] = "*"
+	}
+	for level := 0; level < depth; level++ {
+		for i := 0; i < width; i++ {
+			p := Package{Manifest: Manifest{Name: fmt.Sprintf("l%d/p%d", level, i), Version: "1.0.0", Require: map[string]string{}}}
+			for j := 0; j < width; j++ {
+				p.Require[fmt.Sprintf("l%d/p%d", level+1, j)] = "*"
+			}
+			if level == depth-1 {
+				p.Require = map[string]string{"psr/log": "^1.0"}
+			}
+			lock.Packages = append(lock.Packages, p)
+		}
+	}
+	lock.Packages = append(lock.Packages, Package{Manifest: Manifest{Name: "psr/log", Version: "1.1.4"}})
+	g := NewDependencyGraph(root, lock)
+
+	paths, truncated := g.Why("psr/log", 100)
+	if len(paths) != 100 || !truncated {
+		t.Fatalf("Why() got %d paths, truncated %v, want 100 truncated", len(paths), truncated)
+	}
+	if paths, truncated := g.Why("l3/p0", width*width*width); len(paths) != width*width*width || truncated {
+		t.Errorf("Why() got %d paths, truncated %v, want %d complete", len(paths), truncated, width*width*width)
+	}
+	for _, path := range paths {
+		if len(path) != depth+1 || path[0].From != "acme/app" || path[depth].To != "psr/log" {
+			t.Fatalf("Why() got path %q", edgeStrings(path))
+		}
+	}
+	if got, want := len(g.WhyEdges("psr/log")), width+(depth-1)*width*width+width; got != want {
+		t.Errorf("WhyEdges() got %d edges, want %d", got, want)
+	}
+}
+
+func TestDependencyGraph_Dependents(t *testing.T) {
+	g := testGraph(t)
+	want := []string{"monolog/monolog requires psr/log (^1.0.1)", "phpunit/phpunit requires psr/log (^1.0)"}
+	if got := edgeStrings(g.Dependents("psr/log")); !reflect.DeepEqual(got, want) {
+		t.Errorf("Dependents() got = %q, want %q", got, want)
+	}
+	if !g.IsDev("phpunit/phpunit") || g.IsDev("psr/log") {
+		t.Errorf("IsDev() does not match packages-dev")
+	}
+}
+
+func TestDependencyGraph_WhyNot(t *testing.T) {
+	g := testGraph(t)
+	tests := []struct {
+		name      string
+		candidate *Package
+		want      []string
+		wantErr   bool
+	}{
+		{"compatible", testPackage("psr/log", "1.1.4", nil), nil, false},
+		{"blocked by requirements and conflict", testPackage("psr/log", "2.0.0", nil), []string{
+			"acme/app conflicts psr/log (>=2.0)",
+			"monolog/monolog 2.1.0 requires psr/log (^1.0.1)",
+			"phpunit/phpunit 9.5.0 requires psr/log (^1.0)",
+		}, false},
+		{"blocked by dev requirement", testPackage("phpunit/phpunit", "10.0.0", nil), []string{
+			"acme/app requires (for development) phpunit/phpunit (^9.0)",
+		}, false},
+		{"own requirement", testPackage("monolog/monolog", "2.2.0", map[string]string{"require:psr/log": "^2.0"}), []string{
+			"monolog/monolog 2.2.0 requires psr/log (^2.0)",
+		}, false},
+		{"invalid version", testPackage("psr/log", "foo", nil), nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blockers, err := g.WhyNot(tt.candidate)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WhyNot() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, b := range blockers {
+				got = append(got, b.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("WhyNot() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
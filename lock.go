@@ -0,0 +1,124 @@
+package composer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Lock of composer.lock
+type Lock struct {
+	Readme            []string        `json:"_readme,omitempty"`
+	ContentHash       string          `json:"content-hash"`
+	Packages          []Package       `json:"packages"`
+	PackagesDev       []Package       `json:"packages-dev"`
+	Aliases           []LockAlias     `json:"aliases"`
+	MinimumStability  string          `json:"minimum-stability"`
+	StabilityFlags    StabilityFlags  `json:"stability-flags"`
+	PreferStable      Bool            `json:"prefer-stable"`
+	PreferLowest      Bool            `json:"prefer-lowest"`
+	Platform          PlatformVersion `json:"platform"`
+	PlatformDev       PlatformVersion `json:"platform-dev"`
+	PlatformOverrides PlatformVersion `json:"platform-overrides,omitempty"`
+	PluginApiVersion  string          `json:"plugin-api-version,omitempty"`
+}
+
+// LockAlias is an inline alias of a root requirement, e.g. "dev-main as 1.0.0"
+type LockAlias struct {
+	Package         string `json:"package"`
+	Version         string `json:"version"`
+	Alias           string `json:"alias"`
+	AliasNormalized string `json:"alias_normalized"`
+}
+
+// AllPackages return the packages followed by the dev packages
+func (l *Lock) AllPackages() []*Package {
+	all := make([]*Package, 0, len(l.Packages)+len(l.PackagesDev))
+	for i := range l.Packages {
+		all = append(all, &l.Packages[i])
+	}
+	for i := range l.PackagesDev {
+		all = append(all, &l.PackagesDev[i])
+	}
+	return all
+}
+
+// FindPackage return a locked package by name and whether it is a dev package
+func (l *Lock) FindPackage(name string) (*Package, bool) {
+	for i := range l.Packages {
+		if strings.EqualFold(l.Packages[i].Name, name) {
+			return &l.Packages[i], false
+		}
+	}
+	for i := range l.PackagesDev {
+		if strings.EqualFold(l.PackagesDev[i].Name, name) {
+			return &l.PackagesDev[i], true
+		}
+	}
+	return nil, false
+}
+
+// StabilityFlags convert an object or an empty array into a map of package names to stabilities
+// Example values
+//
+//	"stability-flags": {
+//	    "vendor/name": 20
+//	}
+//
+// or
+// "stability-flags": []
+type StabilityFlags map[string]int
+
+// MarshalJSON marshal JSON into an object
+func (f StabilityFlags) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]int(f))
+}
+
+// UnmarshalJSON convert an object or an empty array into a map
+func (f *StabilityFlags) UnmarshalJSON(bytes []byte) error {
+	var m map[string]int
+	if err := unmarshalObjectOrEmptyArray(bytes, &m); err != nil {
+		return err
+	}
+	*f = m
+	return nil
+}
+
+// PlatformVersion convert an object or an empty array into a map of platform packages to versions
+// Example values
+//
+//	"platform": {
+//	    "php": ">=7.2"
+//	}
+//
+// or
+// "platform": []
+type PlatformVersion map[string]string
+
+// MarshalJSON marshal JSON into an object
+func (p PlatformVersion) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string(p))
+}
+
+// UnmarshalJSON convert an object or an empty array into a map
+func (p *PlatformVersion) UnmarshalJSON(bytes []byte) error {
+	var m map[string]string
+	if err := unmarshalObjectOrEmptyArray(bytes, &m); err != nil {
+		return err
+	}
+	*p = m
+	return nil
+}
+
+// unmarshalObjectOrEmptyArray decode an object into v, PHP writes empty objects as []
+func unmarshalObjectOrEmptyArray(bytes []byte, v interface{}) error {
+	if err := json.Unmarshal(bytes, v); err == nil {
+		return nil
+	}
+	var arr []interface{}
+	if err := json.Unmarshal(bytes, &arr); err == nil && len(arr) == 0 {
+		return nil
+	}
+	return errors.New(fmt.Sprintf("cannot unmarshal %s", bytes))
+}
@@ -0,0 +1,132 @@
+package composer
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const testLock = `{
+    "_readme": [
+        "This file locks the dependencies of your project to a known state"
+    ],
+    "content-hash": "e3b0c44298fc1c149afbf4c8996fb924",
+    "packages": [
+        {
+            "name": "monolog/monolog",
+            "version": "2.1.0",
+            "source": {"type": "git", "url": "https://github.com/Seldaek/monolog.git", "reference": "38914429aac460e8e4616c8cb486ecb40ec90bb1"},
+            "require": {"php": ">=7.2", "psr/log": "^1.0.1"},
+            "provide": {"psr/log-implementation": "1.0.0"},
+            "type": "library",
+            "time": "2020-05-22T08:12:19+00:00"
+        },
+        {
+            "name": "psr/log",
+            "version": "1.1.3",
+            "require": {"php": ">=5.3.0"},
+            "type": "library"
+        }
+    ],
+    "packages-dev": [
+        {
+            "name": "phpunit/phpunit",
+            "version": "9.5.0",
+            "require": {"php": ">=7.3", "psr/log": "^1.0"},
+            "type": "library"
+        }
+    ],
+    "aliases": [],
+    "minimum-stability": "stable",
+    "stability-flags": [],
+    "prefer-stable": false,
+    "prefer-lowest": false,
+    "platform": {
+        "php": "^7.3 || ^8.0"
+    },
+    "platform-dev": [],
+    "plugin-api-version": "2.0.0"
+}`
+
+func TestLock_UnmarshalJSON(t *testing.T) {
+	var lock Lock
+	if err := json.Unmarshal([]byte(testLock), &lock); err != nil {
+		t.Fatalf("UnmarshalJSON() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"content-hash", lock.ContentHash, "e3b0c44298fc1c149afbf4c8996fb924"},
+		{"packages", len(lock.Packages), 2},
+		{"packages-dev", len(lock.PackagesDev), 1},
+		{"empty stability-flags", lock.StabilityFlags, StabilityFlags(nil)},
+		{"platform", lock.Platform, PlatformVersion{"php": "^7.3 || ^8.0"}},
+		{"empty platform-dev", lock.PlatformDev, PlatformVersion(nil)},
+		{"source", lock.Packages[0].Source.Reference, "38914429aac460e8e4616c8cb486ecb40ec90bb1"},
+		{"plugin-api-version", lock.PluginApiVersion, "2.0.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("UnmarshalJSON() got = %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLock_FindPackage(t *testing.T) {
+	var lock Lock
+	if err := json.Unmarshal([]byte(testLock), &lock); err != nil {
+		t.Fatalf("UnmarshalJSON() error = %v", err)
+	}
+	tests := []struct {
+		name    string
+		pkg     string
+		want    string
+		wantDev bool
+	}{
+		{"package", "psr/log", "1.1.3", false},
+		{"dev package", "phpunit/phpunit", "9.5.0", true},
+		{"case insensitive", "Monolog/Monolog", "2.1.0", false},
+		{"missing", "symfony/console", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, dev := lock.FindPackage(tt.pkg)
+			got := ""
+			if p != nil {
+				got = p.Version
+			}
+			if got != tt.want || dev != tt.wantDev {
+				t.Errorf("FindPackage() got = %v %v, want %v %v", got, dev, tt.want, tt.wantDev)
+			}
+		})
+	}
+}
+
+func TestStabilityFlags_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    StabilityFlags
+		wantErr bool
+	}{
+		{"object", `{"vendor/name":20}`, StabilityFlags{"vendor/name": 20}, false},
+		{"empty array", `[]`, nil, false},
+		{"non empty array", `[20]`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got StabilityFlags
+			if err := got.UnmarshalJSON([]byte(tt.data)); (err != nil) != tt.wantErr {
+				t.Errorf("UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSON() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package composer
+
+import (
+	"sort"
+	"strings"
+)
+
+// Kinds of a LockChange besides ChangeAdded, ChangeRemoved, ChangeChanged and ChangeMoved
+const (
+	ChangeUpgraded   = "upgraded"
+	ChangeDowngraded = "downgraded"
+)
+
+// Levels of a version change
+const (
+	LevelMajor = "major"
+	LevelMinor = "minor"
+	LevelPatch = "patch"
+)
+
+// LockChange is the change of one package between two lock files.
+// Kind is ChangeChanged when only the source or dist reference changed, e.g. a new commit on a branch, or when
+// the package switched between branches or between a branch and a tag, and ChangeMoved when the package only moved between packages and packages-dev
+type LockChange struct {
+	Name       string `json:"name"`
+	Kind       string `json:"kind"`
+	OldVersion string `json:"old-version,omitempty"`
+	NewVersion string `json:"new-version,omitempty"`
+	// Level is major, minor or patch for upgrades and downgrades between tagged versions, empty otherwise
+	Level        string `json:"level,omitempty"`
+	OldSourceRef string `json:"old-source-reference,omitempty"`
+	NewSourceRef string `json:"new-source-reference,omitempty"`
+	OldDistRef   string `json:"old-dist-reference,omitempty"`
+	NewDistRef   string `json:"new-dist-reference,omitempty"`
+	// OldDev and NewDev report whether the package is in packages-dev, Moved when they differ
+	OldDev bool `json:"old-dev"`
+	NewDev bool `json:"new-dev"`
+	Moved  bool `json:"moved"`
+}
+
+// String render the change for humans, e.g. "monolog/monolog upgraded from 2.1.0 to 3.0.0 (major)"
+func (c LockChange) String() string {
+	s := c.Name + " " + c.Kind
+	switch c.Kind {
+	case ChangeAdded:
+		s += " " + c.NewVersion
+	case ChangeRemoved:
+		s += " " + c.OldVersion
+	case ChangeUpgraded, ChangeDowngraded:
+		s += " from " + c.OldVersion + " to " + c.NewVersion
+		if c.Level != "" {
+			s += " (" + c.Level + ")"
+		}
+	case ChangeChanged:
+		if c.OldVersion != c.NewVersion {
+			s += " from " + c.OldVersion + " to " + c.NewVersion
+		} else {
+			s += " " + c.NewVersion + " from " + shortReference(c.oldRef()) + " to " + shortReference(c.newRef())
+		}
+	}
+	if c.Moved {
+		if c.Kind != ChangeMoved {
+			s += ", moved"
+		}
+		if c.NewDev {
+			s += " to packages-dev"
+		} else {
+			s += " to packages"
+		}
+	}
+	return s
+}
+
+func (c LockChange) oldRef() string {
+	if c.OldSourceRef != "" {
+		return c.OldSourceRef
+	}
+	return c.OldDistRef
+}
+
+func (c LockChange) newRef() string {
+	if c.NewSourceRef != "" {
+		return c.NewSourceRef
+	}
+	return c.NewDistRef
+}
+
+// shortReference abbreviate a commit hash the way git does
+func shortReference(ref string) string {
+	if len(ref) == 40 {
+		return ref[:7]
+	}
+	return ref
+}
+
+// LockDiff lists the changed packages between two lock files sorted by name
+type LockDiff []LockChange
+
+// String render one change per line
+func (d LockDiff) String() string {
+	var b strings.Builder
+	for _, c := range d {
+		b.WriteString(c.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// DiffLocks return the packages added, removed, upgraded, downgraded, changed or moved from lock a to lock b
+func DiffLocks(a, b *Lock) LockDiff {
+	before, after := lockedPackages(a), lockedPackages(b)
+	names := make([]string, 0, len(before)+len(after))
+	for name := range before {
+		names = append(names, name)
+	}
+	for name := range after {
+		if _, ok := before[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var d LockDiff
+	for _, name := range names {
+		old, inA := before[name]
+		cur, inB := after[name]
+		c := LockChange{Name: name}
+		if inA {
+			c.OldVersion, c.OldDev = old.Version, old.dev
+			c.OldSourceRef, c.OldDistRef = references(old.Package)
+		}
+		if inB {
+			c.NewVersion, c.NewDev = cur.Version, cur.dev
+			c.NewSourceRef, c.NewDistRef = references(cur.Package)
+		}
+
+		switch {
+		case !inA:
+			c.Kind = ChangeAdded
+		case !inB:
+			c.Kind = ChangeRemoved
+		default:
+			c.Moved = c.OldDev != c.NewDev
+			c.Kind, c.Level = compareLocked(old.Package, cur.Package)
+			if c.Kind == "" && (c.OldSourceRef != c.NewSourceRef || c.OldDistRef != c.NewDistRef) {
+				c.Kind = ChangeChanged
+			}
+			if c.Kind == "" && c.Moved {
+				c.Kind = ChangeMoved
+			}
+			if c.Kind == "" {
+				continue
+			}
+		}
+		d = append(d, c)
+	}
+	return d
+}
+
+type lockedPackage struct {
+	*Package
+	dev bool
+}
+
+func lockedPackages(l *Lock) map[string]lockedPackage {
+	packages := map[string]lockedPackage{}
+	for i := range l.Packages {
+		packages[strings.ToLower(l.Packages[i].Name)] = lockedPackage{&l.Packages[i], false}
+	}
+	for i := range l.PackagesDev {
+		packages[strings.ToLower(l.PackagesDev[i].Name)] = lockedPackage{&l.PackagesDev[i], true}
+	}
+	return packages
+}
+
+func references(p *Package) (source string, dist string) {
+	if p.Source != nil {
+		source = p.Source.Reference
+	}
+	if p.Dist != nil {
+		dist = p.Dist.Reference
+	}
+	return source, dist
+}
+
+// compareLocked return the kind and level of a version change, both are empty when the version did not change.
+// Branches are not ordered, a change from or to a branch is ChangeChanged without a level
+func compareLocked(a, b *Package) (kind string, level string) {
+	va, vb := lockedVersion(a), lockedVersion(b)
+	if va == vb {
+		return "", ""
+	}
+	if isBranch(va) || isBranch(vb) {
+		return ChangeChanged, ""
+	}
+	switch CompareVersions(va, vb) {
+	case -1:
+		kind = ChangeUpgraded
+	case 1:
+		kind = ChangeDowngraded
+	default:
+		return "", ""
+	}
+	return kind, versionChangeLevel(va, vb)
+}
+
+// isBranch reports whether a normalized version is a branch, e.g. "dev-main" or "2.1.9999999.9999999-dev"
+func isBranch(version string) bool {
+	return strings.HasPrefix(version, "dev-") || strings.HasSuffix(version, "-dev")
+}
+
+func lockedVersion(p *Package) string {
+	if p.VersionNormalized != "" {
+		return p.VersionNormalized
+	}
+	if normalized, err := NormalizeVersion(p.Version); err == nil {
+		return normalized
+	}
+	return p.Version
+}
+
+// versionChangeLevel return the first differing segment of two normalized versions, e.g. "1.2.0.0" and "1.3.0.0" is minor
+func versionChangeLevel(a, b string) string {
+	pa, pb := strings.SplitN(a, ".", 4), strings.SplitN(b, ".", 4)
+	for i, level := range []string{LevelMajor, LevelMinor} {
+		if i >= len(pa) || i >= len(pb) || pa[i] != pb[i] {
+			return level
+		}
+	}
+	return LevelPatch
+}
@@ -0,0 +1,107 @@
+package composer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func lockedTestPackage(name, version, ref string) Package {
+	p := testPackage(name, version, nil)
+	p.Source = &Source{Type: "git", Url: "https://github.com/" + name + ".git", Reference: ref}
+	p.Dist = &Dist{Type: "zip", Url: "https://api.github.com/repos/" + name + "/zipball/" + ref, Reference: ref}
+	return *p
+}
+
+func TestDiffLocks(t *testing.T) {
+	a := &Lock{
+		Packages: []Package{
+			lockedTestPackage("monolog/monolog", "2.9.1", "aaaaaaa"),
+			lockedTestPackage("psr/log", "1.1.4", "bbbbbbb"),
+			lockedTestPackage("symfony/console", "v6.3.0", "ccccccc"),
+			lockedTestPackage("symfony/string", "v6.3.0", "ddddddd"),
+			lockedTestPackage("acme/lib", "dev-main", "1111111111111111111111111111111111111111"),
+			lockedTestPackage("acme/tool", "1.0.0", "eeeeeee"),
+			lockedTestPackage("acme/feature", "dev-main", "hhhhhhh"),
+			lockedTestPackage("acme/tagged", "2.x-dev", "iiiiiii"),
+		},
+		PackagesDev: []Package{
+			lockedTestPackage("phpunit/phpunit", "10.1.0", "fffffff"),
+		},
+	}
+	b := &Lock{
+		Packages: []Package{
+			lockedTestPackage("monolog/monolog", "3.0.0", "a2"),
+			lockedTestPackage("symfony/console", "v6.2.5", "c2"),
+			lockedTestPackage("symfony/string", "v6.3.1", "d2"),
+			lockedTestPackage("acme/lib", "dev-main", "2222222222222222222222222222222222222222"),
+			lockedTestPackage("psr/container", "2.0.2", "ggggggg"),
+			lockedTestPackage("acme/feature", "dev-feature", "h2"),
+			lockedTestPackage("acme/tagged", "1.0.0", "i2"),
+		},
+		PackagesDev: []Package{
+			lockedTestPackage("phpunit/phpunit", "10.2.0", "f2"),
+			lockedTestPackage("acme/tool", "1.0.0", "eeeeeee"),
+		},
+	}
+
+	got := DiffLocks(a, b)
+	want := LockDiff{
+		{Name: "acme/feature", Kind: ChangeChanged, OldVersion: "dev-main", NewVersion: "dev-feature",
+			OldSourceRef: "hhhhhhh", NewSourceRef: "h2", OldDistRef: "hhhhhhh", NewDistRef: "h2"},
+		{Name: "acme/lib", Kind: ChangeChanged, OldVersion: "dev-main", NewVersion: "dev-main",
+			OldSourceRef: "1111111111111111111111111111111111111111", NewSourceRef: "2222222222222222222222222222222222222222",
+			OldDistRef: "1111111111111111111111111111111111111111", NewDistRef: "2222222222222222222222222222222222222222"},
+		{Name: "acme/tagged", Kind: ChangeChanged, OldVersion: "2.x-dev", NewVersion: "1.0.0",
+			OldSourceRef: "iiiiiii", NewSourceRef: "i2", OldDistRef: "iiiiiii", NewDistRef: "i2"},
+		{Name: "acme/tool", Kind: ChangeMoved, OldVersion: "1.0.0", NewVersion: "1.0.0",
+			OldSourceRef: "eeeeeee", NewSourceRef: "eeeeeee", OldDistRef: "eeeeeee", NewDistRef: "eeeeeee", NewDev: true, Moved: true},
+		{Name: "monolog/monolog", Kind: ChangeUpgraded, Level: LevelMajor, OldVersion: "2.9.1", NewVersion: "3.0.0",
+			OldSourceRef: "aaaaaaa", NewSourceRef: "a2", OldDistRef: "aaaaaaa", NewDistRef: "a2"},
+		{Name: "phpunit/phpunit", Kind: ChangeUpgraded, Level: LevelMinor, OldVersion: "10.1.0", NewVersion: "10.2.0",
+			OldSourceRef: "fffffff", NewSourceRef: "f2", OldDistRef: "fffffff", NewDistRef: "f2", OldDev: true, NewDev: true},
+		{Name: "psr/container", Kind: ChangeAdded, NewVersion: "2.0.2", NewSourceRef: "ggggggg", NewDistRef: "ggggggg"},
+		{Name: "psr/log", Kind: ChangeRemoved, OldVersion: "1.1.4", OldSourceRef: "bbbbbbb", OldDistRef: "bbbbbbb"},
+		{Name: "symfony/console", Kind: ChangeDowngraded, Level: LevelMinor, OldVersion: "v6.3.0", NewVersion: "v6.2.5",
+			OldSourceRef: "ccccccc", NewSourceRef: "c2", OldDistRef: "ccccccc", NewDistRef: "c2"},
+		{Name: "symfony/string", Kind: ChangeUpgraded, Level: LevelPatch, OldVersion: "v6.3.0", NewVersion: "v6.3.1",
+			OldSourceRef: "ddddddd", NewSourceRef: "d2", OldDistRef: "ddddddd", NewDistRef: "d2"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffLocks() got = %+v\nwant %+v", got, want)
+	}
+
+	wantString := `acme/feature changed from dev-main to dev-feature
+acme/lib changed dev-main from 1111111 to 2222222
+acme/tagged changed from 2.x-dev to 1.0.0
+acme/tool moved to packages-dev
+monolog/monolog upgraded from 2.9.1 to 3.0.0 (major)
+phpunit/phpunit upgraded from 10.1.0 to 10.2.0 (minor)
+psr/container added 2.0.2
+psr/log removed 1.1.4
+symfony/console downgraded from v6.3.0 to v6.2.5 (minor)
+symfony/string upgraded from v6.3.0 to v6.3.1 (patch)
+`
+	if got.String() != wantString {
+		t.Errorf("String() got = %v, want %v", got.String(), wantString)
+	}
+}
+
+func Test_versionChangeLevel(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{"1.0.0.0", "2.0.0.0", LevelMajor},
+		{"1.0.0.0", "1.1.0.0", LevelMinor},
+		{"1.0.0.0", "1.0.1.0", LevelPatch},
+		{"1.0.0.0", "1.0.0.1", LevelPatch},
+		{"1.0.0.0-beta1", "1.0.0.0", LevelPatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			if got := versionChangeLevel(tt.a, tt.b); got != tt.want {
+				t.Errorf("versionChangeLevel() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,9 +1,13 @@
 package composer
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
 )
 
 // Manifest of composer.json based on https://getcomposer.org/schema.json
@@ -16,7 +20,7 @@ type Manifest struct {
 	Homepage            string                     `json:"homepage,omitempty"`
 	Readme              string                     `json:"readme,omitempty"`
 	Version             string                     `json:"version,omitempty"`
-	Time                []string                   `json:"time,omitempty"`
+	Time                string                     `json:"time,omitempty"`
 	License             StringOrStrings            `json:"license,omitempty"`
 	Authors             []Author                   `json:"authors,omitempty"`
 	Require             map[string]string          `json:"require,omitempty"`
@@ -36,13 +40,13 @@ type Manifest struct {
 	IncludePath         []string                   `json:"include-path,omitempty"`
 	Scripts             map[string]StringOrStrings `json:"scripts,omitempty"`
 	ScriptsDescriptions map[string]string          `json:"scripts-descriptions,omitempty"`
-	Support             []Support                  `json:"support,omitempty"`
-	Funding             Funding                    `json:"funding,omitempty"`
+	Support             Support                    `json:"support,omitempty"`
+	Funding             []Funding                  `json:"funding,omitempty"`
 	NonFeatureBranches  []string                   `json:"non-feature-branches,omitempty"`
 	DefaultBranch       Bool                       `json:"default-branch,omitempty"`
 	Abandoned           BoolOrString               `json:"abandoned,omitempty"`
 	Comment             StringOrStrings            `json:"_comment,omitempty"`
-	//Extra               TODO     `json:"extra,omitempty"` // Todo implement both map[string]interface{} and []interface{}
+	Extra               Extra                      `json:"extra,omitempty"`
 }
 
 // StringOrStrings convert "string" or array of "strings" into []string
@@ -70,6 +74,46 @@ func (l *StringOrStrings) UnmarshalJSON(bytes []byte) error {
 	return errors.New(fmt.Sprintf("cannot unmarshal %s", bytes))
 }
 
+// Extra convert an object or an array of arbitrary values into a map
+// Arrays are keyed by their index the same way PHP does
+// Example values
+//
+//	"extra": {
+//	           "branch-alias": {
+//	               "dev-main": "1.0.x-dev"
+//	           }
+//	       }
+//
+// or
+// "extra": []
+type Extra map[string]interface{}
+
+// MarshalJSON marshal JSON into an object
+func (e Extra) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}(e))
+}
+
+// UnmarshalJSON convert an object or an array into a map
+func (e *Extra) UnmarshalJSON(bytes []byte) error {
+	var m map[string]interface{}
+	if err := json.Unmarshal(bytes, &m); err == nil {
+		*e = m
+		return nil
+	}
+
+	var arr []interface{}
+	if err := json.Unmarshal(bytes, &arr); err == nil {
+		m = make(map[string]interface{}, len(arr))
+		for i, v := range arr {
+			m[strconv.Itoa(i)] = v
+		}
+		*e = m
+		return nil
+	}
+
+	return errors.New(fmt.Sprintf("cannot unmarshal %s", bytes))
+}
+
 type Author struct {
 	Name     string `json:"name,omitempty"`
 	Email    string `json:"email,omitempty"`
@@ -133,6 +177,58 @@ type Config struct {
 	SortPackages          Bool              `json:"sort-packages,omitempty"`
 	Lock                  Bool              `json:"lock,omitempty"`
 	PlatformCheck         BoolOrString      `json:"platform-check,omitempty"`
+	// keys of the decoded config, a key set to false is told apart from a missing key
+	keys map[string]bool
+}
+
+// MarshalJSON encode the keys of the config with a value and the decoded keys, an explicit false is kept
+func (c Config) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("{")
+	v, t := reflect.ValueOf(c), reflect.TypeOf(c)
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" {
+			continue
+		}
+		field := v.Field(i)
+		empty := field.IsZero() || (field.Kind() == reflect.Map || field.Kind() == reflect.Slice) && field.Len() == 0
+		if empty && !c.keys[name] {
+			continue
+		}
+		data, err := json.Marshal(field.Interface())
+		if err != nil {
+			return nil, err
+		}
+		if b.Len() > 1 {
+			b.WriteString(",")
+		}
+		b.WriteString(strconv.Quote(name) + ":")
+		b.Write(data)
+	}
+	b.WriteString("}")
+	return b.Bytes(), nil
+}
+
+// UnmarshalJSON decode the config and record its keys
+func (c *Config) UnmarshalJSON(bytes []byte) error {
+	type config Config
+	var v config
+	if err := json.Unmarshal(bytes, &v); err != nil {
+		return err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(bytes, &raw); err != nil {
+		return err
+	}
+	*c = Config(v)
+	for k := range raw {
+		if c.keys == nil {
+			c.keys = map[string]bool{}
+		}
+		c.keys[k] = true
+	}
+	return nil
 }
 
 // Bool convert string, integer or bool variations into a boolean
@@ -377,9 +473,14 @@ func (p *Repositories) UnmarshalJSON(bytes []byte) error {
 	}
 	var m map[string]Repository
 	if err := json.Unmarshal(bytes, &m); err == nil {
-		arr := make([]Repository, 0)
-		for _, v := range m {
-			arr = append(arr, v)
+		// the repositories keep the order of their keys, Composer looks packages up in this order
+		doc, err := decodeOrdered(bytes)
+		if err != nil {
+			return err
+		}
+		arr := make([]Repository, 0, len(m))
+		for _, k := range doc.(*jsonObject).keys {
+			arr = append(arr, m[k])
 		}
 		*p = arr
 		return nil
@@ -1,6 +1,7 @@
 package composer
 
 import (
+	"encoding/json"
 	"reflect"
 	"testing"
 )
@@ -282,6 +283,33 @@ func TestBool_UnmarshalJSON(t *testing.T) {
 	}
 }
 
+func TestConfig_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"empty", `{}`, `{}`},
+		{"values", `{"vendor-dir": "lib", "sort-packages": true}`, `{"vendor-dir":"lib","sort-packages":true}`},
+		{"explicit false", `{"sort-packages": false, "store-auths": false, "platform": {}}`, `{"store-auths":false,"platform":{},"sort-packages":false}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c Config
+			if err := json.Unmarshal([]byte(tt.data), &c); err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.Marshal(c)
+			if err != nil {
+				t.Fatalf("MarshalJSON() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("MarshalJSON() got = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestPsr_MarshalJSON(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -374,6 +402,7 @@ func TestRepositories_UnmarshalJSON(t *testing.T) {
 	}{
 		{"Array", Repositories{Repository{Type: "test"}}, args{[]byte("[{\"type\":\"test\"}]")}, false},
 		{"Object", Repositories{Repository{Type: "test"}}, args{[]byte("{\"1\":{\"type\":\"test\"}}")}, false},
+		{"Object order", Repositories{{Type: "b"}, {Type: "a"}, {Type: "c"}}, args{[]byte(`{"z":{"type":"b"},"y":{"type":"a"},"x":{"type":"c"}}`)}, false},
 		{"Error", Repositories{}, args{[]byte("\"Nothing\"")}, true},
 	}
 	for _, tt := range tests {
@@ -0,0 +1,309 @@
+package composer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MergeConflict is a value ours and theirs changed differently in a three-way merge.
+// Base, Ours and Theirs are compact JSON, or versions for locked packages, and empty when the value is absent
+type MergeConflict struct {
+	// Path of the value, e.g. "require.monolog/monolog" or "packages.monolog/monolog"
+	Path   string
+	Base   string
+	Ours   string
+	Theirs string
+}
+
+// String render the conflict for humans, e.g. "require.monolog/monolog: base "^1.0", ours "^2.0", theirs "^3.0""
+func (c MergeConflict) String() string {
+	show := func(s string) string {
+		if s == "" {
+			return "(absent)"
+		}
+		return s
+	}
+	return c.Path + ": base " + show(c.Base) + ", ours " + show(c.Ours) + ", theirs " + show(c.Theirs)
+}
+
+// compactEncoder is used to compare values and to render conflicts
+var compactEncoder = &phpEncoder{unescapedSlashes: true, unescapedUnicode: true}
+
+// composerEncoder encode like Composer JsonFile::encode, the trailing newline is added when writing files
+var composerEncoder = &phpEncoder{pretty: true, indent: "    ", unescapedSlashes: true, unescapedUnicode: true}
+
+func encodeCompact(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return string(compactEncoder.encode(v))
+}
+
+func orderedEqual(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return encodeCompact(a) == encodeCompact(b)
+}
+
+// mergeJSON merge two versions of an ordered JSON value changed from a common base, nil is an absent value.
+// Objects are merged key by key keeping the order of ours, see mergedKeys.
+// When both sides changed a value differently it is a conflict and ours is kept
+func mergeJSON(path string, base, ours, theirs interface{}) (interface{}, []MergeConflict) {
+	switch {
+	case orderedEqual(ours, theirs):
+		return ours, nil
+	case orderedEqual(base, ours):
+		return theirs, nil
+	case orderedEqual(base, theirs):
+		return ours, nil
+	}
+
+	o, oursObject := ours.(*jsonObject)
+	t, theirsObject := theirs.(*jsonObject)
+	b, baseObject := base.(*jsonObject)
+	if base == nil {
+		b, baseObject = newJSONObject(), true
+	}
+	if !oursObject || !theirsObject || !baseObject {
+		return ours, []MergeConflict{{Path: path, Base: encodeCompact(base), Ours: encodeCompact(ours), Theirs: encodeCompact(theirs)}}
+	}
+
+	merged := newJSONObject()
+	var conflicts []MergeConflict
+	for _, k := range mergedKeys(o, t) {
+		v, c := mergeJSON(joinPath(path, k), b.values[k], o.values[k], t.values[k])
+		conflicts = append(conflicts, c...)
+		if v == nil {
+			continue
+		}
+		merged.set(k, v)
+		if text, ok := o.texts[k]; ok && orderedEqual(v, o.values[k]) {
+			if merged.texts == nil {
+				merged.texts = map[string][]byte{}
+			}
+			merged.texts[k] = text
+		}
+	}
+	return merged, conflicts
+}
+
+// mergedKeys return the keys of ours followed by the keys only theirs has, each of them is placed
+// right after the key preceding it in theirs, so a key added to a sorted object stays sorted
+func mergedKeys(ours, theirs *jsonObject) []string {
+	keys := append([]string{}, ours.keys...)
+	for i, k := range theirs.keys {
+		if _, ok := ours.values[k]; ok {
+			continue
+		}
+		at := 0
+		for j := i - 1; j >= 0 && at == 0; j-- {
+			for p, key := range keys {
+				if key == theirs.keys[j] {
+					at = p + 1
+					break
+				}
+			}
+		}
+		keys = append(keys[:at], append([]string{k}, keys[at:]...)...)
+	}
+	return keys
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// MergeManifests merge the composer.json documents ours and theirs changed from base, the way a git merge driver does.
+// Keys edited on one side only, e.g. a requirement, a script or an autoload namespace, are merged and a value
+// both sides changed differently is a conflict where ours is kept. The formatting of ours is preserved:
+// its indentation, its key order and the text of every object which is the same as in ours
+func MergeManifests(base, ours, theirs []byte) ([]byte, []MergeConflict, error) {
+	for _, data := range [][]byte{base, ours, theirs} {
+		if len(strings.TrimSpace(string(data))) == 0 {
+			continue
+		}
+		var m Manifest
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, nil, err
+		}
+	}
+	b, err := objectDocument(base)
+	if err != nil {
+		return nil, nil, err
+	}
+	o, err := decodeOrderedText(ours)
+	if err != nil {
+		return nil, nil, err
+	}
+	t, err := decodeOrdered(theirs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch {
+	case orderedEqual(o, t), orderedEqual(b, t):
+		return ours, nil, nil
+	case orderedEqual(b, o):
+		return theirs, nil, nil
+	}
+	merged, conflicts := mergeJSON("", b, o, t)
+	e := &phpEncoder{pretty: true, indent: detectIndent(ours), unescapedSlashes: true, unescapedUnicode: true, raw: true}
+	result := e.encode(merged)
+	if strings.HasSuffix(string(ours), "\n") {
+		result = append(result, '\n')
+	}
+	return result, conflicts, nil
+}
+
+// detectIndent return the indentation of the first indented key of a document, 4 spaces by default
+func detectIndent(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed != line && strings.HasPrefix(trimmed, `"`) {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+	return "    "
+}
+
+// objectDocument decode a document holding an object, an empty document is an empty object
+func objectDocument(data []byte) (*jsonObject, error) {
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return newJSONObject(), nil
+	}
+	doc, err := decodeOrdered(data)
+	if err != nil {
+		return nil, err
+	}
+	o, ok := doc.(*jsonObject)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %s", data))
+	}
+	return o, nil
+}
+
+// MergeLocks merge the composer.lock documents ours and theirs changed from base, the way a git merge driver does.
+// Packages are merged one by one whether they are in packages or packages-dev, other keys are merged value by value.
+// A package both sides changed differently is a conflict and the version of ours is kept.
+// The content-hash is computed from composerJSON, the merged composer.json, when it is given
+func MergeLocks(base, ours, theirs, composerJSON []byte) ([]byte, []MergeConflict, error) {
+	var docs [3]*jsonObject
+	for i, data := range [][]byte{base, ours, theirs} {
+		doc, err := objectDocument(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		docs[i] = doc
+	}
+	b, o, t := docs[0], docs[1], docs[2]
+
+	packages, devPackages, conflicts := mergeLockedPackages(b, o, t)
+	merged := newJSONObject()
+	for _, k := range mergedKeys(o, t) {
+		switch k {
+		case "packages":
+			merged.set(k, packages)
+		case "packages-dev":
+			merged.set(k, devPackages)
+		default:
+			v, c := mergeJSON(k, b.values[k], o.values[k], t.values[k])
+			if k == "content-hash" && composerJSON != nil {
+				hash, err := ContentHash(composerJSON)
+				if err != nil {
+					return nil, nil, err
+				}
+				v, c = json.RawMessage(`"`+hash+`"`), nil
+			}
+			conflicts = append(conflicts, c...)
+			if v != nil {
+				merged.set(k, v)
+			}
+		}
+	}
+	return append(composerEncoder.encode(merged), '\n'), conflicts, nil
+}
+
+type lockedNode struct {
+	value interface{}
+	dev   bool
+}
+
+// mergeLockedPackages merge packages and packages-dev, both sorted by name the way Composer writes them
+func mergeLockedPackages(base, ours, theirs *jsonObject) ([]interface{}, []interface{}, []MergeConflict) {
+	b, o, t := lockedNodes(base), lockedNodes(ours), lockedNodes(theirs)
+	names := map[string]bool{}
+	for _, nodes := range []map[string]lockedNode{b, o, t} {
+		for name := range nodes {
+			names[name] = true
+		}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	equal := func(x, y lockedNode) bool { return x.dev == y.dev && orderedEqual(x.value, y.value) }
+	packages, devPackages := []interface{}{}, []interface{}{}
+	var conflicts []MergeConflict
+	for _, name := range sorted {
+		bn, on, tn := b[name], o[name], t[name]
+		var result lockedNode
+		switch {
+		case equal(on, tn), equal(bn, tn):
+			result = on
+		case equal(bn, on):
+			result = tn
+		default:
+			result = on
+			section := "packages"
+			if on.dev || on.value == nil && tn.dev {
+				section = "packages-dev"
+			}
+			conflicts = append(conflicts, MergeConflict{Path: section + "." + name, Base: nodeVersion(bn), Ours: nodeVersion(on), Theirs: nodeVersion(tn)})
+		}
+		switch {
+		case result.value == nil:
+		case result.dev:
+			devPackages = append(devPackages, result.value)
+		default:
+			packages = append(packages, result.value)
+		}
+	}
+	return packages, devPackages, conflicts
+}
+
+// lockedNodes index the packages of a lock document by name
+func lockedNodes(doc *jsonObject) map[string]lockedNode {
+	nodes := map[string]lockedNode{}
+	for _, section := range []string{"packages", "packages-dev"} {
+		list, _ := doc.values[section].([]interface{})
+		for _, p := range list {
+			if name := nodeString(p, "name"); name != "" {
+				nodes[name] = lockedNode{value: p, dev: section == "packages-dev"}
+			}
+		}
+	}
+	return nodes
+}
+
+func nodeString(v interface{}, key string) string {
+	o, ok := v.(*jsonObject)
+	if !ok {
+		return ""
+	}
+	var s string
+	_ = json.Unmarshal(rawOrEmpty(o.values[key]), &s)
+	return s
+}
+
+func nodeVersion(n lockedNode) string {
+	return nodeString(n.value, "version")
+}
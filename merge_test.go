@@ -0,0 +1,253 @@
+package composer
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func lockJSON(hash string, packages, devPackages []string) string {
+	return `{
+    "_readme": [
+        "This file locks the dependencies of your project to a known state"
+    ],
+    "content-hash": "` + hash + `",
+    "packages": [` + strings.Join(packages, ",") + `],
+    "packages-dev": [` + strings.Join(devPackages, ",") + `],
+    "aliases": [],
+    "minimum-stability": "stable",
+    "stability-flags": [],
+    "prefer-stable": false,
+    "prefer-lowest": false,
+    "platform": {
+        "php": "^8.1"
+    },
+    "platform-dev": [],
+    "plugin-api-version": "2.3.0"
+}`
+}
+
+func lockedJSON(name, version string) string {
+	return `{"name": "` + name + `", "version": "` + version + `", "dist": {"type": "zip", "url": "https://example.com/` + name + `/` + version + `.zip"}}`
+}
+
+func TestMergeLocks(t *testing.T) {
+	base := lockJSON("base", []string{lockedJSON("monolog/monolog", "2.0.0"), lockedJSON("psr/log", "1.1.0"), lockedJSON("symfony/console", "v6.0.0")}, []string{lockedJSON("phpunit/phpunit", "10.0.0")})
+	ours := lockJSON("ours", []string{lockedJSON("monolog/monolog", "2.1.0"), lockedJSON("psr/log", "1.1.0"), lockedJSON("symfony/console", "v6.0.0")}, []string{lockedJSON("phpunit/phpunit", "10.0.0")})
+	theirs := lockJSON("theirs", []string{lockedJSON("monolog/monolog", "2.0.0"), lockedJSON("psr/container", "2.0.0"), lockedJSON("symfony/console", "v6.0.0")}, []string{lockedJSON("phpunit/phpunit", "10.1.0")})
+
+	merged, conflicts, err := MergeLocks([]byte(base), []byte(ours), []byte(theirs), []byte(`{"require": {"monolog/monolog": "1.0.*"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("MergeLocks() conflicts = %v", conflicts)
+	}
+	want := lockJSON("bef20e1ca06eac6c027a5bc95193a923", []string{lockedJSON("monolog/monolog", "2.1.0"), lockedJSON("psr/container", "2.0.0"), lockedJSON("symfony/console", "v6.0.0")}, []string{lockedJSON("phpunit/phpunit", "10.1.0")})
+	if got, want := string(merged), composerFormat(t, want); got != want {
+		t.Errorf("MergeLocks() got = %v, want %v", got, want)
+	}
+}
+
+func TestMergeLocks_conflicts(t *testing.T) {
+	base := lockJSON("base", []string{lockedJSON("monolog/monolog", "2.0.0"), lockedJSON("psr/log", "1.1.0")}, nil)
+	ours := lockJSON("ours", []string{lockedJSON("monolog/monolog", "2.1.0")}, nil)
+	theirs := lockJSON("theirs", []string{lockedJSON("monolog/monolog", "3.0.0"), lockedJSON("psr/log", "1.1.4")}, nil)
+
+	merged, conflicts, err := MergeLocks([]byte(base), []byte(ours), []byte(theirs), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []MergeConflict{
+		{Path: "packages.monolog/monolog", Base: "2.0.0", Ours: "2.1.0", Theirs: "3.0.0"},
+		{Path: "packages.psr/log", Base: "1.1.0", Theirs: "1.1.4"},
+		{Path: "content-hash", Base: `"base"`, Ours: `"ours"`, Theirs: `"theirs"`},
+	}
+	if !reflect.DeepEqual(conflicts, want) {
+		t.Errorf("MergeLocks() conflicts = %v, want %v", conflicts, want)
+	}
+	if got, want := string(merged), composerFormat(t, ours); got != want {
+		t.Errorf("MergeLocks() got = %v, want %v", got, want)
+	}
+	if got := conflicts[1].String(); got != "packages.psr/log: base 1.1.0, ours (absent), theirs 1.1.4" {
+		t.Errorf("String() got = %v", got)
+	}
+}
+
+func TestMergeLocks_movedToDev(t *testing.T) {
+	base := lockJSON("h", []string{lockedJSON("psr/log", "1.1.0")}, nil)
+	theirs := lockJSON("h", nil, []string{lockedJSON("psr/log", "1.1.0")})
+	merged, conflicts, err := MergeLocks([]byte(base), []byte(base), []byte(theirs), nil)
+	if err != nil || len(conflicts) != 0 {
+		t.Fatalf("MergeLocks() error = %v, conflicts = %v", err, conflicts)
+	}
+	if got, want := string(merged), composerFormat(t, theirs); got != want {
+		t.Errorf("MergeLocks() got = %v, want %v", got, want)
+	}
+}
+
+func composerFormat(t *testing.T, doc string) string {
+	v, err := decodeOrdered([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(composerEncoder.encode(v)) + "\n"
+}
+
+func Test_mergeJSON(t *testing.T) {
+	tests := []struct {
+		name          string
+		base          string
+		ours          string
+		theirs        string
+		want          string
+		wantConflicts []MergeConflict
+	}{
+		{"only ours changed", `{"a": 1}`, `{"a": 2}`, `{"a": 1}`, `{"a":2}`, nil},
+		{"only theirs changed", `{"a": 1}`, `{"a": 1}`, `{"a": 3}`, `{"a":3}`, nil},
+		{"different keys", `{"a": 1, "b": 1}`, `{"a": 2, "b": 1}`, `{"a": 1, "b": 3, "c": 4}`, `{"a":2,"b":3,"c":4}`, nil},
+		{"removed and kept", `{"a": 1, "b": 1}`, `{"b": 1}`, `{"a": 1, "b": 1}`, `{"b":1}`, nil},
+		{"sorted insertion", `{"a": 1, "c": 1}`, `{"a": 1, "c": 1, "d": 1}`, `{"a": 1, "b": 1, "c": 1}`, `{"a":1,"b":1,"c":1,"d":1}`, nil},
+		{"nested", `{"r": {"x": "1"}}`, `{"r": {"x": "1", "y": "2"}}`, `{"r": {"z": "3", "x": "1"}}`, `{"r":{"z":"3","x":"1","y":"2"}}`, nil},
+		{"both added differently", `{}`, `{"a": [1]}`, `{"a": [2]}`, `{"a":[1]}`, []MergeConflict{{Path: "a", Ours: "[1]", Theirs: "[2]"}}},
+		{"removed and changed", `{"a": {"b": 1}}`, `{}`, `{"a": {"b": 2}}`, `{}`, []MergeConflict{{Path: "a", Base: `{"b":1}`, Theirs: `{"b":2}`}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var docs [3]interface{}
+			for i, s := range []string{tt.base, tt.ours, tt.theirs} {
+				v, err := decodeOrdered([]byte(s))
+				if err != nil {
+					t.Fatal(err)
+				}
+				docs[i] = v
+			}
+			got, conflicts := mergeJSON("", docs[0], docs[1], docs[2])
+			if encodeCompact(got) != tt.want {
+				t.Errorf("mergeJSON() got = %v, want %v", encodeCompact(got), tt.want)
+			}
+			if !reflect.DeepEqual(conflicts, tt.wantConflicts) {
+				t.Errorf("mergeJSON() conflicts = %v, want %v", conflicts, tt.wantConflicts)
+			}
+		})
+	}
+}
+
+func TestMergeManifests(t *testing.T) {
+	base := `{
+  "name": "acme/app",
+  "keywords": ["a", "b"],
+  "require": {
+    "php": "^8.1",
+    "monolog/monolog": "^2.0",
+    "symfony/console": "^6.0"
+  },
+  "scripts": {
+    "test": "phpunit"
+  },
+  "autoload": {
+    "psr-4": {"App\\": "src/"}
+  }
+}
+`
+	ours := `{
+  "name": "acme/app",
+  "keywords": ["a", "b"],
+  "require": {
+    "php": "^8.1",
+    "monolog/monolog": "^3.0",
+    "symfony/console": "^6.0"
+  },
+  "scripts": {
+    "test": "phpunit",
+    "lint": "phpcs"
+  },
+  "autoload": {
+    "psr-4": {"App\\": "src/"}
+  }
+}
+`
+	theirs := `{
+    "name": "acme/app",
+    "keywords": ["a", "b"],
+    "require": {
+        "php": "^8.1",
+        "monolog/monolog": "^2.0",
+        "psr/log": "^3.0",
+        "symfony/console": "^6.0"
+    },
+    "scripts": {
+        "test": "phpunit"
+    },
+    "autoload": {
+        "psr-4": {"App\\": "src/"}
+    },
+    "config": {"sort-packages": true}
+}
+`
+	want := `{
+  "name": "acme/app",
+  "keywords": ["a", "b"],
+  "require": {
+    "php": "^8.1",
+    "monolog/monolog": "^3.0",
+    "psr/log": "^3.0",
+    "symfony/console": "^6.0"
+  },
+  "scripts": {
+    "test": "phpunit",
+    "lint": "phpcs"
+  },
+  "autoload": {
+    "psr-4": {"App\\": "src/"}
+  },
+  "config": {
+    "sort-packages": true
+  }
+}
+`
+	got, conflicts, err := MergeManifests([]byte(base), []byte(ours), []byte(theirs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("MergeManifests() conflicts = %v", conflicts)
+	}
+	if string(got) != want {
+		t.Errorf("MergeManifests() got = %v, want %v", string(got), want)
+	}
+}
+
+func TestMergeManifests_conflicts(t *testing.T) {
+	base := `{"require": {"monolog/monolog": "^2.0"}, "scripts": {"test": "phpunit"}}`
+	ours := `{"require": {"monolog/monolog": "^2.1"}, "scripts": {"test": ["@lint", "phpunit"]}}`
+	theirs := `{"require": {"monolog/monolog": "^3.0"}, "scripts": {"test": "phpunit --stop-on-failure"}}`
+	got, conflicts, err := MergeManifests([]byte(base), []byte(ours), []byte(theirs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []MergeConflict{
+		{Path: "require.monolog/monolog", Base: `"^2.0"`, Ours: `"^2.1"`, Theirs: `"^3.0"`},
+		{Path: "scripts.test", Base: `"phpunit"`, Ours: `["@lint","phpunit"]`, Theirs: `"phpunit --stop-on-failure"`},
+	}
+	if !reflect.DeepEqual(conflicts, want) {
+		t.Errorf("MergeManifests() conflicts = %v, want %v", conflicts, want)
+	}
+	var m Manifest
+	if err := json.Unmarshal(got, &m); err != nil || m.Require["monolog/monolog"] != "^2.1" {
+		t.Errorf("MergeManifests() got = %s, error = %v", got, err)
+	}
+}
+
+func TestMergeManifests_unchanged(t *testing.T) {
+	base := "{\n\t\"require\": {\"php\": \"^8.0\"}\n}"
+	theirs := "{\n\t\"require\": {\"php\": \"^8.1\"}\n}"
+	got, _, err := MergeManifests([]byte(base), []byte(base), []byte(theirs))
+	if err != nil || string(got) != theirs {
+		t.Errorf("MergeManifests() got = %s, error = %v", got, err)
+	}
+	if _, _, err := MergeManifests([]byte(base), []byte(`{"require": []}`), []byte(theirs)); err == nil {
+		t.Error("MergeManifests() expected an error for an invalid manifest")
+	}
+}
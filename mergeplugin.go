@@ -0,0 +1,342 @@
+package composer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MergePluginConfig is the extra.merge-plugin section read by wikimedia/composer-merge-plugin
+type MergePluginConfig struct {
+	// Include are glob patterns of files to merge, patterns matching nothing are ignored
+	Include []string
+	// Require are glob patterns of files to merge, a pattern matching nothing is an error
+	Require []string
+	// Recurse merge the merge-plugin sections of the included files, true by default
+	Recurse bool
+	// Replace let the last file defining a requirement win instead of combining the constraints
+	Replace bool
+	// IgnoreDuplicates keep the first definition of a requirement
+	IgnoreDuplicates bool
+	// MergeDev merge require-dev and autoload-dev, true by default
+	MergeDev bool
+	// MergeExtra merge the extra sections, MergeExtraDeep merge them recursively
+	MergeExtra     bool
+	MergeExtraDeep bool
+	// MergeScripts merge the scripts sections
+	MergeScripts bool
+}
+
+// MergePlugin return the merge-plugin configuration of the extra section and whether there is one
+func (e Extra) MergePlugin() (MergePluginConfig, bool) {
+	c := MergePluginConfig{Recurse: true, MergeDev: true}
+	section, ok := e["merge-plugin"].(map[string]interface{})
+	if !ok {
+		return c, false
+	}
+	c.Include = stringOrStrings(section["include"])
+	c.Require = stringOrStrings(section["require"])
+	for key, value := range map[string]*bool{
+		"recurse":           &c.Recurse,
+		"replace":           &c.Replace,
+		"ignore-duplicates": &c.IgnoreDuplicates,
+		"merge-dev":         &c.MergeDev,
+		"merge-extra":       &c.MergeExtra,
+		"merge-extra-deep":  &c.MergeExtraDeep,
+		"merge-scripts":     &c.MergeScripts,
+	} {
+		if b, ok := section[key].(bool); ok {
+			*value = b
+		}
+	}
+	return c, true
+}
+
+func stringOrStrings(v interface{}) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		var strs []string
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				strs = append(strs, s)
+			}
+		}
+		return strs
+	}
+	return nil
+}
+
+// LoadMergedManifest read a root composer.json and merge the files its extra.merge-plugin section includes,
+// the way wikimedia/composer-merge-plugin does:
+//
+// - include patterns are merged before require patterns, each file once, nested includes right after their file
+// - patterns and autoload paths of an included file are relative to the directory of that file
+// - a requirement defined twice gets both constraints, e.g. "^1.0, <1.5", unless replace or ignore-duplicates is set
+// - conflict, replace, provide and suggest of an included file win over what is already defined
+// - extra and scripts of the root win unless replace is set, the merge-plugin section itself is not merged
+// - repositories of included files come before the repositories of the root
+func LoadMergedManifest(file string) (*Manifest, error) {
+	root, err := readManifest(file)
+	if err != nil {
+		return nil, err
+	}
+	config, ok := root.Extra.MergePlugin()
+	if !ok {
+		return root, nil
+	}
+
+	m := &mergePlugin{root: root, config: config, dir: filepath.Dir(file), loaded: map[string]bool{}}
+	m.loaded[filepath.Clean(file)] = true
+	if err := m.mergeIncludes("", config.Include, config.Require); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+func readManifest(file string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %s: %v", file, err))
+	}
+	return &m, nil
+}
+
+type mergePlugin struct {
+	root   *Manifest
+	config MergePluginConfig
+	// dir of the root composer.json, included files are relative to it
+	dir    string
+	loaded map[string]bool
+}
+
+// mergeIncludes merge the files matching include and require patterns relative to base, a slash separated
+// directory relative to the root composer.json
+func (m *mergePlugin) mergeIncludes(base string, include, require []string) error {
+	for _, patterns := range []struct {
+		list     []string
+		required bool
+	}{{include, false}, {require, true}} {
+		for _, pattern := range patterns.list {
+			files, err := m.glob(path.Join(base, pattern))
+			if err != nil {
+				return err
+			}
+			if len(files) == 0 && patterns.required {
+				return errors.New(fmt.Sprintf("merge-plugin: no file matches the required pattern %s", pattern))
+			}
+			for _, f := range files {
+				if err := m.mergeFile(f); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// glob match a slash separated pattern relative to the root directory, braces like {a,b} are expanded
+func (m *mergePlugin) glob(pattern string) ([]string, error) {
+	var files []string
+	for _, p := range expandBraces(pattern) {
+		matches, err := filepath.Glob(filepath.Join(m.dir, filepath.FromSlash(p)))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			rel, err := filepath.Rel(m.dir, match)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, filepath.ToSlash(rel))
+		}
+	}
+	return files, nil
+}
+
+// expandBraces expand the first {a,b} group of a pattern recursively, like PHP glob with GLOB_BRACE
+func expandBraces(pattern string) []string {
+	start := strings.Index(pattern, "{")
+	end := strings.Index(pattern, "}")
+	if start == -1 || end < start {
+		return []string{pattern}
+	}
+	var patterns []string
+	for _, alternative := range strings.Split(pattern[start+1:end], ",") {
+		patterns = append(patterns, expandBraces(pattern[:start]+alternative+pattern[end+1:])...)
+	}
+	return patterns
+}
+
+// mergeFile merge one included composer.json, file is slash separated and relative to the root directory
+func (m *mergePlugin) mergeFile(file string) error {
+	abs := filepath.Join(m.dir, filepath.FromSlash(file))
+	if m.loaded[abs] {
+		return nil
+	}
+	m.loaded[abs] = true
+
+	included, err := readManifest(abs)
+	if err != nil {
+		return err
+	}
+	base := path.Dir(file)
+	m.merge(included, base)
+
+	if config, ok := included.Extra.MergePlugin(); ok && m.config.Recurse {
+		return m.mergeIncludes(base, config.Include, config.Require)
+	}
+	return nil
+}
+
+func (m *mergePlugin) merge(included *Manifest, base string) {
+	root := m.root
+	root.Require = m.mergeRequires(root.Require, included.Require)
+	root.Autoload = mergeAutoload(root.Autoload, included.Autoload, base)
+	if m.config.MergeDev {
+		root.RequireDev = m.mergeRequires(root.RequireDev, included.RequireDev)
+		root.AutoloadDev = mergeAutoload(root.AutoloadDev, included.AutoloadDev, base)
+	}
+	root.Conflict = mergeStringMaps(root.Conflict, included.Conflict)
+	root.Replace = mergeStringMaps(root.Replace, included.Replace)
+	root.Provide = mergeStringMaps(root.Provide, included.Provide)
+	root.Suggest = mergeStringMaps(root.Suggest, included.Suggest)
+	if len(included.Repositories) > 0 {
+		root.Repositories = append(append(Repositories{}, included.Repositories...), root.Repositories...)
+	}
+
+	if m.config.MergeExtra && len(included.Extra) > 0 {
+		extra := Extra{}
+		for k, v := range included.Extra {
+			if k != "merge-plugin" {
+				extra[k] = v
+			}
+		}
+		if m.config.Replace {
+			root.Extra = Extra(mergeExtra(root.Extra, extra, m.config.MergeExtraDeep))
+		} else {
+			root.Extra = Extra(mergeExtra(extra, root.Extra, m.config.MergeExtraDeep))
+		}
+	}
+	if m.config.MergeScripts && len(included.Scripts) > 0 {
+		scripts := map[string]StringOrStrings{}
+		first, last := included.Scripts, root.Scripts
+		if m.config.Replace {
+			first, last = last, first
+		}
+		for _, s := range []map[string]StringOrStrings{first, last} {
+			for k, v := range s {
+				scripts[k] = v
+			}
+		}
+		root.Scripts = scripts
+	}
+}
+
+// mergeRequires add requirements, a package already required keeps its constraint with ignore-duplicates,
+// gets the new one with replace and both otherwise
+func (m *mergePlugin) mergeRequires(origin, merge map[string]string) map[string]string {
+	if len(merge) == 0 {
+		return origin
+	}
+	result := make(map[string]string, len(origin)+len(merge))
+	for k, v := range origin {
+		result[k] = v
+	}
+	for _, name := range sortedKeys(merge) {
+		existing := ""
+		for k := range result {
+			if strings.EqualFold(k, name) {
+				existing = k
+				break
+			}
+		}
+		switch {
+		case existing == "":
+			result[name] = merge[name]
+		case m.config.IgnoreDuplicates:
+		case m.config.Replace:
+			delete(result, existing)
+			result[name] = merge[name]
+		default:
+			result[existing] = result[existing] + ", " + merge[name]
+		}
+	}
+	return result
+}
+
+// mergeStringMaps is PHP array_merge, values of b win
+func mergeStringMaps(a, b map[string]string) map[string]string {
+	if len(b) == 0 {
+		return a
+	}
+	result := make(map[string]string, len(a)+len(b))
+	for _, m := range []map[string]string{a, b} {
+		for k, v := range m {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// mergeExtra merge two extra sections, values of b win. Deep merges nested objects recursively
+func mergeExtra(a, b map[string]interface{}, deep bool) map[string]interface{} {
+	result := make(map[string]interface{}, len(a)+len(b))
+	for k, v := range a {
+		result[k] = v
+	}
+	for k, v := range b {
+		existing, ok1 := result[k].(map[string]interface{})
+		merged, ok2 := v.(map[string]interface{})
+		if deep && ok1 && ok2 {
+			result[k] = mergeExtra(existing, merged, true)
+			continue
+		}
+		result[k] = v
+	}
+	return result
+}
+
+// mergeAutoload merge the autoload section of an included file, its paths are prefixed with base
+func mergeAutoload(root, included Autoload, base string) Autoload {
+	prefix := func(paths []string) []string {
+		if base == "." || base == "" {
+			return paths
+		}
+		prefixed := make([]string, len(paths))
+		for i, p := range paths {
+			prefixed[i] = base + "/" + p
+		}
+		return prefixed
+	}
+	mergePsr := func(a, b Psr) Psr {
+		if len(b) == 0 {
+			return a
+		}
+		result := Psr{}
+		for k, v := range a {
+			result[k] = v
+		}
+		for k, v := range b {
+			result[k] = append(append([]string{}, result[k]...), prefix(v)...)
+		}
+		return result
+	}
+	root.Psr0 = mergePsr(root.Psr0, included.Psr0)
+	root.Psr4 = mergePsr(root.Psr4, included.Psr4)
+	root.Classmap = append(root.Classmap, prefix(included.Classmap)...)
+	root.Files = append(root.Files, prefix(included.Files)...)
+	root.ExcludeFromClassmap = append(root.ExcludeFromClassmap, prefix(included.ExcludeFromClassmap)...)
+	return root
+}
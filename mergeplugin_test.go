@@ -0,0 +1,137 @@
+package composer
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadMergedManifest(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"composer.json": `{
+			"name": "acme/app",
+			"require": {"php": "^8.1", "monolog/monolog": "^2.0"},
+			"autoload": {"psr-4": {"App\\": "src/"}},
+			"repositories": [{"type": "composer", "url": "https://root.example.com"}],
+			"scripts": {"test": "phpunit"},
+			"extra": {
+				"branch": "root",
+				"merge-plugin": {
+					"include": ["modules/*/composer.json", "missing/*.json"],
+					"require": "shared/composer.{json,local.json}",
+					"merge-extra": true,
+					"merge-scripts": true
+				}
+			}
+		}`,
+		"modules/a/composer.json": `{
+			"require": {"monolog/monolog": "<2.5", "psr/log": "^3.0"},
+			"require-dev": {"phpunit/phpunit": "^10.0"},
+			"autoload": {"psr-4": {"App\\": "lib/", "A\\": "src/"}, "files": ["helpers.php"]},
+			"repositories": [{"type": "vcs", "url": "https://a.example.com"}],
+			"scripts": {"test": "pest", "lint": "phpcs"},
+			"extra": {"branch": "a", "module": "a", "merge-plugin": {"include": "nested/composer.json"}}
+		}`,
+		"modules/a/nested/composer.json": `{"require": {"symfony/console": "^6.0"}, "autoload": {"classmap": ["lib/"]}}`,
+		"modules/b/composer.json":        `{"conflict": {"psr/log": "<3.0"}, "require": {"modules/a-also": "*"}, "extra": {"merge-plugin": {"include": "../a/composer.json"}}}`,
+		"shared/composer.json":           `{"require": {"guzzlehttp/guzzle": "^7.0"}}`,
+	})
+
+	got, err := LoadMergedManifest(filepath.Join(dir, "composer.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRequire := map[string]string{
+		"php":               "^8.1",
+		"monolog/monolog":   "^2.0, <2.5",
+		"psr/log":           "^3.0",
+		"symfony/console":   "^6.0",
+		"modules/a-also":    "*",
+		"guzzlehttp/guzzle": "^7.0",
+	}
+	if !reflect.DeepEqual(got.Require, wantRequire) {
+		t.Errorf("Require got = %v, want %v", got.Require, wantRequire)
+	}
+	if want := map[string]string{"phpunit/phpunit": "^10.0"}; !reflect.DeepEqual(got.RequireDev, want) {
+		t.Errorf("RequireDev got = %v, want %v", got.RequireDev, want)
+	}
+	wantAutoload := Autoload{
+		Psr4:     Psr{"App\\": {"src/", "modules/a/lib/"}, "A\\": {"modules/a/src/"}},
+		Classmap: []string{"modules/a/nested/lib/"},
+		Files:    []string{"modules/a/helpers.php"},
+	}
+	if !reflect.DeepEqual(got.Autoload, wantAutoload) {
+		t.Errorf("Autoload got = %+v, want %+v", got.Autoload, wantAutoload)
+	}
+	if want := map[string]string{"psr/log": "<3.0"}; !reflect.DeepEqual(got.Conflict, want) {
+		t.Errorf("Conflict got = %v, want %v", got.Conflict, want)
+	}
+	if len(got.Repositories) != 2 || got.Repositories[0].Url != "https://a.example.com" {
+		t.Errorf("Repositories got = %+v", got.Repositories)
+	}
+	if got.Extra["branch"] != "root" || got.Extra["module"] != "a" {
+		t.Errorf("Extra got = %v", got.Extra)
+	}
+	if want := map[string]StringOrStrings{"test": {"phpunit"}, "lint": {"phpcs"}}; !reflect.DeepEqual(got.Scripts, want) {
+		t.Errorf("Scripts got = %v, want %v", got.Scripts, want)
+	}
+}
+
+func TestLoadMergedManifest_options(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     string
+		wantRequire map[string]string
+		wantDev     map[string]string
+		wantExtra   Extra
+	}{
+		{"replace", `"replace": true, "merge-extra": true`,
+			map[string]string{"monolog/monolog": "^3.0"}, map[string]string{"phpunit/phpunit": "^10.0"},
+			Extra{"a": map[string]interface{}{"x": "included", "z": "included"}}},
+		{"ignore duplicates", `"ignore-duplicates": true`,
+			map[string]string{"monolog/monolog": "^2.0"}, map[string]string{"phpunit/phpunit": "^10.0"},
+			Extra{"a": map[string]interface{}{"x": "root", "y": "root"}}},
+		{"no dev, deep extra", `"merge-dev": false, "merge-extra": true, "merge-extra-deep": true`,
+			map[string]string{"monolog/monolog": "^2.0, ^3.0"}, nil,
+			Extra{"a": map[string]interface{}{"x": "root", "y": "root", "z": "included"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, map[string]string{
+				"composer.json": `{"require": {"monolog/monolog": "^2.0"}, "extra": {"a": {"x": "root", "y": "root"}, "merge-plugin": {"include": "inc.json", ` + tt.options + `}}}`,
+				"inc.json":      `{"require": {"monolog/monolog": "^3.0"}, "require-dev": {"phpunit/phpunit": "^10.0"}, "extra": {"a": {"x": "included", "z": "included"}}}`,
+			})
+			got, err := LoadMergedManifest(filepath.Join(dir, "composer.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.Require, tt.wantRequire) {
+				t.Errorf("Require got = %v, want %v", got.Require, tt.wantRequire)
+			}
+			if !reflect.DeepEqual(got.RequireDev, tt.wantDev) {
+				t.Errorf("RequireDev got = %v, want %v", got.RequireDev, tt.wantDev)
+			}
+			delete(got.Extra, "merge-plugin")
+			if !reflect.DeepEqual(got.Extra, tt.wantExtra) {
+				t.Errorf("Extra got = %v, want %v", got.Extra, tt.wantExtra)
+			}
+		})
+	}
+}
+
+func TestLoadMergedManifest_requiredMissing(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"composer.json": `{"extra": {"merge-plugin": {"require": "missing.json"}}}`,
+	})
+	if _, err := LoadMergedManifest(filepath.Join(dir, "composer.json")); err == nil {
+		t.Error("LoadMergedManifest() expected an error")
+	}
+}
+
+func Test_expandBraces(t *testing.T) {
+	got := expandBraces("a/{b,c}/composer.{json,local.json}")
+	want := []string{"a/b/composer.json", "a/b/composer.local.json", "a/c/composer.json", "a/c/composer.local.json"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expandBraces() got = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,395 @@
+package composer
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FileMove is a file to move, the paths are slash separated and relative to the package directory
+type FileMove struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Psr4Migration rewrite the PSR-0 rules and the deprecated target-dir of a package into PSR-4 rules
+type Psr4Migration struct {
+	// Dir is the package directory
+	Dir string
+	// Flatten map each namespace to the directory of its PSR-0 rule: with "Acme\\Foo\\": "src/" the file
+	// src/Acme/Foo/Bar.php moves to src/Bar.php. Otherwise the PSR-4 rule maps src/Acme/Foo/ and only the
+	// classes with an underscore in their name move, e.g. src/Acme/Foo/Bar/Baz.php to src/Acme/Foo/Bar_Baz.php
+	Flatten bool
+}
+
+// Psr4MigrationPlan is the result of a migration
+type Psr4MigrationPlan struct {
+	Autoload    Autoload   `json:"autoload"`
+	AutoloadDev Autoload   `json:"autoload-dev"`
+	Moves       []FileMove `json:"moves"`
+}
+
+// Migrate rewrite the autoload and autoload-dev sections of a package and drop its target-dir. The class map of
+// the package with the files moved is checked to be the class map before the migration, with the same classes
+// in the moved files. The files are not moved, see GitMvCommands
+func (g Psr4Migration) Migrate(m *Manifest) (*Psr4MigrationPlan, error) {
+	if m.TargetDir != "" && (len(m.Autoload.Psr4) > 0 || len(m.AutoloadDev.Psr4) > 0) {
+		return nil, errors.New("cannot migrate a package using target-dir with psr-4 rules")
+	}
+	tmp, err := ioutil.TempDir("", "composer-migrate")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	before, err := g.classMapBefore(m, filepath.Join(tmp, "before"))
+	if err != nil {
+		return nil, err
+	}
+	plan := &Psr4MigrationPlan{}
+	moves := map[string]string{}
+	if plan.Autoload, err = g.migrate(m.Autoload, m.TargetDir, before, moves); err != nil {
+		return nil, err
+	}
+	if plan.AutoloadDev, err = g.migrate(m.AutoloadDev, m.TargetDir, before, moves); err != nil {
+		return nil, err
+	}
+	targets := map[string]string{}
+	for _, from := range sortedKeys(moves) {
+		to := moves[from]
+		if other, ok := targets[to]; ok {
+			return nil, errors.New(fmt.Sprintf("cannot move both %s and %s to %s", other, from, to))
+		}
+		if _, moved := moves[to]; !moved && fileExists(filepath.Join(g.Dir, filepath.FromSlash(to))) {
+			return nil, errors.New(fmt.Sprintf("cannot move %s to %s which exists", from, to))
+		}
+		targets[to] = from
+		plan.Moves = append(plan.Moves, FileMove{From: from, To: to})
+	}
+
+	after, err := g.classMapAfter(plan, filepath.Join(tmp, "after"))
+	if err != nil {
+		return nil, err
+	}
+	var changes []string
+	for _, class := range sortedKeys(before) {
+		want := before[class]
+		if to, ok := moves[want]; ok {
+			want = to
+		}
+		if got, ok := after[class]; !ok {
+			changes = append(changes, fmt.Sprintf("%s is not found in %s any more", class, want))
+		} else if got != want {
+			changes = append(changes, fmt.Sprintf("%s is found in %s instead of %s", class, got, want))
+		}
+	}
+	for _, class := range sortedKeys(after) {
+		if _, ok := before[class]; !ok {
+			changes = append(changes, fmt.Sprintf("%s is found in %s", class, after[class]))
+		}
+	}
+	if len(changes) > 0 {
+		return nil, errors.New(fmt.Sprintf("the migration changes the class map: %s", strings.Join(changes, ", ")))
+	}
+
+	m.Autoload, m.AutoloadDev, m.TargetDir = plan.Autoload, plan.AutoloadDev, ""
+	return plan, nil
+}
+
+// migrate turn the PSR-0 rules of an autoload section into PSR-4 rules, the moves of the classes they load
+// are added by old path. A prefix without a namespace, e.g. "Twig_", has no PSR-4 equivalent but a fallback
+// directory every class lookup goes through, its classes are kept where they are on a classmap rule instead
+func (g Psr4Migration) migrate(a Autoload, targetDir string, classes map[string]string, moves map[string]string) (Autoload, error) {
+	migrated := a
+	migrated.Psr0 = nil
+	migrated.Psr4 = Psr{}
+	for prefix, dirs := range a.Psr4 {
+		migrated.Psr4[prefix] = append([]string{}, dirs...)
+	}
+	if targetDir != "" {
+		migrated.Classmap = trimTargetDir(a.Classmap, targetDir)
+		migrated.Files = trimTargetDir(a.Files, targetDir)
+		migrated.ExcludeFromClassmap = trimTargetDir(a.ExcludeFromClassmap, targetDir)
+	}
+
+	prefixes := make([]string, 0, len(a.Psr0))
+	for prefix := range a.Psr0 {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(prefixes)))
+	type rule struct {
+		prefix, dir, namespace, psr4Dir string
+		classmap                        bool
+	}
+	var rules []rule
+	for _, prefix := range prefixes {
+		for _, dir := range a.Psr0[prefix] {
+			r := rule{prefix: prefix, dir: normalizePath(strings.Replace(dir, `\`, "/", -1))}
+			if !strings.Contains(prefix, `\`) {
+				path, err := classmapRule(prefix, r.dir, targetDir)
+				if err != nil {
+					return Autoload{}, err
+				}
+				r.classmap = true
+				rules = append(rules, r)
+				if !containsString(migrated.Classmap, path) {
+					migrated.Classmap = append(migrated.Classmap, path)
+				}
+				continue
+			}
+			namespace, psr4Dir, err := g.psr4Rule(prefix, r.dir, targetDir)
+			if err != nil {
+				return Autoload{}, err
+			}
+			r.namespace, r.psr4Dir = namespace, psr4Dir
+			rules = append(rules, r)
+			if !containsString(migrated.Psr4[namespace], psr4Dir) {
+				migrated.Psr4[namespace] = append(migrated.Psr4[namespace], psr4Dir)
+			}
+		}
+	}
+	if len(migrated.Psr4) == 0 {
+		migrated.Psr4 = nil
+	}
+
+	for _, class := range sortedKeys(classes) {
+		file := classes[class]
+		for _, r := range rules {
+			if !strings.HasPrefix(class, r.prefix) {
+				continue
+			}
+			if old, ok := stripTargetDir(joinDir(r.dir, psr0Path(class)), targetDir); !ok || old != file {
+				continue
+			}
+			if r.classmap {
+				break
+			}
+			to := joinDir(r.psr4Dir, strings.Replace(class[len(r.namespace):], `\`, "/", -1)+".php")
+			if to != file {
+				moves[file] = to
+			}
+			break
+		}
+	}
+	return migrated, nil
+}
+
+// psr4Rule return the namespace and the directory relative to the package of the PSR-4 rule replacing a PSR-0 rule
+func (g Psr4Migration) psr4Rule(prefix, dir, targetDir string) (namespace, psr4Dir string, err error) {
+	namespace = prefix[:strings.LastIndex(prefix, `\`)+1]
+	namespaceDir := joinDir(dir, strings.TrimSuffix(strings.Replace(namespace, `\`, "/", -1), "/"))
+	if g.Flatten {
+		if d, ok := stripTargetDir(dir, targetDir); ok {
+			return namespace, withSlash(d), nil
+		}
+	}
+	if d, ok := stripTargetDir(namespaceDir, targetDir); ok {
+		return namespace, withSlash(d), nil
+	}
+	// the package is installed within the directory of the namespace, the namespace of target-dir maps the package
+	if strings.HasPrefix(targetDir+"/", withSlash(namespaceDir)) {
+		if d, ok := stripTargetDir(dir, ""); ok && d == "" {
+			return strings.Replace(targetDir, "/", `\`, -1) + `\`, "", nil
+		}
+	}
+	return "", "", errors.New(fmt.Sprintf("cannot migrate the psr-0 rule %s => %s, its directory is outside of target-dir %s", prefix, dir, targetDir))
+}
+
+// classmapRule return the classmap path relative to the package replacing a PSR-0 rule without a namespace,
+// the directory of the classes starting with the prefix, e.g. lib/Twig/ for "Twig_": "lib/"
+func classmapRule(prefix, dir, targetDir string) (string, error) {
+	prefixDir := strings.Replace(prefix, "_", "/", -1)
+	prefixDir = prefixDir[:strings.LastIndex(prefixDir, "/")+1]
+	if d, ok := stripTargetDir(joinDir(dir, prefixDir), targetDir); ok {
+		if d == "" {
+			return "./", nil
+		}
+		return d + "/", nil
+	}
+	return "", errors.New(fmt.Sprintf("cannot migrate the psr-0 rule %s => %s, its directory is outside of target-dir %s", prefix, dir, targetDir))
+}
+
+// classMapBefore build the class map of a package installed like Composer installs a package with a target-dir,
+// and return the files relative to the package
+func (g Psr4Migration) classMapBefore(m *Manifest, root string) (map[string]string, error) {
+	dir := filepath.Join(root, filepath.FromSlash(m.TargetDir))
+	if err := copyPackage(g.Dir, dir); err != nil {
+		return nil, err
+	}
+	var sources []AutoloadSource
+	for _, a := range []Autoload{m.Autoload, m.AutoloadDev} {
+		if m.TargetDir != "" {
+			// the paths of classmap rules which are not found from the install path start at target-dir
+			a.Classmap = prefixTargetDir(root, a.Classmap, m.TargetDir)
+			a.ExcludeFromClassmap = prefixTargetDir(root, a.ExcludeFromClassmap, m.TargetDir)
+		}
+		sources = append(sources, AutoloadSource{Dir: root, Autoload: a})
+	}
+	return relativeClassMap(sources, dir)
+}
+
+// classMapAfter build the class map of a package with the moves of a migration
+func (g Psr4Migration) classMapAfter(plan *Psr4MigrationPlan, dir string) (map[string]string, error) {
+	if err := copyPackage(g.Dir, dir); err != nil {
+		return nil, err
+	}
+	// moves go through temporary names, a file may move where another one was
+	for i, move := range plan.Moves {
+		if err := os.Rename(filepath.Join(dir, filepath.FromSlash(move.From)), filepath.Join(dir, fmt.Sprintf(".move%d", i))); err != nil {
+			return nil, err
+		}
+	}
+	for i, move := range plan.Moves {
+		to := filepath.Join(dir, filepath.FromSlash(move.To))
+		if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.Rename(filepath.Join(dir, fmt.Sprintf(".move%d", i)), to); err != nil {
+			return nil, err
+		}
+	}
+	return relativeClassMap([]AutoloadSource{{Dir: dir, Autoload: plan.Autoload}, {Dir: dir, Autoload: plan.AutoloadDev}}, dir)
+}
+
+// relativeClassMap generate a class map with the paths relative to dir
+func relativeClassMap(sources []AutoloadSource, dir string) (map[string]string, error) {
+	abs, err := absolutePath(dir)
+	if err != nil {
+		return nil, err
+	}
+	m, err := ClassMapGenerator{BaseDir: dir}.Generate(sources)
+	if err != nil {
+		return nil, err
+	}
+	classes := map[string]string{}
+	for class, file := range m.Classes {
+		classes[class] = strings.TrimPrefix(file, abs+"/")
+	}
+	return classes, nil
+}
+
+// copyPackage copy the files of a package but its vendor directory and dot files
+func copyPackage(src, dst string) error {
+	return filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		if strings.HasPrefix(info.Name(), ".") || filepath.ToSlash(rel) == "vendor" {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		content, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, content, 0644)
+	})
+}
+
+// GitMvCommands return the shell commands moving files with git, creating the missing directories first
+func GitMvCommands(moves []FileMove) []string {
+	var commands []string
+	created := map[string]bool{}
+	for _, move := range moves {
+		if dir := path.Dir(move.To); dir != "." && !created[dir] {
+			created[dir] = true
+			commands = append(commands, "mkdir -p "+shellQuote(dir))
+		}
+		commands = append(commands, "git mv "+shellQuote(move.From)+" "+shellQuote(move.To))
+	}
+	return commands
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// psr0Path is the path of a class for PSR-0, underscores of the class name are directories
+func psr0Path(class string) string {
+	pos := strings.LastIndex(class, `\`)
+	return strings.Replace(class[:pos+1], `\`, "/", -1) + strings.Replace(class[pos+1:], "_", "/", -1) + ".php"
+}
+
+// stripTargetDir return a path relative to the install path of a package relative to the package
+func stripTargetDir(p, targetDir string) (string, bool) {
+	p, targetDir = normalizePath(p), normalizePath(targetDir)
+	switch {
+	case targetDir == "":
+		return p, !strings.HasPrefix(p, "../") && p != ".."
+	case p == targetDir:
+		return "", true
+	case strings.HasPrefix(p, targetDir+"/"):
+		return p[len(targetDir)+1:], true
+	}
+	return "", false
+}
+
+// trimTargetDir remove target-dir from the paths which start with it
+func trimTargetDir(paths []string, targetDir string) []string {
+	var trimmed []string
+	for _, p := range paths {
+		if rel, ok := stripTargetDir(p, targetDir); ok {
+			p = rel
+		}
+		trimmed = append(trimmed, p)
+	}
+	return trimmed
+}
+
+// prefixTargetDir prefix target-dir to the paths which are not found from the install path like Composer
+func prefixTargetDir(root string, paths []string, targetDir string) []string {
+	var prefixed []string
+	for _, p := range paths {
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(p))); err != nil {
+			p = targetDir + "/" + p
+		}
+		prefixed = append(prefixed, p)
+	}
+	return prefixed
+}
+
+func joinDir(dir, p string) string {
+	if dir == "" {
+		return normalizePath(p)
+	}
+	return normalizePath(dir + "/" + p)
+}
+
+func withSlash(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	return dir + "/"
+}
+
+func fileExists(file string) bool {
+	_, err := os.Stat(file)
+	return err == nil
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package composer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPsr4Migration_Migrate(t *testing.T) {
+	tests := []struct {
+		name            string
+		files           map[string]string
+		manifest        Manifest
+		flatten         bool
+		wantAutoload    Autoload
+		wantAutoloadDev Autoload
+		wantMoves       []FileMove
+		wantErr         bool
+	}{
+		{
+			name: "namespace directory",
+			files: map[string]string{
+				"src/Acme/Foo/Bar.php":     "<?php\nnamespace Acme\\Foo;\nclass Bar {}\n",
+				"src/Acme/Foo/Baz/Qux.php": "<?php\nnamespace Acme\\Foo;\nclass Baz_Qux {}\n",
+				"lib/helpers.php":          "<?php\nclass Helpers {}\n",
+			},
+			manifest:     Manifest{Autoload: Autoload{Psr0: Psr{`Acme\Foo\`: {"src/"}}, Classmap: []string{"lib/"}}},
+			wantAutoload: Autoload{Psr4: Psr{`Acme\Foo\`: {"src/Acme/Foo/"}}, Classmap: []string{"lib/"}},
+			wantMoves:    []FileMove{{From: "src/Acme/Foo/Baz/Qux.php", To: "src/Acme/Foo/Baz_Qux.php"}},
+		},
+		{
+			name: "flatten",
+			files: map[string]string{
+				"src/Acme/Foo/Bar.php":     "<?php\nnamespace Acme\\Foo;\nclass Bar {}\n",
+				"src/Acme/Foo/Baz/Qux.php": "<?php\nnamespace Acme\\Foo\\Baz;\nclass Qux {}\n",
+			},
+			manifest:     Manifest{Autoload: Autoload{Psr0: Psr{`Acme\Foo\`: {"src/"}}}},
+			flatten:      true,
+			wantAutoload: Autoload{Psr4: Psr{`Acme\Foo\`: {"src/"}}},
+			wantMoves: []FileMove{
+				{From: "src/Acme/Foo/Bar.php", To: "src/Bar.php"},
+				{From: "src/Acme/Foo/Baz/Qux.php", To: "src/Baz/Qux.php"},
+			},
+		},
+		{
+			name: "target-dir",
+			files: map[string]string{
+				"Yaml.php":          "<?php\nnamespace Symfony\\Component\\Yaml;\nclass Yaml {}\n",
+				"Exception/Foo.php": "<?php\nnamespace Symfony\\Component\\Yaml\\Exception;\nclass Foo {}\n",
+				"Resources/map.php": "<?php\nclass YamlMap {}\n",
+			},
+			manifest: Manifest{TargetDir: "Symfony/Component/Yaml", Autoload: Autoload{
+				Psr0:     Psr{`Symfony\Component\Yaml\`: {""}},
+				Classmap: []string{"Resources/"},
+			}},
+			flatten:      true,
+			wantAutoload: Autoload{Psr4: Psr{`Symfony\Component\Yaml\`: {""}}, Classmap: []string{"Resources/"}},
+		},
+		{
+			name: "target-dir below the prefix",
+			files: map[string]string{
+				"Yaml.php": "<?php\nnamespace Symfony\\Component\\Yaml;\nclass Yaml {}\n",
+			},
+			manifest:     Manifest{TargetDir: "Symfony/Component/Yaml", Autoload: Autoload{Psr0: Psr{`Symfony\`: {""}}}},
+			wantAutoload: Autoload{Psr4: Psr{`Symfony\Component\Yaml\`: {""}}},
+		},
+		{
+			name: "underscore prefix",
+			files: map[string]string{
+				"lib/Twig/Environment.php":      "<?php\nclass Twig_Environment {}\n",
+				"lib/Twig/Node/Expression.php":  "<?php\nclass Twig_Node_Expression {}\n",
+				"lib/Twig/Extra/Markdown.php":   "<?php\nnamespace Twig\\Extra;\nclass Markdown {}\n",
+				"tests/Twig/Tests/Foo.php":      "<?php\nclass Twig_Tests_Foo {}\n",
+				"tests/Twig/Tests/Node/Bar.php": "<?php\nclass Twig_Tests_Node_Bar {}\n",
+			},
+			manifest: Manifest{
+				Autoload:    Autoload{Psr0: Psr{"Twig_": {"lib/"}, `Twig\Extra\`: {"lib/"}}},
+				AutoloadDev: Autoload{Psr0: Psr{"Twig_Tests_": {"tests/"}}},
+			},
+			wantAutoload:    Autoload{Psr4: Psr{`Twig\Extra\`: {"lib/Twig/Extra/"}}, Classmap: []string{"lib/Twig/"}},
+			wantAutoloadDev: Autoload{Classmap: []string{"tests/Twig/Tests/"}},
+		},
+		{
+			name: "existing target",
+			files: map[string]string{
+				"src/Acme/Bar.php": "<?php\nnamespace Acme;\nclass Bar {}\n",
+				"src/Bar.php":      "<?php\nclass Unrelated {}\n",
+			},
+			manifest: Manifest{Autoload: Autoload{Psr0: Psr{`Acme\`: {"src/"}}}},
+			flatten:  true,
+			wantErr:  true,
+		},
+		{
+			name: "class map changed",
+			files: map[string]string{
+				"src/Acme/Bar.php": "<?php\nnamespace Acme;\nclass Bar {}\n",
+				"src/Other.php":    "<?php\nnamespace Acme;\nclass Other {}\n",
+			},
+			manifest: Manifest{Autoload: Autoload{Psr0: Psr{`Acme\`: {"src/"}}}},
+			flatten:  true,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.manifest
+			plan, err := Psr4Migration{Dir: writeFiles(t, tt.files), Flatten: tt.flatten}.Migrate(&m)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Migrate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(m.Autoload, tt.wantAutoload) || !reflect.DeepEqual(plan.Autoload, tt.wantAutoload) {
+				t.Errorf("Migrate() autoload = %+v, want %+v", m.Autoload, tt.wantAutoload)
+			}
+			if !reflect.DeepEqual(m.AutoloadDev, tt.wantAutoloadDev) || !reflect.DeepEqual(plan.AutoloadDev, tt.wantAutoloadDev) {
+				t.Errorf("Migrate() autoload-dev = %+v, want %+v", m.AutoloadDev, tt.wantAutoloadDev)
+			}
+			if !reflect.DeepEqual(plan.Moves, tt.wantMoves) {
+				t.Errorf("Migrate() moves = %+v, want %+v", plan.Moves, tt.wantMoves)
+			}
+			if m.TargetDir != "" {
+				t.Errorf("Migrate() target-dir = %s, want none", m.TargetDir)
+			}
+		})
+	}
+}
+
+func TestGitMvCommands(t *testing.T) {
+	got := GitMvCommands([]FileMove{
+		{From: "src/Acme/Bar.php", To: "src/Bar.php"},
+		{From: "src/Acme/Baz.php", To: "src/Baz.php"},
+		{From: "Readme's.php", To: "Readme.php"},
+	})
+	want := []string{
+		"mkdir -p 'src'",
+		"git mv 'src/Acme/Bar.php' 'src/Bar.php'",
+		"git mv 'src/Acme/Baz.php' 'src/Baz.php'",
+		`git mv 'Readme'\''s.php' 'Readme.php'`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GitMvCommands() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,244 @@
+package composer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// schemaKeyOrder is the order of the properties in the composer.json schema, normalized documents follow it
+var schemaKeyOrder = []string{
+	"name", "description", "license", "type", "abandoned", "version", "default-branch", "non-feature-branches",
+	"keywords", "readme", "time", "authors", "homepage", "support", "funding", "source", "dist", "_comment",
+	"require", "require-dev", "replace", "conflict", "provide", "suggest", "repositories",
+	"minimum-stability", "prefer-stable", "autoload", "autoload-dev", "target-dir", "include-path", "bin",
+	"archive", "php-ext", "config", "extra", "scripts", "scripts-descriptions", "scripts-aliases",
+}
+
+// nestedKeyOrder is the schema order of the properties of nested objects, by path of the object.
+// Items of a list share the path of the list
+var nestedKeyOrder = map[string][]string{
+	"authors":      {"name", "email", "homepage", "role"},
+	"support":      {"email", "issues", "forum", "wiki", "irc", "source", "docs", "rss", "chat", "security"},
+	"funding":      {"type", "url"},
+	"autoload":     {"psr-0", "psr-4", "classmap", "files", "exclude-from-classmap"},
+	"autoload-dev": {"psr-0", "psr-4", "classmap", "files", "exclude-from-classmap"},
+	"archive":      {"name", "exclude"},
+}
+
+// packageMapKeys are the maps of package links sorted like config.sort-packages does
+var packageMapKeys = []string{"require", "require-dev", "conflict", "provide", "replace", "suggest"}
+
+// constraintMapKeys are the maps of package links whose constraints are normalized
+var constraintMapKeys = []string{"require", "require-dev", "conflict", "provide", "replace"}
+
+// sortedHashKeys are sorted by key recursively, except unsortedHashPaths where the order has a meaning
+var sortedHashKeys = []string{"config", "extra", "scripts-descriptions"}
+
+var unsortedHashPaths = map[string]bool{
+	"extra.installer-paths": true,
+	"extra.patches":         true,
+}
+
+var (
+	orConstraintRegex  = regexp.MustCompile(`\s*\|\|?\s*`)
+	andConstraintRegex = regexp.MustCompile(`(?:\s*,\s*|\s+)`)
+	hyphenRangeRegex   = regexp.MustCompile(`\s+-\s+`)
+)
+
+// NormalizeConstraint normalize the spacing of a version constraint the way ergebnis/composer-normalize does,
+// e.g. "^1.0,<1.5|^2.0" into "^1.0 <1.5 || ^2.0". Hyphen ranges are kept
+func NormalizeConstraint(constraint string) string {
+	var groups []string
+	for _, or := range orConstraintRegex.Split(strings.TrimSpace(constraint), -1) {
+		ranges := hyphenRangeRegex.Split(strings.TrimSpace(or), -1)
+		for i, r := range ranges {
+			ranges[i] = strings.Join(andConstraintRegex.Split(strings.TrimSpace(r), -1), " ")
+		}
+		groups = append(groups, strings.Join(ranges, " - "))
+	}
+	return strings.Join(groups, " || ")
+}
+
+// Normalize normalize the content of a manifest: constraints spacing and the order of bin.
+// Key order only exists in documents, see NormalizeJSON
+func Normalize(m *Manifest) {
+	for _, links := range []map[string]string{m.Require, m.RequireDev, m.Conflict, m.Provide, m.Replace} {
+		for name, constraint := range links {
+			links[name] = NormalizeConstraint(constraint)
+		}
+	}
+	sort.Strings(m.Bin)
+}
+
+// NormalizeJSON format a composer.json document the way ergebnis/composer-normalize does: keys in schema order,
+// package links sorted with platform packages first, config, extra and scripts-descriptions sorted by key,
+// normalized constraints, and 4 spaces indentation with unescaped slashes and unicode and a final new line
+func NormalizeJSON(data []byte) ([]byte, error) {
+	doc, err := decodeOrdered(data)
+	if err != nil {
+		return nil, err
+	}
+	root, ok := doc.(*jsonObject)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %s", data))
+	}
+
+	root = orderKeys(root, schemaKeyOrder)
+	for path, order := range nestedKeyOrder {
+		switch v := root.values[path].(type) {
+		case *jsonObject:
+			root.values[path] = orderKeys(v, order)
+		case []interface{}:
+			for i, item := range v {
+				if o, ok := item.(*jsonObject); ok {
+					v[i] = orderKeys(o, order)
+				}
+			}
+		}
+	}
+	for _, key := range packageMapKeys {
+		if links, ok := root.values[key].(*jsonObject); ok {
+			root.values[key] = sortPackageLinks(links)
+		}
+	}
+	for _, key := range constraintMapKeys {
+		if links, ok := root.values[key].(*jsonObject); ok {
+			for _, name := range links.keys {
+				var constraint string
+				if raw, ok := links.values[name].(json.RawMessage); ok && json.Unmarshal(raw, &constraint) == nil {
+					links.values[name] = marshalRaw(NormalizeConstraint(constraint))
+				}
+			}
+		}
+	}
+	for _, key := range sortedHashKeys {
+		if v, ok := root.values[key]; ok {
+			root.values[key] = sortKeysRecursive(key, v)
+		}
+	}
+	if bin, ok := root.values["bin"].([]interface{}); ok {
+		sort.SliceStable(bin, func(i, j int) bool { return encodeCompact(bin[i]) < encodeCompact(bin[j]) })
+	}
+	return append(composerEncoder.encode(root), '\n'), nil
+}
+
+// orderKeys return the object with the keys of order first, in that order, followed by the other keys sorted
+func orderKeys(o *jsonObject, order []string) *jsonObject {
+	ordered := newJSONObject()
+	known := map[string]bool{}
+	for _, k := range order {
+		known[k] = true
+		if v, ok := o.values[k]; ok {
+			ordered.set(k, v)
+		}
+	}
+	var others []string
+	for _, k := range o.keys {
+		if !known[k] {
+			others = append(others, k)
+		}
+	}
+	sort.Strings(others)
+	for _, k := range others {
+		ordered.set(k, o.values[k])
+	}
+	return ordered
+}
+
+// sortKeysRecursive sort objects by key, the "*" wildcard sorts last as Composer matches patterns in order
+func sortKeysRecursive(path string, v interface{}) interface{} {
+	switch t := v.(type) {
+	case *jsonObject:
+		keys := append([]string{}, t.keys...)
+		if !unsortedHashPaths[path] {
+			sort.SliceStable(keys, func(i, j int) bool {
+				wi, wj := keys[i] == "*", keys[j] == "*"
+				if wi != wj {
+					return wj
+				}
+				return keys[i] < keys[j]
+			})
+		}
+		sorted := newJSONObject()
+		for _, k := range keys {
+			sorted.set(k, sortKeysRecursive(path+"."+k, t.values[k]))
+		}
+		return sorted
+	case []interface{}:
+		for i, item := range t {
+			t[i] = sortKeysRecursive(path, item)
+		}
+	}
+	return v
+}
+
+// sortPackageLinks sort package links like Composer config.sort-packages: php, hhvm, ext-*, lib-*,
+// other platform packages, then packages, each in natural order
+func sortPackageLinks(o *jsonObject) *jsonObject {
+	keys := append([]string{}, o.keys...)
+	sort.SliceStable(keys, func(i, j int) bool {
+		return naturalCompare(packageSortKey(keys[i]), packageSortKey(keys[j])) < 0
+	})
+	sorted := newJSONObject()
+	for _, k := range keys {
+		sorted.set(k, o.values[k])
+	}
+	return sorted
+}
+
+// packageSortKey prefix a package name with its sort group, the way Composer JsonManipulator does
+func packageSortKey(name string) string {
+	if !IsPlatformPackage(name) {
+		return "5-" + name
+	}
+	for i, prefix := range []string{"php", "hhvm", "ext", "lib"} {
+		if strings.HasPrefix(name, prefix) {
+			return string(rune('0'+i)) + "-" + name
+		}
+	}
+	return "4-" + name
+}
+
+// naturalCompare compare strings like PHP strnatcmp, runs of digits compare by their numeric value
+func naturalCompare(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		ca, cb := a[i], b[j]
+		if isDigit(ca) && isDigit(cb) {
+			si, sj := i, j
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+			na, nb := strings.TrimLeft(a[si:i], "0"), strings.TrimLeft(b[sj:j], "0")
+			if len(na) != len(nb) {
+				return compareInts(len(na), len(nb))
+			}
+			if c := strings.Compare(na, nb); c != 0 {
+				return c
+			}
+			continue
+		}
+		if ca != cb {
+			return compareInts(int(ca), int(cb))
+		}
+		i++
+		j++
+	}
+	return compareInts(len(a)-i, len(b)-j)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func marshalRaw(s string) json.RawMessage {
+	data, _ := json.Marshal(s)
+	return data
+}
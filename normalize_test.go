@@ -0,0 +1,164 @@
+package composer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		want       string
+	}{
+		{"^1.0", "^1.0"},
+		{"  ^1.0  ", "^1.0"},
+		{"^1.0|^2.0", "^1.0 || ^2.0"},
+		{"^1.0||^2.0", "^1.0 || ^2.0"},
+		{"^1.0   ||    ^2.0", "^1.0 || ^2.0"},
+		{">=1.0,<1.5", ">=1.0 <1.5"},
+		{">=1.0 ,  <1.5 | ^2.0", ">=1.0 <1.5 || ^2.0"},
+		{"1.0 -  2.0", "1.0 - 2.0"},
+		{"dev-main as 1.0.x-dev", "dev-main as 1.0.x-dev"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			if got := NormalizeConstraint(tt.constraint); got != tt.want {
+				t.Errorf("NormalizeConstraint() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	m := &Manifest{
+		Require: map[string]string{"php": ">=7.4,<8.3"},
+		Bin:     StringOrStrings{"bin/z", "bin/a"},
+	}
+	Normalize(m)
+	if m.Require["php"] != ">=7.4 <8.3" {
+		t.Errorf("Normalize() require got = %v", m.Require)
+	}
+	if !reflect.DeepEqual(m.Bin, StringOrStrings{"bin/a", "bin/z"}) {
+		t.Errorf("Normalize() bin got = %v", m.Bin)
+	}
+}
+
+func TestNormalizeJSON(t *testing.T) {
+	input := `{
+  "autoload": {"files": ["a.php"], "psr-4": {"App\\": "src/"}},
+  "require": {
+    "symfony/console": "^6.0|^7.0",
+    "ext-json": "*",
+    "monolog/monolog": "^2.0",
+    "lib-icu": "*",
+    "php": "^8.1",
+    "ext-mbstring": "*",
+    "composer-plugin-api": "^2.0",
+    "acme/package10": "*",
+    "acme/package9": "*"
+  },
+  "name": "acme/app",
+  "config": {"sort-packages": true, "preferred-install": {"*": "dist", "acme/*": "source"}, "allow-plugins": {"b/b": true, "a/a": false}},
+  "extra": {"z": 1, "installer-paths": {"web/modules/{$name}": ["type:drupal-module"], "vendor/{$name}": ["type:library"]}, "a": {"y": 1, "x": 2}},
+  "authors": [{"email": "jane@example.com", "name": "Jane"}],
+  "x-custom": "é",
+  "bin": ["bin/z", "bin/a"],
+  "description": "An <app> & more"
+}`
+	want := `{
+    "name": "acme/app",
+    "description": "An <app> & more",
+    "authors": [
+        {
+            "name": "Jane",
+            "email": "jane@example.com"
+        }
+    ],
+    "require": {
+        "php": "^8.1",
+        "ext-json": "*",
+        "ext-mbstring": "*",
+        "lib-icu": "*",
+        "composer-plugin-api": "^2.0",
+        "acme/package9": "*",
+        "acme/package10": "*",
+        "monolog/monolog": "^2.0",
+        "symfony/console": "^6.0 || ^7.0"
+    },
+    "autoload": {
+        "psr-4": {
+            "App\\": "src/"
+        },
+        "files": [
+            "a.php"
+        ]
+    },
+    "bin": [
+        "bin/a",
+        "bin/z"
+    ],
+    "config": {
+        "allow-plugins": {
+            "a/a": false,
+            "b/b": true
+        },
+        "preferred-install": {
+            "acme/*": "source",
+            "*": "dist"
+        },
+        "sort-packages": true
+    },
+    "extra": {
+        "a": {
+            "x": 2,
+            "y": 1
+        },
+        "installer-paths": {
+            "web/modules/{$name}": [
+                "type:drupal-module"
+            ],
+            "vendor/{$name}": [
+                "type:library"
+            ]
+        },
+        "z": 1
+    },
+    "x-custom": "é"
+}
+`
+	got, err := NormalizeJSON([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("NormalizeJSON() got = %v, want %v", string(got), want)
+	}
+
+	again, err := NormalizeJSON(got)
+	if err != nil || string(again) != string(got) {
+		t.Errorf("NormalizeJSON() is not idempotent, got = %v", string(again))
+	}
+	if _, err := NormalizeJSON([]byte(`[]`)); err == nil {
+		t.Error("NormalizeJSON() expected an error")
+	}
+}
+
+func Test_naturalCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"a9", "a10", -1},
+		{"a10", "a9", 1},
+		{"a010", "a10", 0},
+		{"abc", "abd", -1},
+		{"ab", "abc", -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			if got := naturalCompare(tt.a, tt.b); got != tt.want {
+				t.Errorf("naturalCompare() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
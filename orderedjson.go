@@ -0,0 +1,323 @@
+package composer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// jsonObject is a decoded JSON object which keeps the order of its keys.
+// Documents decoded by decodeOrdered hold *jsonObject, []interface{} and json.RawMessage for scalars,
+// scalars keep their original text so an unchanged document encodes back to the same values
+type jsonObject struct {
+	keys   []string
+	values map[string]interface{}
+	// texts are the original texts of the values when the object was decoded by decodeOrderedText
+	texts map[string][]byte
+}
+
+func newJSONObject() *jsonObject {
+	return &jsonObject{values: map[string]interface{}{}}
+}
+
+func (o *jsonObject) get(key string) (interface{}, bool) {
+	v, ok := o.values[key]
+	return v, ok
+}
+
+// set a key, new keys are appended
+func (o *jsonObject) set(key string, v interface{}) {
+	delete(o.texts, key)
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = v
+}
+
+func (o *jsonObject) delete(key string) {
+	if _, ok := o.values[key]; !ok {
+		return
+	}
+	delete(o.values, key)
+	delete(o.texts, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// decodeOrdered decode a JSON document keeping the order of object keys
+func decodeOrdered(data []byte) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	d := &orderedDecoder{data: data}
+	return d.value(), nil
+}
+
+// decodeOrderedText decode a document like decodeOrdered and keep the text of every value of an object,
+// a raw phpEncoder writes the values which were not changed as they were written
+func decodeOrderedText(data []byte) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	d := &orderedDecoder{data: data, keepText: true}
+	return d.value(), nil
+}
+
+// orderedDecoder parse a document already known to be valid
+type orderedDecoder struct {
+	data     []byte
+	pos      int
+	keepText bool
+}
+
+func (d *orderedDecoder) skipSpace() {
+	for d.pos < len(d.data) {
+		switch d.data[d.pos] {
+		case ' ', '\t', '\n', '\r':
+			d.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (d *orderedDecoder) value() interface{} {
+	d.skipSpace()
+	switch d.data[d.pos] {
+	case '{':
+		d.pos++
+		o := newJSONObject()
+		for {
+			d.skipSpace()
+			if d.data[d.pos] == '}' {
+				d.pos++
+				return o
+			}
+			var key string
+			_ = json.Unmarshal(d.scalar(), &key)
+			d.skipSpace()
+			d.pos++ // ':'
+			d.skipSpace()
+			start := d.pos
+			o.set(key, d.value())
+			if d.keepText {
+				if o.texts == nil {
+					o.texts = map[string][]byte{}
+				}
+				o.texts[key] = d.data[start:d.pos]
+			}
+			d.skipSpace()
+			if d.data[d.pos] == ',' {
+				d.pos++
+			}
+		}
+	case '[':
+		d.pos++
+		list := []interface{}{}
+		for {
+			d.skipSpace()
+			if d.data[d.pos] == ']' {
+				d.pos++
+				return list
+			}
+			list = append(list, d.value())
+			d.skipSpace()
+			if d.data[d.pos] == ',' {
+				d.pos++
+			}
+		}
+	}
+	return json.RawMessage(d.scalar())
+}
+
+// scalar return the text of a string, number, boolean or null
+func (d *orderedDecoder) scalar() []byte {
+	start := d.pos
+	if d.data[d.pos] == '"' {
+		d.pos++
+		for d.data[d.pos] != '"' {
+			if d.data[d.pos] == '\\' {
+				d.pos++
+			}
+			d.pos++
+		}
+		d.pos++
+		return d.data[start:d.pos]
+	}
+	for d.pos < len(d.data) {
+		switch d.data[d.pos] {
+		case ',', '}', ']', ' ', '\t', '\n', '\r':
+			return d.data[start:d.pos]
+		}
+		d.pos++
+	}
+	return d.data[start:d.pos]
+}
+
+// phpEncoder encode an ordered document the way PHP json_encode does
+type phpEncoder struct {
+	// pretty is JSON_PRETTY_PRINT, indenting with indent
+	pretty bool
+	indent string
+	// unescapedSlashes is JSON_UNESCAPED_SLASHES and unescapedUnicode JSON_UNESCAPED_UNICODE
+	unescapedSlashes bool
+	unescapedUnicode bool
+	// phpArrays encode what json_decode($json, true) turns into lists as lists: empty objects
+	// and objects keyed "0", "1", ... in order
+	phpArrays bool
+	// raw write strings, and the values of objects decoded by decodeOrderedText, as they were written in the document
+	raw bool
+}
+
+func (e *phpEncoder) encode(v interface{}) []byte {
+	var b bytes.Buffer
+	e.write(&b, v, 0)
+	return b.Bytes()
+}
+
+func (e *phpEncoder) write(b *bytes.Buffer, v interface{}, depth int) {
+	switch t := v.(type) {
+	case *jsonObject:
+		if e.phpArrays && isPHPList(t) {
+			list := make([]interface{}, len(t.keys))
+			for i, k := range t.keys {
+				list[i] = t.values[k]
+			}
+			e.write(b, list, depth)
+			return
+		}
+		if len(t.keys) == 0 {
+			b.WriteString("{}")
+			return
+		}
+		b.WriteByte('{')
+		for i, k := range t.keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			e.newline(b, depth+1)
+			e.writeString(b, k)
+			b.WriteByte(':')
+			if e.pretty {
+				b.WriteByte(' ')
+			}
+			if text, ok := t.texts[k]; ok && e.raw {
+				b.Write(text)
+				continue
+			}
+			e.write(b, t.values[k], depth+1)
+		}
+		e.newline(b, depth)
+		b.WriteByte('}')
+	case []interface{}:
+		if len(t) == 0 {
+			b.WriteString("[]")
+			return
+		}
+		b.WriteByte('[')
+		for i, item := range t {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			e.newline(b, depth+1)
+			e.write(b, item, depth+1)
+		}
+		e.newline(b, depth)
+		b.WriteByte(']')
+	case json.RawMessage:
+		if len(t) > 0 && t[0] == '"' && !e.raw {
+			var s string
+			_ = json.Unmarshal(t, &s)
+			e.writeString(b, s)
+			return
+		}
+		b.Write(t)
+	}
+}
+
+func (e *phpEncoder) newline(b *bytes.Buffer, depth int) {
+	if !e.pretty {
+		return
+	}
+	b.WriteByte('\n')
+	for i := 0; i < depth; i++ {
+		b.WriteString(e.indent)
+	}
+}
+
+// writeString escape a string like json_encode: control characters, quotes and backslashes always,
+// slashes and non ASCII characters as \uXXXX unless the matching flag is set
+func (e *phpEncoder) writeString(b *bytes.Buffer, s string) {
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '/':
+			if e.unescapedSlashes {
+				b.WriteByte('/')
+			} else {
+				b.WriteString(`\/`)
+			}
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			switch {
+			case r < 0x20:
+				fmt.Fprintf(b, `\u%04x`, r)
+			case r == 0x2028 || r == 0x2029:
+				// escaped even with JSON_UNESCAPED_UNICODE unless JSON_UNESCAPED_LINE_TERMINATORS is set
+				fmt.Fprintf(b, `\u%04x`, r)
+			case r < utf8.RuneSelf || e.unescapedUnicode:
+				b.WriteRune(r)
+			case r > 0xffff:
+				r1, r2 := utf16.EncodeRune(r)
+				fmt.Fprintf(b, `\u%04x\u%04x`, r1, r2)
+			default:
+				fmt.Fprintf(b, `\u%04x`, r)
+			}
+		}
+	}
+	b.WriteByte('"')
+}
+
+// isPHPList reports whether json_decode($json, true) turns an object into a list
+func isPHPList(o *jsonObject) bool {
+	for i, k := range o.keys {
+		if k != strconv.Itoa(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedObject return a copy of an object with its keys sorted, like PHP ksort
+func sortedObject(o *jsonObject) *jsonObject {
+	sorted := newJSONObject()
+	keys := append([]string{}, o.keys...)
+	sort.Strings(keys)
+	for _, k := range keys {
+		sorted.set(k, o.values[k])
+	}
+	return sorted
+}
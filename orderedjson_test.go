@@ -0,0 +1,64 @@
+package composer
+
+import "testing"
+
+func TestPhpEncoder(t *testing.T) {
+	// escape builds a \uXXXX escape sequence
+	escape := func(hex string) string { return `\` + "u" + hex }
+	unicode := string(rune(0xe9)) + string(rune(0x1f600)) + string(rune(0x2028))
+	doc := `{"b": "a/b", "a": [1, 2.50, true, null], "u": "` + unicode + ` <>&", "e": {}, "l": [], "c": "` + `\"\\\n` + escape("0001") + `"}`
+	escaped := escape("00e9") + escape("d83d") + escape("de00") + escape("2028")
+	control := `\"\\\n` + escape("0001")
+	tests := []struct {
+		name    string
+		encoder *phpEncoder
+		want    string
+	}{
+		{"no flags", &phpEncoder{}, `{"b":"a\/b","a":[1,2.50,true,null],"u":"` + escaped + ` <>&","e":{},"l":[],"c":"` + control + `"}`},
+		{"unescaped", &phpEncoder{unescapedSlashes: true, unescapedUnicode: true}, `{"b":"a/b","a":[1,2.50,true,null],"u":"` + unicode[:len(unicode)-3] + escape("2028") + ` <>&","e":{},"l":[],"c":"` + control + `"}`},
+		{"php arrays", &phpEncoder{phpArrays: true}, `{"b":"a\/b","a":[1,2.50,true,null],"u":"` + escaped + ` <>&","e":[],"l":[],"c":"` + control + `"}`},
+		{"pretty", &phpEncoder{pretty: true, indent: "    ", raw: true}, `{
+    "b": "a/b",
+    "a": [
+        1,
+        2.50,
+        true,
+        null
+    ],
+    "u": "` + unicode + ` <>&",
+    "e": {},
+    "l": [],
+    "c": "` + control + `"
+}`},
+	}
+	v, err := decodeOrdered([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(tt.encoder.encode(v)); got != tt.want {
+				t.Errorf("encode() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeOrdered(t *testing.T) {
+	if _, err := decodeOrdered([]byte(`{"a": }`)); err == nil {
+		t.Error("decodeOrdered() expected an error")
+	}
+	v, err := decodeOrdered([]byte(` {"z": 1, "a": {"y": "}", "b": "\"]"}, "m": []} `))
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := v.(*jsonObject)
+	if got := o.keys; len(got) != 3 || got[0] != "z" || got[1] != "a" || got[2] != "m" {
+		t.Errorf("decodeOrdered() keys = %v", got)
+	}
+	o.delete("a")
+	o.set("n", o.values["z"])
+	if got := string(compactEncoder.encode(o)); got != `{"z":1,"m":[],"n":1}` {
+		t.Errorf("encode() got = %v", got)
+	}
+}
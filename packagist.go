@@ -0,0 +1,330 @@
+package composer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MinifiedFormat is the value of the "minified" key of p2 files written by Composer 2
+const MinifiedFormat = "composer/2.0"
+
+// minifiedUnset marks a key that must be removed from the previous version during expansion
+const minifiedUnset = "__unset"
+
+// Package of a Composer repository or of a composer.lock file.
+// It shares all fields with Manifest and adds the ones only a published version has
+type Package struct {
+	Manifest
+	VersionNormalized  string  `json:"version_normalized,omitempty"`
+	Source             *Source `json:"source,omitempty"`
+	Dist               *Dist   `json:"dist,omitempty"`
+	NotificationURL    string  `json:"notification-url,omitempty"`
+	InstallationSource string  `json:"installation-source,omitempty"`
+}
+
+// Source where a package can be cloned from
+type Source struct {
+	Type      string   `json:"type"`
+	Url       string   `json:"url"`
+	Reference string   `json:"reference"`
+	Mirrors   []Mirror `json:"mirrors,omitempty"`
+}
+
+// Dist where a package archive can be downloaded from
+type Dist struct {
+	Type      string   `json:"type"`
+	Url       string   `json:"url"`
+	Reference string   `json:"reference,omitempty"`
+	Shasum    string   `json:"shasum,omitempty"`
+	Mirrors   []Mirror `json:"mirrors,omitempty"`
+}
+
+// Mirror of a source or a dist url
+type Mirror struct {
+	Url       string `json:"url"`
+	Preferred Bool   `json:"preferred,omitempty"`
+}
+
+// RepositoryMirror is the "mirrors" entry of packages.json
+type RepositoryMirror struct {
+	DistUrl   string `json:"dist-url,omitempty"`
+	GitUrl    string `json:"git-url,omitempty"`
+	HgUrl     string `json:"hg-url,omitempty"`
+	Preferred Bool   `json:"preferred,omitempty"`
+}
+
+// RepositoryRoot is the packages.json document served at the root of a Composer repository
+type RepositoryRoot struct {
+	Packages                 InlinePackages              `json:"packages,omitempty"`
+	MetadataUrl              string                      `json:"metadata-url,omitempty"`
+	ProvidersUrl             string                      `json:"providers-url,omitempty"`
+	ProvidersApi             string                      `json:"providers-api,omitempty"`
+	ProviderIncludes         map[string]ProviderInclude  `json:"provider-includes,omitempty"`
+	Includes                 map[string]ProviderInclude  `json:"includes,omitempty"`
+	NotifyBatch              string                      `json:"notify-batch,omitempty"`
+	Search                   string                      `json:"search,omitempty"`
+	List                     string                      `json:"list,omitempty"`
+	Mirrors                  []RepositoryMirror          `json:"mirrors,omitempty"`
+	AvailablePackages        []string                    `json:"available-packages,omitempty"`
+	AvailablePackagePatterns []string                    `json:"available-package-patterns,omitempty"`
+	SecurityAdvisories       *SecurityAdvisoriesEndpoint `json:"security-advisories,omitempty"`
+	Warnings                 []RepositoryMessage         `json:"warnings,omitempty"`
+	Infos                    []RepositoryMessage         `json:"infos,omitempty"`
+}
+
+// ProviderInclude references an included file by its hash
+type ProviderInclude struct {
+	Sha256 string `json:"sha256,omitempty"`
+	Sha1   string `json:"sha1,omitempty"`
+}
+
+// SecurityAdvisoriesEndpoint describes where a repository publishes its security advisories
+type SecurityAdvisoriesEndpoint struct {
+	Metadata Bool   `json:"metadata,omitempty"`
+	ApiUrl   string `json:"api-url,omitempty"`
+}
+
+// RepositoryMessage is a warning or an info a repository wants to show to the user
+type RepositoryMessage struct {
+	Message     string `json:"message"`
+	VersionSpec string `json:"versions"`
+}
+
+// SecurityAdvisory of a package
+type SecurityAdvisory struct {
+	AdvisoryId       string                   `json:"advisoryId"`
+	PackageName      string                   `json:"packageName"`
+	RemoteId         string                   `json:"remoteId,omitempty"`
+	Title            string                   `json:"title"`
+	Link             string                   `json:"link,omitempty"`
+	Cve              string                   `json:"cve,omitempty"`
+	AffectedVersions string                   `json:"affectedVersions"`
+	Source           string                   `json:"source,omitempty"`
+	ReportedAt       string                   `json:"reportedAt,omitempty"`
+	ComposerRepo     string                   `json:"composerRepository,omitempty"`
+	Severity         string                   `json:"severity,omitempty"`
+	Sources          []SecurityAdvisorySource `json:"sources,omitempty"`
+}
+
+// SecurityAdvisorySource names a database an advisory was taken from
+type SecurityAdvisorySource struct {
+	Name     string `json:"name"`
+	RemoteId string `json:"remoteId"`
+}
+
+// InlinePackages convert packages listed inside packages.json into a map of versions
+// Example values
+//
+//	"packages": {
+//	    "vendor/name": {
+//	        "1.0.0": { "name": "vendor/name", "version": "1.0.0" }
+//	    }
+//	}
+//
+// or
+// "packages": []
+type InlinePackages map[string]map[string]Package
+
+// MarshalJSON marshal JSON into a map of versions
+func (p InlinePackages) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]map[string]Package(p))
+}
+
+// UnmarshalJSON convert a map of versions or an empty array into a map of versions
+func (p *InlinePackages) UnmarshalJSON(bytes []byte) error {
+	var m map[string]map[string]Package
+	if err := json.Unmarshal(bytes, &m); err == nil {
+		*p = m
+		return nil
+	}
+	var arr []interface{}
+	if err := json.Unmarshal(bytes, &arr); err == nil && len(arr) == 0 {
+		*p = InlinePackages{}
+		return nil
+	}
+	return errors.New(fmt.Sprintf("cannot unmarshal %s", bytes))
+}
+
+// PackageMetadata is a p2 file served at metadata-url, e.g. /p2/vendor/name.json or /p2/vendor/name~dev.json
+type PackageMetadata struct {
+	Packages           map[string][]Package          `json:"packages"`
+	Minified           string                        `json:"minified,omitempty"`
+	SecurityAdvisories map[string][]SecurityAdvisory `json:"security-advisories,omitempty"`
+}
+
+// UnmarshalJSON decode a p2 file and expand it when it is minified
+func (pm *PackageMetadata) UnmarshalJSON(bytes []byte) error {
+	var raw struct {
+		Packages           map[string][]map[string]json.RawMessage `json:"packages"`
+		Minified           string                                  `json:"minified"`
+		SecurityAdvisories json.RawMessage                         `json:"security-advisories"`
+	}
+	if err := json.Unmarshal(bytes, &raw); err != nil {
+		return err
+	}
+
+	packages := make(map[string][]Package, len(raw.Packages))
+	for name, versions := range raw.Packages {
+		if raw.Minified == MinifiedFormat {
+			versions = ExpandMinified(versions)
+		} else if raw.Minified != "" {
+			return errors.New("unsupported minified format " + raw.Minified)
+		}
+
+		decoded := make([]Package, len(versions))
+		for i, v := range versions {
+			b, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			if err := json.Unmarshal(b, &decoded[i]); err != nil {
+				return fmt.Errorf("cannot unmarshal %s version %d: %w", name, i, err)
+			}
+		}
+		packages[name] = decoded
+	}
+
+	advisories, err := unmarshalAdvisories(raw.SecurityAdvisories)
+	if err != nil {
+		return err
+	}
+
+	*pm = PackageMetadata{Packages: packages, Minified: raw.Minified, SecurityAdvisories: advisories}
+	return nil
+}
+
+// MarshalJSON encode a p2 file, minifying the versions when Minified is set
+func (pm PackageMetadata) MarshalJSON() ([]byte, error) {
+	type plain PackageMetadata
+	if pm.Minified == "" {
+		return json.Marshal(plain(pm))
+	}
+	if pm.Minified != MinifiedFormat {
+		return nil, errors.New("unsupported minified format " + pm.Minified)
+	}
+
+	packages := make(map[string][]map[string]json.RawMessage, len(pm.Packages))
+	for name, versions := range pm.Packages {
+		expanded := make([]map[string]json.RawMessage, len(versions))
+		for i, v := range versions {
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			if err := json.Unmarshal(b, &expanded[i]); err != nil {
+				return nil, err
+			}
+		}
+		packages[name] = MinifyExpanded(expanded)
+	}
+
+	return json.Marshal(struct {
+		Packages           map[string][]map[string]json.RawMessage `json:"packages"`
+		Minified           string                                  `json:"minified"`
+		SecurityAdvisories map[string][]SecurityAdvisory           `json:"security-advisories,omitempty"`
+	}{packages, pm.Minified, pm.SecurityAdvisories})
+}
+
+// unmarshalAdvisories accept both a list of advisories and a map of them keyed by package name
+func unmarshalAdvisories(bytes json.RawMessage) (map[string][]SecurityAdvisory, error) {
+	if len(bytes) == 0 || string(bytes) == "null" {
+		return nil, nil
+	}
+	var m map[string][]SecurityAdvisory
+	if err := json.Unmarshal(bytes, &m); err == nil {
+		return m, nil
+	}
+	var arr []SecurityAdvisory
+	if err := json.Unmarshal(bytes, &arr); err != nil {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal security advisories %s", bytes))
+	}
+	m = map[string][]SecurityAdvisory{}
+	for _, a := range arr {
+		m[a.PackageName] = append(m[a.PackageName], a)
+	}
+	return m, nil
+}
+
+// ExpandMinified expand versions written in the "composer/2.0" minified format.
+// Every version after the first one only lists the keys that changed compared to the
+// previous version, and "__unset" removes a key
+func ExpandMinified(versions []map[string]json.RawMessage) []map[string]json.RawMessage {
+	expanded := make([]map[string]json.RawMessage, 0, len(versions))
+	var current map[string]json.RawMessage
+	for _, v := range versions {
+		if current == nil {
+			current = make(map[string]json.RawMessage, len(v))
+		} else {
+			previous := current
+			current = make(map[string]json.RawMessage, len(previous))
+			for key, val := range previous {
+				current[key] = val
+			}
+		}
+		for key, val := range v {
+			if isMinifiedUnset(val) {
+				delete(current, key)
+				continue
+			}
+			current[key] = val
+		}
+		expanded = append(expanded, current)
+	}
+	return expanded
+}
+
+// MinifyExpanded is the reverse of ExpandMinified
+func MinifyExpanded(versions []map[string]json.RawMessage) []map[string]json.RawMessage {
+	minified := make([]map[string]json.RawMessage, 0, len(versions))
+	var previous map[string]json.RawMessage
+	for _, v := range versions {
+		if previous == nil {
+			minified = append(minified, v)
+			previous = v
+			continue
+		}
+		diff := map[string]json.RawMessage{}
+		for key, val := range v {
+			if prev, ok := previous[key]; !ok || !jsonEqual(prev, val) {
+				diff[key] = val
+			}
+		}
+		for key := range previous {
+			if _, ok := v[key]; !ok {
+				diff[key] = json.RawMessage(`"` + minifiedUnset + `"`)
+			}
+		}
+		minified = append(minified, diff)
+		previous = v
+	}
+	return minified
+}
+
+// MetadataUrl build the url of a p2 file from the metadata-url template of packages.json
+func MetadataUrl(template string, name string, dev bool) string {
+	if dev {
+		name += "~dev"
+	}
+	return strings.Replace(template, "%package%", name, -1)
+}
+
+func isMinifiedUnset(val json.RawMessage) bool {
+	var s string
+	return json.Unmarshal(val, &s) == nil && s == minifiedUnset
+}
+
+// jsonEqual compare two JSON values ignoring formatting and key order
+func jsonEqual(a, b json.RawMessage) bool {
+	var va, vb interface{}
+	if err := json.Unmarshal(a, &va); err != nil {
+		return false
+	}
+	if err := json.Unmarshal(b, &vb); err != nil {
+		return false
+	}
+	ja, _ := json.Marshal(va)
+	jb, _ := json.Marshal(vb)
+	return string(ja) == string(jb)
+}
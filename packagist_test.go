@@ -0,0 +1,190 @@
+package composer
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const minifiedMetadata = `{
+    "minified": "composer/2.0",
+    "packages": {
+        "monolog/monolog": [
+            {
+                "name": "monolog/monolog",
+                "description": "Sends your logs to files, sockets, inboxes, databases and various web services",
+                "version": "2.1.0",
+                "version_normalized": "2.1.0.0",
+                "license": ["MIT"],
+                "time": "2020-05-22T08:12:19+00:00",
+                "require": {"php": ">=7.2", "psr/log": "^1.0.1"},
+                "suggest": {"ext-mbstring": "Allow to work properly with unicode symbols"},
+                "source": {"type": "git", "url": "https://github.com/Seldaek/monolog.git", "reference": "38914429aac460e8e4616c8cb486ecb40ec90bb1"},
+                "dist": {"type": "zip", "url": "https://api.github.com/repos/Seldaek/monolog/zipball/38914429aac460e8e4616c8cb486ecb40ec90bb1", "reference": "38914429aac460e8e4616c8cb486ecb40ec90bb1", "shasum": ""},
+                "autoload": {"psr-4": {"Monolog\\": "src/Monolog"}},
+                "support": {"issues": "https://github.com/Seldaek/monolog/issues"},
+                "funding": [{"type": "github", "url": "https://github.com/Seldaek"}],
+                "extra": {"branch-alias": {"dev-main": "2.x-dev"}}
+            },
+            {
+                "version": "2.0.2",
+                "version_normalized": "2.0.2.0",
+                "suggest": "__unset",
+                "require": {"php": "^7.2", "psr/log": "^1.0.1"}
+            },
+            {
+                "version": "1.25.5",
+                "version_normalized": "1.25.5.0",
+                "extra": "__unset"
+            }
+        ]
+    }
+}`
+
+func TestPackageMetadata_UnmarshalJSON(t *testing.T) {
+	var pm PackageMetadata
+	if err := json.Unmarshal([]byte(minifiedMetadata), &pm); err != nil {
+		t.Fatalf("UnmarshalJSON() error = %v", err)
+	}
+
+	versions := pm.Packages["monolog/monolog"]
+	if len(versions) != 3 {
+		t.Fatalf("UnmarshalJSON() got %d versions, want 3", len(versions))
+	}
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"first version", versions[0].VersionNormalized, "2.1.0.0"},
+		{"name is inherited", versions[2].Name, "monolog/monolog"},
+		{"require is replaced", versions[1].Require["php"], "^7.2"},
+		{"suggest is unset", len(versions[1].Suggest), 0},
+		{"suggest stays unset", len(versions[2].Suggest), 0},
+		{"extra is kept", versions[1].Extra["branch-alias"], map[string]interface{}{"dev-main": "2.x-dev"}},
+		{"extra is unset", len(versions[2].Extra), 0},
+		{"dist is inherited", versions[2].Dist.Type, "zip"},
+		{"autoload is inherited", versions[2].Autoload.Psr4, Psr{"Monolog\\": {"src/Monolog"}}},
+		{"support", versions[0].Support.Homepage, "https://github.com/Seldaek/monolog/issues"},
+		{"funding", versions[0].Funding, []Funding{{Type: "github", Url: "https://github.com/Seldaek"}}},
+		{"time", versions[0].Time, "2020-05-22T08:12:19+00:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("UnmarshalJSON() got = %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPackageMetadata_MarshalJSON(t *testing.T) {
+	var pm PackageMetadata
+	if err := json.Unmarshal([]byte(minifiedMetadata), &pm); err != nil {
+		t.Fatalf("UnmarshalJSON() error = %v", err)
+	}
+	b, err := json.Marshal(pm)
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %v", err)
+	}
+
+	var raw struct {
+		Packages map[string][]map[string]json.RawMessage `json:"packages"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if _, ok := raw.Packages["monolog/monolog"][1]["name"]; ok {
+		t.Errorf("MarshalJSON() did not minify unchanged keys")
+	}
+	if got := string(raw.Packages["monolog/monolog"][1]["suggest"]); got != `"__unset"` {
+		t.Errorf("MarshalJSON() suggest = %s, want \"__unset\"", got)
+	}
+
+	var again PackageMetadata
+	if err := json.Unmarshal(b, &again); err != nil {
+		t.Fatalf("UnmarshalJSON() error = %v", err)
+	}
+	if !reflect.DeepEqual(pm, again) {
+		t.Errorf("round trip changed the metadata")
+	}
+}
+
+func TestExpandMinified(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions string
+		want     string
+	}{
+		{"nothing", `[]`, `[]`},
+		{"single", `[{"a":1}]`, `[{"a":1}]`},
+		{"override", `[{"a":1,"b":2},{"b":3}]`, `[{"a":1,"b":2},{"a":1,"b":3}]`},
+		{"unset", `[{"a":1,"b":2},{"b":"__unset"},{"c":4}]`, `[{"a":1,"b":2},{"a":1},{"a":1,"c":4}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var versions []map[string]json.RawMessage
+			if err := json.Unmarshal([]byte(tt.versions), &versions); err != nil {
+				t.Fatal(err)
+			}
+			got, _ := json.Marshal(ExpandMinified(versions))
+			if string(got) != tt.want {
+				t.Errorf("ExpandMinified() got = %s, want %s", got, tt.want)
+			}
+			minified, _ := json.Marshal(MinifyExpanded(ExpandMinified(versions)))
+			if string(minified) != tt.versions {
+				t.Errorf("MinifyExpanded() got = %s, want %s", minified, tt.versions)
+			}
+		})
+	}
+}
+
+func TestRepositoryRoot_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    RepositoryRoot
+		wantErr bool
+	}{
+		{"packagist", `{"packages":[],"metadata-url":"/p2/%package%.json","providers-url":"/p/%package%$%hash%.json","security-advisories":{"metadata":true,"api-url":"https://packagist.org/api/security-advisories/"}}`,
+			RepositoryRoot{Packages: InlinePackages{}, MetadataUrl: "/p2/%package%.json", ProvidersUrl: "/p/%package%$%hash%.json", SecurityAdvisories: &SecurityAdvisoriesEndpoint{Metadata: true, ApiUrl: "https://packagist.org/api/security-advisories/"}}, false},
+		{"available packages", `{"metadata-url":"/p2/%package%.json","available-packages":["a/b","c/d"]}`,
+			RepositoryRoot{MetadataUrl: "/p2/%package%.json", AvailablePackages: []string{"a/b", "c/d"}}, false},
+		{"inline packages", `{"packages":{"a/b":{"1.0.0":{"name":"a/b","version":"1.0.0"}}}}`,
+			RepositoryRoot{Packages: InlinePackages{"a/b": {"1.0.0": Package{Manifest: Manifest{Name: "a/b", Version: "1.0.0"}}}}}, false},
+		{"invalid packages", `{"packages":"a/b"}`, RepositoryRoot{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got RepositoryRoot
+			if err := json.Unmarshal([]byte(tt.data), &got); (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSON() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetadataUrl(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		pkg      string
+		dev      bool
+		want     string
+	}{
+		{"tagged", "/p2/%package%.json", "monolog/monolog", false, "/p2/monolog/monolog.json"},
+		{"dev", "/p2/%package%.json", "monolog/monolog", true, "/p2/monolog/monolog~dev.json"},
+		{"absolute", "https://repo.example.com/p2/%package%.json", "a/b", false, "https://repo.example.com/p2/a/b.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MetadataUrl(tt.template, tt.pkg, tt.dev); got != tt.want {
+				t.Errorf("MetadataUrl() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package composer
+
+import (
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+// classTypes are the keywords declaring a class-like symbol, enum is PHP 8.1
+var classTypes = []string{"class", "interface", "trait", "enum"}
+
+var classCandidateRegex = regexp.MustCompile(`(?i)\b(?:class|interface|trait|enum)\s`)
+
+// FindClasses return the fully qualified names of the classes, interfaces, traits and enums declared
+// in PHP source, the way Composer PhpFileParser does. Strings, heredocs, nowdocs, comments and inline HTML
+// are skipped first, namespaces apply to the declarations which follow them, braced or not.
+// Foo::class, $class, ->class and anonymous classes are not declarations
+func FindClasses(contents []byte) []string {
+	candidates := len(classCandidateRegex.FindAllIndex(contents, 2))
+	if candidates == 0 {
+		return nil
+	}
+	cleaned := (&phpCleaner{contents: contents, maxMatches: candidates}).clean()
+
+	var classes []string
+	namespace := ""
+	for i := 0; i < len(cleaned); i++ {
+		if !declarationBoundary(cleaned, i) {
+			continue
+		}
+		if end, ns, ok := matchNamespace(cleaned, i); ok {
+			namespace = ns + `\`
+			i = end - 1
+			continue
+		}
+		end, keyword, name, ok := matchDeclaration(cleaned, i)
+		if !ok {
+			continue
+		}
+		i = end - 1
+		if name == "extends" || name == "implements" {
+			continue
+		}
+		switch {
+		case name[0] == ':':
+			// an XHP class, https://github.com/facebook/xhp
+			name = "xhp" + strings.NewReplacer("-", "_", ":", "__").Replace(name[1:])
+		case keyword == "enum":
+			// the colon of a backed enum, e.g. "enum Suit: string", is not part of the name
+			if colon := strings.LastIndex(name, ":"); colon != -1 {
+				name = name[:colon]
+			}
+		}
+		classes = append(classes, strings.TrimLeft(namespace+name, `\`))
+	}
+	return classes
+}
+
+// FindClassesInFile return the classes declared in a PHP file, see FindClasses
+func FindClassesInFile(file string) ([]string, error) {
+	contents, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return FindClasses(contents), nil
+}
+
+// declarationBoundary reports whether a keyword can start at i: at a word boundary and not after $, : or >
+func declarationBoundary(s []byte, i int) bool {
+	if i == 0 {
+		return true
+	}
+	switch c := s[i-1]; {
+	case isWordByte(c), c == '$', c == ':', c == '>':
+		return false
+	}
+	return true
+}
+
+// matchDeclaration match "class Name" at i, the keyword is case insensitive
+func matchDeclaration(s []byte, i int) (end int, keyword, name string, ok bool) {
+	for _, t := range classTypes {
+		if !hasPrefixFold(s[i:], t) {
+			continue
+		}
+		j := i + len(t)
+		k := skipPHPSpace(s, j)
+		if k == j || k == len(s) || !isNameStart(s[k]) && s[k] != ':' {
+			return 0, "", "", false
+		}
+		start := k
+		for k < len(s) && (isNameByte(s[k]) || s[k] == ':' || s[k] == '-') {
+			k++
+		}
+		return k, t, string(s[start:k]), true
+	}
+	return 0, "", "", false
+}
+
+// matchNamespace match a namespace declaration at i, "namespace Foo\Bar;" or "namespace Foo {" or "namespace {"
+func matchNamespace(s []byte, i int) (end int, namespace string, ok bool) {
+	if !hasPrefixFold(s[i:], "namespace") {
+		return 0, "", false
+	}
+	j := i + len("namespace")
+	var name []byte
+	if k := skipPHPSpace(s, j); k > j && k < len(s) && isNameStart(s[k]) {
+		for {
+			start := k
+			for k < len(s) && isNameByte(s[k]) {
+				k++
+			}
+			name = append(name, s[start:k]...)
+			j = k
+			sep := skipPHPSpace(s, k)
+			if sep == len(s) || s[sep] != '\\' {
+				break
+			}
+			next := skipPHPSpace(s, sep+1)
+			if next == len(s) || !isNameStart(s[next]) {
+				break
+			}
+			name = append(name, '\\')
+			k = next
+		}
+	}
+	j = skipPHPSpace(s, j)
+	if j == len(s) || s[j] != '{' && s[j] != ';' {
+		return 0, "", false
+	}
+	return j + 1, string(name), true
+}
+
+func hasPrefixFold(s []byte, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(string(s[:len(prefix)]), prefix)
+}
+
+// skipPHPSpace return the position of the first byte from i which is not a PCRE \s
+func skipPHPSpace(s []byte, i int) int {
+	for i < len(s) && isPHPSpace(s[i]) {
+		i++
+	}
+	return i
+}
+
+func isPHPSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
+
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || isDigit(c) || c == '_'
+}
+
+// isNameStart and isNameByte are the bytes of PHP labels, bytes from 0x7f are parts of multibyte names
+func isNameStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c >= 0x7f
+}
+
+func isNameByte(c byte) bool {
+	return isNameStart(c) || isDigit(c)
+}
+
+// phpCleaner strip what cannot hold declarations from PHP source like Composer PhpFileCleaner:
+// inline HTML, comments, and strings, heredocs and nowdocs which are replaced by null
+type phpCleaner struct {
+	contents []byte
+	index    int
+	// maxMatches is the number of declaration candidates, with one the cleaning stops at the declaration
+	maxMatches int
+}
+
+func (p *phpCleaner) clean() []byte {
+	var clean []byte
+	for p.index < len(p.contents) {
+		p.skipToPHP()
+		clean = append(clean, "<?"...)
+
+	code:
+		for p.index < len(p.contents) {
+			c := p.contents[p.index]
+			switch {
+			case c == '?' && p.peek('>'):
+				clean = append(clean, "?>"...)
+				p.index += 2
+				break code
+			case c == '"' || c == '\'':
+				p.skipString(c)
+				clean = append(clean, "null"...)
+				continue
+			case c == '<' && p.peek('<'):
+				if end, delimiter, ok := p.matchHeredoc(); ok {
+					p.index = end
+					p.skipHeredoc(delimiter)
+					clean = append(clean, "null"...)
+					continue
+				}
+			case c == '/' && p.peek('/'):
+				p.skipToNewline()
+				continue
+			case c == '/' && p.peek('*'):
+				p.skipComment()
+				continue
+			}
+			if p.maxMatches == 1 && p.index > 0 && declarationBoundary(p.contents, p.index) {
+				if end, keyword, _, ok := matchDeclaration(p.contents, p.index); ok && string(p.contents[p.index:p.index+len(keyword)]) == keyword {
+					return append(clean, p.contents[p.index:end]...)
+				}
+			}
+			clean = append(clean, c)
+			p.index++
+		}
+	}
+	return clean
+}
+
+func (p *phpCleaner) peek(c byte) bool {
+	return p.index+1 < len(p.contents) && p.contents[p.index+1] == c
+}
+
+func (p *phpCleaner) skipToPHP() {
+	for p.index < len(p.contents) {
+		if p.contents[p.index] == '<' && p.peek('?') {
+			p.index += 2
+			return
+		}
+		p.index++
+	}
+}
+
+func (p *phpCleaner) skipString(delimiter byte) {
+	p.index++
+	for p.index < len(p.contents) {
+		c := p.contents[p.index]
+		if c == '\\' && (p.peek('\\') || p.peek(delimiter)) {
+			p.index += 2
+			continue
+		}
+		p.index++
+		if c == delimiter {
+			return
+		}
+	}
+}
+
+func (p *phpCleaner) skipComment() {
+	p.index += 2
+	for p.index < len(p.contents) {
+		if p.contents[p.index] == '*' && p.peek('/') {
+			p.index += 2
+			return
+		}
+		p.index++
+	}
+}
+
+func (p *phpCleaner) skipToNewline() {
+	for p.index < len(p.contents) && p.contents[p.index] != '\r' && p.contents[p.index] != '\n' {
+		p.index++
+	}
+}
+
+// matchHeredoc match the start of a heredoc or a nowdoc at the index, e.g. <<<EOT or <<<'EOT' and a new line
+func (p *phpCleaner) matchHeredoc() (end int, delimiter []byte, ok bool) {
+	s := p.contents
+	i := p.index + 3
+	if i > len(s) || string(s[p.index:i]) != "<<<" {
+		return 0, nil, false
+	}
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
+		i++
+	}
+	var quote byte
+	if i < len(s) && (s[i] == '\'' || s[i] == '"') {
+		quote = s[i]
+		i++
+	}
+	start := i
+	if i == len(s) || !isLabelByte(s[i]) || isDigit(s[i]) {
+		return 0, nil, false
+	}
+	for i < len(s) && isLabelByte(s[i]) {
+		i++
+	}
+	delimiter = s[start:i]
+	if quote != 0 {
+		if i == len(s) || s[i] != quote {
+			return 0, nil, false
+		}
+		i++
+	}
+	switch {
+	case i+1 < len(s) && s[i] == '\r' && s[i+1] == '\n':
+		return i + 2, delimiter, true
+	case i < len(s) && (s[i] == '\n' || s[i] == '\r'):
+		return i + 1, delimiter, true
+	}
+	return 0, nil, false
+}
+
+// isLabelByte is a byte of a heredoc label, unlike class names 0x7f is not part of it
+func isLabelByte(c byte) bool {
+	return isNameByte(c) && c != 0x7f
+}
+
+// skipHeredoc skip the lines of a heredoc up to its closing delimiter, which may be indented since PHP 7.3
+func (p *phpCleaner) skipHeredoc(delimiter []byte) {
+	for p.index < len(p.contents) {
+		switch c := p.contents[p.index]; {
+		case c == ' ' || c == '\t':
+			p.index++
+			continue
+		case c == delimiter[0]:
+			end := p.index + len(delimiter)
+			if end <= len(p.contents) && string(p.contents[p.index:end]) == string(delimiter) &&
+				(end == len(p.contents) || !isLabelByte(p.contents[end])) {
+				p.index = end
+				return
+			}
+		}
+		p.skipToNewline()
+		for p.index < len(p.contents) && (p.contents[p.index] == '\r' || p.contents[p.index] == '\n') {
+			p.index++
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package composer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindClasses(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     []string
+	}{
+		{
+			name:     "no declaration",
+			contents: "<?php\necho 'hello';\n",
+			want:     nil,
+		},
+		{
+			name:     "global namespace",
+			contents: "<?php\nclass Foo {}\ninterface Bar {}\ntrait Baz {}\n",
+			want:     []string{"Foo", "Bar", "Baz"},
+		},
+		{
+			name:     "namespace",
+			contents: "<?php\n\nnamespace Acme\\Util;\n\nuse Other\\Thing;\n\nfinal class Str extends Thing implements \\Stringable\n{\n}\n",
+			want:     []string{"Acme\\Util\\Str"},
+		},
+		{
+			name:     "namespace with spaces",
+			contents: "<?php namespace Acme \\ Util ;\nabstract class Str {}\n",
+			want:     []string{"Acme\\Util\\Str"},
+		},
+		{
+			name: "braced namespaces",
+			contents: "<?php\nnamespace Foo {\n    class A {}\n}\nnamespace Bar\\Baz {\n    interface B {}\n}\n" +
+				"namespace {\n    class C {}\n}\n",
+			want: []string{"Foo\\A", "Bar\\Baz\\B", "C"},
+		},
+		{
+			name:     "enums",
+			contents: "<?php\nnamespace App;\nenum Status {}\nenum Suit: string {}\nenum Size:int {}\n",
+			want:     []string{"App\\Status", "App\\Suit", "App\\Size"},
+		},
+		{
+			name: "keywords which are not declarations",
+			contents: "<?php\nnamespace App;\n$name = Foo::class;\n$object->class = 1;\n$class = new class {};\n" +
+				"$a = new class extends Bar {};\n$b = new class implements Baz {};\n$c = new class(1) {};\n" +
+				"class Real {}\n",
+			want: []string{"App\\Real"},
+		},
+		{
+			name:     "comments",
+			contents: "<?php\n// class Line {}\n/* class Block {}\n */\n/** @see class Doc */\nclass Real {}\n",
+			want:     []string{"Real"},
+		},
+		{
+			name: "strings",
+			contents: "<?php\n$a = 'class Single {}';\n$b = \"class Double { \\\" } class Escaped {}\";\n" +
+				"$c = 'it\\'s class Quote {}';\nclass Real {}\n",
+			want: []string{"Real"},
+		},
+		{
+			name: "heredoc and nowdoc",
+			contents: "<?php\n$a = <<<EOT\nclass Heredoc {}\nEOT;\n$b = <<<'EOT'\nclass Nowdoc {}\nEOT;\n" +
+				"$c = <<<\"SQL\"\n    class Indented {}\n    SQL;\n$d = <<<EOT\nEOTX class Longer {}\n  EOT . 'x';\nclass Real {}\n",
+			want: []string{"Real"},
+		},
+		{
+			name:     "inline html",
+			contents: "<html>class Html {}</html>\n<?php class Foo {} ?>\n<p>class Text {}</p>\n<?php class Bar {}\n",
+			want:     []string{"Foo", "Bar"},
+		},
+		{
+			name:     "case insensitive keywords",
+			contents: "<?php\nNAMESPACE Acme;\nClass Foo {}\nINTERFACE Bar {}\n",
+			want:     []string{"Acme\\Foo", "Acme\\Bar"},
+		},
+		{
+			name:     "single declaration",
+			contents: "<?php\nnamespace Acme;\n\n/** test */\nclass Foo\n{\n    public function bar() { return \"}\"; }\n}\n",
+			want:     []string{"Acme\\Foo"},
+		},
+		{
+			name:     "xhp",
+			contents: "<?hh\nclass :foo:bar-baz {}\n",
+			want:     []string{"xhpfoo__bar_baz"},
+		},
+		{
+			name:     "words containing keywords",
+			contents: "<?php\nfunction subclass() {}\n$x = myclass ($y);\nclass Real {}\n",
+			want:     []string{"Real"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FindClasses([]byte(tt.contents)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindClasses() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package composer
+
+import (
+	"strconv"
+	"strings"
+)
+
+// PHPMinorVersions are the PHP minor versions considered by PHPVersionRange, in ascending order.
+// A range without an upper bound, e.g. ">=7.4", ends at the last one, "8.5"
+var PHPMinorVersions = []string{
+	"5.3", "5.4", "5.5", "5.6",
+	"7.0", "7.1", "7.2", "7.3", "7.4",
+	"8.0", "8.1", "8.2", "8.3", "8.4", "8.5",
+}
+
+// PHPVersionRange is the range of PHP minor versions a whole dependency tree supports
+type PHPVersionRange struct {
+	// Lowest and Highest supported minor versions, e.g. "7.4" and "8.3", empty when none is supported.
+	// Highest is "8.5", the last of PHPMinorVersions, when no requirement has an upper bound
+	Lowest  string
+	Highest string
+	// Supported lists every supported minor version, a range can have holes, e.g. "^7.4 || ^8.1"
+	Supported []string
+	// LowestSetBy and HighestSetBy name the packages excluding the minor version right below Lowest
+	// and right above Highest, RootPackageName stands for the root package. When no package excludes it
+	// on its own, they name the smallest set of packages whose requirements exclude it together
+	LowestSetBy  []string
+	HighestSetBy []string
+}
+
+// PHPVersionRange intersect the php requirements of the root package and of every locked package and
+// return the lowest and highest PHP minor versions the intersection matches, out of PHPMinorVersions.
+// Packages without a php requirement, or with an invalid one, do not restrict the range
+func (l *Lock) PHPVersionRange(noDev bool) PHPVersionRange {
+	requirements := map[string]Constraint{}
+	var names []string
+	add := func(name string, require map[string]string) {
+		for target, pretty := range require {
+			if strings.ToLower(target) != "php" {
+				continue
+			}
+			c, err := ParseConstraints(pretty)
+			if err != nil {
+				continue
+			}
+			if _, ok := requirements[name]; ok {
+				c = newMultiConstraint([]Constraint{requirements[name], c}, true)
+			} else {
+				names = append(names, name)
+			}
+			requirements[name] = c
+		}
+	}
+	add(RootPackageName, l.Platform)
+	if !noDev {
+		add(RootPackageName, l.PlatformDev)
+	}
+	packages := l.Packages
+	if !noDev {
+		packages = append(append([]Package{}, l.Packages...), l.PackagesDev...)
+	}
+	for i := range packages {
+		add(strings.ToLower(packages[i].Name), packages[i].Require)
+	}
+
+	all := make([]Constraint, len(names))
+	for i, name := range names {
+		all[i] = requirements[name]
+	}
+
+	// excluders[i] are the packages which do not support PHPMinorVersions[i]
+	excluders := make([][]string, len(PHPMinorVersions))
+	var r PHPVersionRange
+	first, last := -1, -1
+	for i, minor := range PHPMinorVersions {
+		m := minorConstraint(minor)
+		if len(all) == 0 || Intersects(newMultiConstraint(all, true), m) {
+			r.Supported = append(r.Supported, minor)
+			if first == -1 {
+				first = i
+			}
+			last = i
+			continue
+		}
+		for _, name := range names {
+			if !Intersects(requirements[name], m) {
+				excluders[i] = append(excluders[i], name)
+			}
+		}
+		if len(excluders[i]) == 0 {
+			excluders[i] = jointExcluders(names, requirements, m)
+		}
+	}
+	if first == -1 {
+		return r
+	}
+
+	r.Lowest, r.Highest = PHPMinorVersions[first], PHPMinorVersions[last]
+	if first > 0 {
+		r.LowestSetBy = excluders[first-1]
+	}
+	if last < len(PHPMinorVersions)-1 {
+		r.HighestSetBy = excluders[last+1]
+	}
+	return r
+}
+
+// jointExcluders return a smallest set of packages whose requirements together exclude a minor version,
+// each of them supports it on its own
+func jointExcluders(names []string, requirements map[string]Constraint, minor Constraint) []string {
+	culprits := append([]string{}, names...)
+	for _, name := range names {
+		var rest []Constraint
+		var restNames []string
+		for _, c := range culprits {
+			if c != name {
+				rest = append(rest, requirements[c])
+				restNames = append(restNames, c)
+			}
+		}
+		if len(rest) > 0 && !Intersects(newMultiConstraint(rest, true), minor) {
+			culprits = restNames
+		}
+	}
+	return culprits
+}
+
+// minorConstraint return the constraint matching every release of a PHP minor version, e.g. "7.4" into ">=7.4.0 <7.5.0-dev"
+func minorConstraint(minor string) Constraint {
+	parts := strings.SplitN(minor, ".", 2)
+	next, _ := strconv.Atoi(parts[1])
+	return MustParseConstraints(">=" + minor + ".0 <" + parts[0] + "." + strconv.Itoa(next+1) + ".0-dev")
+}
@@ -0,0 +1,88 @@
+package composer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLock_PHPVersionRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		root     string
+		packages map[string]string
+		noDev    bool
+		want     PHPVersionRange
+	}{
+		{
+			name:     "intersection",
+			root:     "^7.2 || ^8.0",
+			packages: map[string]string{"monolog/monolog": ">=7.2", "symfony/console": ">=7.4 <8.3"},
+			want: PHPVersionRange{
+				Lowest: "7.4", Highest: "8.2",
+				Supported:    []string{"7.4", "8.0", "8.1", "8.2"},
+				LowestSetBy:  []string{"symfony/console"},
+				HighestSetBy: []string{"symfony/console"},
+			},
+		},
+		{
+			name:     "bound set by several packages",
+			root:     "^8.1",
+			packages: map[string]string{"a/a": "~8.1.0 || ~8.2.0", "b/b": ">=8.0 <8.3"},
+			want: PHPVersionRange{
+				Lowest: "8.1", Highest: "8.2",
+				Supported:    []string{"8.1", "8.2"},
+				LowestSetBy:  []string{RootPackageName, "a/a"},
+				HighestSetBy: []string{"a/a", "b/b"},
+			},
+		},
+		{
+			name:     "holes",
+			root:     "^7.4 || ^8.1",
+			packages: map[string]string{"a/a": "*"},
+			want: PHPVersionRange{
+				Lowest: "7.4", Highest: "8.5",
+				Supported:   []string{"7.4", "8.1", "8.2", "8.3", "8.4", "8.5"},
+				LowestSetBy: []string{RootPackageName},
+			},
+		},
+		{
+			name:     "requirements excluding a minor together",
+			root:     ">=8.1.10",
+			packages: map[string]string{"a/a": "<8.1.5 || >=8.2", "b/b": ">=7.4"},
+			want: PHPVersionRange{
+				Lowest: "8.2", Highest: "8.5",
+				Supported:   []string{"8.2", "8.3", "8.4", "8.5"},
+				LowestSetBy: []string{RootPackageName, "a/a"},
+			},
+		},
+		{
+			name:     "unsatisfiable",
+			root:     "^7.4",
+			packages: map[string]string{"a/a": "^8.0"},
+			want:     PHPVersionRange{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lock := &Lock{Platform: PlatformVersion{"php": tt.root}}
+			for _, name := range sortedKeys(tt.packages) {
+				lock.Packages = append(lock.Packages, *testPackage(name, "1.0.0", map[string]string{"require:php": tt.packages[name]}))
+			}
+			if got := lock.PHPVersionRange(tt.noDev); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PHPVersionRange() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLock_PHPVersionRange_dev(t *testing.T) {
+	lock := &Lock{Platform: PlatformVersion{"php": "^7.4 || ^8.0"}}
+	lock.PackagesDev = append(lock.PackagesDev, *testPackage("phpunit/phpunit", "10.0.0", map[string]string{"require:php": ">=8.1"}))
+
+	if got := lock.PHPVersionRange(false); got.Lowest != "8.1" || !reflect.DeepEqual(got.LowestSetBy, []string{"phpunit/phpunit"}) {
+		t.Errorf("PHPVersionRange() got = %+v", got)
+	}
+	if got := lock.PHPVersionRange(true); got.Lowest != "7.4" {
+		t.Errorf("PHPVersionRange() without dev got = %+v", got)
+	}
+}
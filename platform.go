@@ -0,0 +1,287 @@
+package composer
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Platform describes the runtime a project is deployed to
+type Platform struct {
+	// PHP version, e.g. "8.1.2" or "8.1.2-1ubuntu2.14"
+	PHP      string
+	PHP64Bit bool
+	PHPIPv6  bool
+	PHPZTS   bool
+	PHPDebug bool
+	// Extensions loaded by PHP and their versions, e.g. {"json": "8.1.2", "Zend OPcache": "8.1.2"}
+	Extensions map[string]string
+	// Libraries PHP is linked with and their versions, e.g. {"openssl": "3.0.2", "icu": "70.1"}
+	Libraries map[string]string
+}
+
+// Packages return the platform packages and their versions, e.g. {"php": "8.1.2", "ext-json": "8.1.2"}.
+// The result can be given to NewPlatformRepository
+func (p Platform) Packages() map[string]string {
+	packages := map[string]string{}
+	if p.PHP != "" {
+		version := platformVersion(p.PHP)
+		packages["php"] = version
+		for name, enabled := range map[string]bool{"php-64bit": p.PHP64Bit, "php-ipv6": p.PHPIPv6, "php-zts": p.PHPZTS, "php-debug": p.PHPDebug} {
+			if enabled {
+				packages[name] = version
+			}
+		}
+	}
+	for name, version := range p.Extensions {
+		packages["ext-"+NormalizeExtensionName(name)] = platformVersion(version)
+	}
+	for name, version := range p.Libraries {
+		packages["lib-"+strings.ToLower(name)] = platformVersion(version)
+	}
+	return packages
+}
+
+// WithOverrides return the platform packages with config.platform applied, overrides win over detected versions
+func (p Platform) WithOverrides(overrides map[string]string) map[string]string {
+	packages := p.Packages()
+	for name, version := range overrides {
+		packages[NormalizePlatformName(name)] = version
+	}
+	return packages
+}
+
+var platformVersionSuffix = regexp.MustCompile(`^([^~+-]+).*$`)
+
+// platformVersion strip distribution suffixes from a version, e.g. "8.1.2-1ubuntu2.14" into "8.1.2"
+func platformVersion(version string) string {
+	if _, err := NormalizeVersion(version); err == nil {
+		return version
+	}
+	return platformVersionSuffix.ReplaceAllString(version, "$1")
+}
+
+var platformPackageRegex = regexp.MustCompile(`(?i)^(?:php(?:-64bit|-ipv6|-zts|-debug)?|hhvm|(?:ext|lib)-[a-z0-9](?:[_.-]?[a-z0-9]+)*|composer(?:-(?:plugin|runtime)-api)?)$`)
+
+// IsPlatformPackage reports whether a name refers to the platform rather than to a package,
+// e.g. "php", "ext-json", "lib-openssl" or "composer-plugin-api"
+func IsPlatformPackage(name string) bool {
+	return platformPackageRegex.MatchString(name)
+}
+
+// PlatformKind is the kind of a platform package
+type PlatformKind string
+
+// Platform package kinds
+const (
+	PlatformNone      PlatformKind = ""
+	PlatformPHP       PlatformKind = "php"
+	PlatformExtension PlatformKind = "ext"
+	PlatformLibrary   PlatformKind = "lib"
+	PlatformComposer  PlatformKind = "composer"
+	PlatformHHVM      PlatformKind = "hhvm"
+)
+
+// PlatformPackageKind classify a requirement name, PlatformNone is returned for regular packages
+func PlatformPackageKind(name string) PlatformKind {
+	if !IsPlatformPackage(name) {
+		return PlatformNone
+	}
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasPrefix(name, "ext-"):
+		return PlatformExtension
+	case strings.HasPrefix(name, "lib-"):
+		return PlatformLibrary
+	case strings.HasPrefix(name, "php"):
+		return PlatformPHP
+	case strings.HasPrefix(name, "composer"):
+		return PlatformComposer
+	}
+	return PlatformHHVM
+}
+
+// extensionAliases map extension names to the name of their ext-* package
+var extensionAliases = map[string]string{
+	"opcache":      "zend-opcache",
+	"zend-opcache": "zend-opcache",
+}
+
+// NormalizeExtensionName normalize the name of a PHP extension the way Composer names ext-* packages,
+// e.g. "Zend OPcache" into "zend-opcache". The "ext-" prefix is kept when given
+func NormalizeExtensionName(name string) string {
+	prefix := ""
+	if len(name) > 4 && strings.EqualFold(name[:4], "ext-") {
+		prefix, name = "ext-", name[4:]
+	}
+	name = strings.ToLower(strings.Replace(strings.TrimSpace(name), " ", "-", -1))
+	if alias, ok := extensionAliases[name]; ok {
+		name = alias
+	}
+	return prefix + name
+}
+
+// NormalizePlatformName lowercase a platform package name and normalize extension names,
+// e.g. "ext-Zend OPcache" into "ext-zend-opcache". Other names are only lowercased
+func NormalizePlatformName(name string) string {
+	if len(name) > 4 && strings.EqualFold(name[:4], "ext-") {
+		return NormalizeExtensionName(name)
+	}
+	return strings.ToLower(name)
+}
+
+// SplitRequirements split a require map, e.g. Manifest.Require, into platform requirements and package requirements
+func SplitRequirements(require map[string]string) (platform map[string]string, packages map[string]string) {
+	platform, packages = map[string]string{}, map[string]string{}
+	for name, constraint := range require {
+		if IsPlatformPackage(name) {
+			platform[name] = constraint
+		} else {
+			packages[name] = constraint
+		}
+	}
+	return platform, packages
+}
+
+// Platform check statuses
+const (
+	PlatformSuccess = "success"
+	PlatformFailed  = "failed"
+	PlatformMissing = "missing"
+)
+
+// PlatformRequirement is one package requiring a platform package
+type PlatformRequirement struct {
+	Source     string
+	Constraint string
+}
+
+// PlatformCheck is the result of checking all requirements on a platform package
+type PlatformCheck struct {
+	Name string
+	// Version of the platform package, empty when it is missing
+	Version string
+	// Provider is the installed package replacing or providing the platform package, e.g. a polyfill
+	Provider string
+	Status   string
+	// Requirements are all packages requiring the platform package
+	Requirements []PlatformRequirement
+	// Failures are the requirements the version does not satisfy
+	Failures []PlatformRequirement
+}
+
+// PlatformReport lists the checks of every platform requirement sorted by name
+type PlatformReport []PlatformCheck
+
+// OK reports whether every platform requirement is satisfied
+func (r PlatformReport) OK() bool {
+	for _, c := range r {
+		if c.Status != PlatformSuccess {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckPlatformReqs check the platform requirements of the root manifest, the equivalent of
+// composer check-platform-reqs without a lock file. Config.Platform overrides are applied
+func (m *Manifest) CheckPlatformReqs(platform Platform, noDev bool) PlatformReport {
+	c := newPlatformChecker(platform.WithOverrides(m.Config.Platform))
+	name := m.Name
+	if name == "" {
+		name = RootPackageName
+	}
+	c.add(name, m.Require)
+	if !noDev {
+		c.add(name, m.RequireDev)
+	}
+	return c.report()
+}
+
+// CheckPlatformReqs check the platform requirements of the root package and of every locked package,
+// the equivalent of composer check-platform-reqs. The platform-overrides of the lock are applied
+// and packages replacing or providing a platform package satisfy its requirements
+func (l *Lock) CheckPlatformReqs(platform Platform, noDev bool) PlatformReport {
+	c := newPlatformChecker(platform.WithOverrides(l.PlatformOverrides))
+	c.add(RootPackageName, l.Platform)
+	if !noDev {
+		c.add(RootPackageName, l.PlatformDev)
+	}
+
+	packages := l.Packages
+	if !noDev {
+		packages = append(append([]Package{}, l.Packages...), l.PackagesDev...)
+	}
+	for i := range packages {
+		p := &packages[i]
+		c.add(strings.ToLower(p.Name), p.Require)
+		for _, m := range []map[string]string{p.Replace, p.Provide} {
+			for name := range m {
+				if _, ok := c.providers[NormalizePlatformName(name)]; !ok {
+					c.providers[NormalizePlatformName(name)] = strings.ToLower(p.Name)
+				}
+			}
+		}
+	}
+	return c.report()
+}
+
+type platformChecker struct {
+	versions     map[string]string
+	providers    map[string]string
+	requirements map[string][]PlatformRequirement
+}
+
+func newPlatformChecker(versions map[string]string) *platformChecker {
+	return &platformChecker{versions: versions, providers: map[string]string{}, requirements: map[string][]PlatformRequirement{}}
+}
+
+// add the php, ext-* and lib-* requirements of a package
+func (c *platformChecker) add(source string, requires map[string]string) {
+	for _, name := range sortedKeys(requires) {
+		n := NormalizePlatformName(name)
+		switch PlatformPackageKind(n) {
+		case PlatformPHP, PlatformExtension, PlatformLibrary:
+		default:
+			continue
+		}
+		c.requirements[n] = append(c.requirements[n], PlatformRequirement{Source: source, Constraint: requires[name]})
+	}
+}
+
+func (c *platformChecker) report() PlatformReport {
+	names := make([]string, 0, len(c.requirements))
+	for name := range c.requirements {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	report := make(PlatformReport, 0, len(names))
+	for _, name := range names {
+		check := PlatformCheck{Name: name, Requirements: c.requirements[name]}
+		version, ok := c.versions[name]
+		switch {
+		case ok:
+			check.Version = version
+			normalized, err := NormalizeVersion(version)
+			for _, r := range check.Requirements {
+				constraint, parseErr := ParseConstraints(r.Constraint)
+				if err != nil || parseErr != nil || !constraint.Matches(normalized) {
+					check.Failures = append(check.Failures, r)
+				}
+			}
+			check.Status = PlatformSuccess
+			if len(check.Failures) > 0 {
+				check.Status = PlatformFailed
+			}
+		case c.providers[name] != "":
+			check.Provider = c.providers[name]
+			check.Status = PlatformSuccess
+		default:
+			check.Failures = check.Requirements
+			check.Status = PlatformMissing
+		}
+		report = append(report, check)
+	}
+	return report
+}
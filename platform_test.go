@@ -0,0 +1,161 @@
+package composer
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestPlatform_Packages(t *testing.T) {
+	p := Platform{
+		PHP:        "8.1.2-1ubuntu2.14",
+		PHP64Bit:   true,
+		Extensions: map[string]string{"json": "8.1.2", "Zend OPcache": "8.1.2"},
+		Libraries:  map[string]string{"openssl": "3.0.2"},
+	}
+	want := map[string]string{
+		"php":              "8.1.2",
+		"php-64bit":        "8.1.2",
+		"ext-json":         "8.1.2",
+		"ext-zend-opcache": "8.1.2",
+		"lib-openssl":      "3.0.2",
+	}
+	if got := p.Packages(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Packages() got = %v, want %v", got, want)
+	}
+}
+
+func TestManifest_CheckPlatformReqs(t *testing.T) {
+	platform := Platform{PHP: "7.4.3", PHP64Bit: true, Extensions: map[string]string{"json": "7.4.3", "intl": "7.4.3"}}
+	tests := []struct {
+		name     string
+		manifest Manifest
+		noDev    bool
+		want     map[string]string
+		ok       bool
+	}{
+		{"satisfied", Manifest{Require: map[string]string{"php": "^7.4", "php-64bit": "*", "ext-json": "*", "monolog/monolog": "^2.0"}}, false,
+			map[string]string{"php": PlatformSuccess, "php-64bit": PlatformSuccess, "ext-json": PlatformSuccess}, true},
+		{"php too old", Manifest{Require: map[string]string{"php": "^8.0"}}, false,
+			map[string]string{"php": PlatformFailed}, false},
+		{"missing extension", Manifest{Require: map[string]string{"ext-mbstring": "*"}}, false,
+			map[string]string{"ext-mbstring": PlatformMissing}, false},
+		{"dev requirement", Manifest{RequireDev: map[string]string{"ext-xdebug": "*"}}, false,
+			map[string]string{"ext-xdebug": PlatformMissing}, false},
+		{"no-dev", Manifest{RequireDev: map[string]string{"ext-xdebug": "*"}}, true,
+			map[string]string{}, true},
+		{"extension alias", Manifest{Require: map[string]string{"ext-Zend-OPcache": "*"}}, false,
+			map[string]string{"ext-zend-opcache": PlatformMissing}, false},
+		{"config.platform override", Manifest{Require: map[string]string{"php": "^8.0"}, Config: Config{Platform: map[string]string{"php": "8.0.0"}}}, false,
+			map[string]string{"php": PlatformSuccess}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := tt.manifest.CheckPlatformReqs(platform, tt.noDev)
+			got := map[string]string{}
+			for _, c := range report {
+				got[c.Name] = c.Status
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckPlatformReqs() got = %v, want %v", got, tt.want)
+			}
+			if report.OK() != tt.ok {
+				t.Errorf("OK() got = %v, want %v", report.OK(), tt.ok)
+			}
+		})
+	}
+}
+
+func TestLock_CheckPlatformReqs(t *testing.T) {
+	var lock Lock
+	if err := json.Unmarshal([]byte(testLock), &lock); err != nil {
+		t.Fatalf("UnmarshalJSON() error = %v", err)
+	}
+	lock.Packages = append(lock.Packages, *testPackage("symfony/polyfill-mbstring", "1.22.0", map[string]string{"provide:ext-mbstring": "*", "require:php": ">=7.1"}))
+	lock.Packages = append(lock.Packages, *testPackage("acme/strings", "1.0.0", map[string]string{"require:ext-mbstring": "*"}))
+
+	report := lock.CheckPlatformReqs(Platform{PHP: "7.2.0"}, false)
+	if len(report) != 2 || report[0].Status != PlatformSuccess || report[0].Provider != "symfony/polyfill-mbstring" {
+		t.Fatalf("CheckPlatformReqs() got = %+v", report)
+	}
+	wantFailures := []PlatformRequirement{{RootPackageName, "^7.3 || ^8.0"}, {"phpunit/phpunit", ">=7.3"}}
+	if report[1].Status != PlatformFailed || !reflect.DeepEqual(report[1].Failures, wantFailures) {
+		t.Errorf("CheckPlatformReqs() php = %+v, want failures %v", report[1], wantFailures)
+	}
+
+	noDev := lock.CheckPlatformReqs(Platform{PHP: "7.2.0"}, true)
+	if len(noDev[1].Failures) != 1 {
+		t.Errorf("CheckPlatformReqs() without dev got failures %v", noDev[1].Failures)
+	}
+}
+
+func TestPlatformPackageKind(t *testing.T) {
+	tests := []struct {
+		name string
+		want PlatformKind
+	}{
+		{"php", PlatformPHP},
+		{"php-64bit", PlatformPHP},
+		{"PHP-ZTS", PlatformPHP},
+		{"ext-json", PlatformExtension},
+		{"ext-zend-opcache", PlatformExtension},
+		{"lib-openssl", PlatformLibrary},
+		{"composer", PlatformComposer},
+		{"composer-plugin-api", PlatformComposer},
+		{"composer-runtime-api", PlatformComposer},
+		{"hhvm", PlatformHHVM},
+		{"monolog/monolog", PlatformNone},
+		{"phpunit/phpunit", PlatformNone},
+		{"php-foo", PlatformNone},
+		{"ext-", PlatformNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PlatformPackageKind(tt.name); got != tt.want {
+				t.Errorf("PlatformPackageKind() got = %q, want %q", got, tt.want)
+			}
+			if got := IsPlatformPackage(tt.name); got != (tt.want != PlatformNone) {
+				t.Errorf("IsPlatformPackage() got = %v", got)
+			}
+		})
+	}
+}
+
+func TestNormalizePlatformName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"ext-JSON", "ext-json"},
+		{"ext-Zend OPcache", "ext-zend-opcache"},
+		{"ext-opcache", "ext-zend-opcache"},
+		{"EXT-Zend-OPcache", "ext-zend-opcache"},
+		{"PHP", "php"},
+		{"lib-ICU", "lib-icu"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizePlatformName(tt.name); got != tt.want {
+				t.Errorf("NormalizePlatformName() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if got := NormalizeExtensionName("Zend OPcache"); got != "zend-opcache" {
+		t.Errorf("NormalizeExtensionName() got = %v", got)
+	}
+}
+
+func TestSplitRequirements(t *testing.T) {
+	platform, packages := SplitRequirements(map[string]string{
+		"php":                 "^8.0",
+		"ext-json":            "*",
+		"composer-plugin-api": "^2.0",
+		"monolog/monolog":     "^2.0",
+	})
+	if want := map[string]string{"php": "^8.0", "ext-json": "*", "composer-plugin-api": "^2.0"}; !reflect.DeepEqual(platform, want) {
+		t.Errorf("SplitRequirements() platform = %v, want %v", platform, want)
+	}
+	if want := map[string]string{"monolog/monolog": "^2.0"}; !reflect.DeepEqual(packages, want) {
+		t.Errorf("SplitRequirements() packages = %v, want %v", packages, want)
+	}
+}
@@ -0,0 +1,128 @@
+package composer
+
+import "strings"
+
+// policy orders the candidates of a requirement, it implements prefer-lowest and prefer-stable
+type policy struct {
+	preferLowest bool
+	preferStable bool
+}
+
+// prefer pick the candidate to try first. Packages named after the requirement win over replacers
+// and providers, then the most stable one wins with prefer-stable, then the highest or lowest version
+func (p *policy) prefer(candidates []*poolPackage, target string) *poolPackage {
+	best := candidates[0]
+	for _, c := range candidates[1:] {
+		if p.compare(c, best, target) < 0 {
+			best = c
+		}
+	}
+	return best
+}
+
+// compare return a negative number when a is preferred over b
+func (p *policy) compare(a, b *poolPackage, target string) int {
+	if (a.name == target) != (b.name == target) {
+		if a.name == target {
+			return -1
+		}
+		return 1
+	}
+	if p.preferStable {
+		sa, sb := Stabilities[VersionStability(a.version)], Stabilities[VersionStability(b.version)]
+		if sa != sb {
+			return sa - sb
+		}
+	}
+	cmp := CompareVersions(a.version, b.version)
+	if !p.preferLowest {
+		cmp = -cmp
+	}
+	if cmp == 0 {
+		return a.id - b.id
+	}
+	return cmp
+}
+
+// fixedPackages return the locked packages which must keep their version, by name.
+// Without Locked nothing is fixed, which is a full update
+func (s *Solver) fixedPackages(root *poolPackage) map[string]*Package {
+	fixed := map[string]*Package{}
+	if len(s.Locked) == 0 {
+		return fixed
+	}
+	for name, p := range s.lockedByName() {
+		fixed[name] = p
+	}
+	for name := range s.updateAllowList(root) {
+		delete(fixed, name)
+	}
+	return fixed
+}
+
+func (s *Solver) lockedByName() map[string]*Package {
+	locked := make(map[string]*Package, len(s.Locked))
+	for _, p := range s.Locked {
+		locked[strings.ToLower(p.Name)] = p
+	}
+	return locked
+}
+
+// updateAllowList expand Update into the names of the locked packages allowed to change
+func (s *Solver) updateAllowList(root *poolPackage) map[string]bool {
+	allowed := map[string]bool{}
+	if len(s.Update) == 0 {
+		return allowed
+	}
+
+	locked := s.lockedByName()
+	pattern := packageNamesToRegexp(s.Update)
+	var queue []string
+	for name := range locked {
+		if pattern.MatchString(name) {
+			allowed[name] = true
+			queue = append(queue, name)
+		}
+	}
+	if !s.UpdateWithDependencies && !s.UpdateWithAllDependencies {
+		return allowed
+	}
+
+	rootRequires := map[string]bool{}
+	for _, l := range root.requires(true) {
+		rootRequires[l.target] = true
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for dependency := range locked[name].Require {
+			dependency = strings.ToLower(dependency)
+			for _, candidate := range lockedProviders(locked, dependency) {
+				if allowed[candidate] || rootRequires[candidate] && !s.UpdateWithAllDependencies {
+					continue
+				}
+				allowed[candidate] = true
+				queue = append(queue, candidate)
+			}
+		}
+	}
+	return allowed
+}
+
+// lockedProviders return the names of the locked packages named after, replacing or providing a name
+func lockedProviders(locked map[string]*Package, name string) []string {
+	var names []string
+	for n, p := range locked {
+		if n == name {
+			names = append(names, n)
+			continue
+		}
+		for _, m := range []map[string]string{p.Replace, p.Provide} {
+			if _, ok := m[name]; ok {
+				names = append(names, n)
+				break
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,121 @@
+package composer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSolver_Policies(t *testing.T) {
+	repo := NewArrayRepository(
+		testPackage("a/a", "1.0.0", map[string]string{"require:c/c": "^1.0"}),
+		testPackage("a/a", "1.1.0", map[string]string{"require:c/c": "^1.1"}),
+		testPackage("a/a", "1.2.0-beta1", map[string]string{"require:c/c": "^1.1"}),
+		testPackage("b/b", "1.0.0", map[string]string{"require:c/c": "^1.0"}),
+		testPackage("b/b", "1.1.0", map[string]string{"require:c/c": "^1.0"}),
+		testPackage("c/c", "1.0.0", nil),
+		testPackage("c/c", "1.1.0", nil),
+		testPackage("c/c", "1.2.0", nil),
+	)
+
+	tests := []struct {
+		name   string
+		solver Solver
+		root   Manifest
+		want   []string
+	}{
+		{"prefer highest", Solver{}, Manifest{Require: map[string]string{"a/a": "^1.0"}},
+			[]string{"a/a 1.1.0", "c/c 1.2.0"}},
+		{"prefer lowest", Solver{PreferLowest: true}, Manifest{Require: map[string]string{"a/a": "^1.0", "b/b": "^1.0"}},
+			[]string{"a/a 1.0.0", "b/b 1.0.0", "c/c 1.0.0"}},
+		{"unstable allowed", Solver{}, Manifest{Require: map[string]string{"a/a": "^1.0"}, MinimumStability: "beta"},
+			[]string{"a/a 1.2.0-beta1", "c/c 1.2.0"}},
+		{"prefer stable", Solver{PreferStable: true}, Manifest{Require: map[string]string{"a/a": "^1.0"}, MinimumStability: "beta"},
+			[]string{"a/a 1.1.0", "c/c 1.2.0"}},
+		{"prefer stable from manifest", Solver{}, Manifest{Require: map[string]string{"a/a": "^1.0"}, MinimumStability: "beta", PreferStable: true},
+			[]string{"a/a 1.1.0", "c/c 1.2.0"}},
+		{"prefer lowest stable", Solver{PreferLowest: true, PreferStable: true}, Manifest{Require: map[string]string{"a/a": "^1.1"}, MinimumStability: "beta"},
+			[]string{"a/a 1.1.0", "c/c 1.1.0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.solver
+			s.Repositories = []PackageRepository{repo}
+			got, err := s.Solve(&tt.root)
+			if err != nil {
+				t.Fatalf("Solve() error = %v", err)
+			}
+			if got := resolvedVersions(got.Packages); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Solve() packages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSolver_PartialUpdate(t *testing.T) {
+	repo := NewArrayRepository(
+		testPackage("a/a", "1.0.0", map[string]string{"require:c/c": "^1.0"}),
+		testPackage("a/a", "1.1.0", map[string]string{"require:c/c": "^1.0"}),
+		testPackage("b/b", "1.0.0", map[string]string{"require:c/c": "^1.0"}),
+		testPackage("b/b", "1.1.0", map[string]string{"require:c/c": "^1.0"}),
+		testPackage("c/c", "1.0.0", nil),
+		testPackage("c/c", "1.1.0", nil),
+	)
+	locked := []*Package{
+		testPackage("a/a", "1.0.0", map[string]string{"require:c/c": "^1.0"}),
+		testPackage("b/b", "1.0.0", map[string]string{"require:c/c": "^1.0"}),
+		testPackage("c/c", "1.0.0", nil),
+	}
+	root := Manifest{Require: map[string]string{"a/a": "^1.0", "b/b": "^1.0"}}
+	rootWithC := Manifest{Require: map[string]string{"a/a": "^1.0", "b/b": "^1.0", "c/c": "^1.0"}}
+
+	tests := []struct {
+		name   string
+		solver Solver
+		root   Manifest
+		want   []string
+	}{
+		{"install from lock", Solver{Locked: locked}, root,
+			[]string{"a/a 1.0.0", "b/b 1.0.0", "c/c 1.0.0"}},
+		{"full update", Solver{}, root,
+			[]string{"a/a 1.1.0", "b/b 1.1.0", "c/c 1.1.0"}},
+		{"update one package", Solver{Locked: locked, Update: []string{"a/a"}}, root,
+			[]string{"a/a 1.1.0", "b/b 1.0.0", "c/c 1.0.0"}},
+		{"update with wildcard", Solver{Locked: locked, Update: []string{"*/b"}}, root,
+			[]string{"a/a 1.0.0", "b/b 1.1.0", "c/c 1.0.0"}},
+		{"with dependencies", Solver{Locked: locked, Update: []string{"a/a"}, UpdateWithDependencies: true}, root,
+			[]string{"a/a 1.1.0", "b/b 1.0.0", "c/c 1.1.0"}},
+		{"with dependencies keeps root requirements", Solver{Locked: locked, Update: []string{"a/a"}, UpdateWithDependencies: true}, rootWithC,
+			[]string{"a/a 1.1.0", "b/b 1.0.0", "c/c 1.0.0"}},
+		{"with all dependencies", Solver{Locked: locked, Update: []string{"a/a"}, UpdateWithAllDependencies: true}, rootWithC,
+			[]string{"a/a 1.1.0", "b/b 1.0.0", "c/c 1.1.0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.solver
+			s.Repositories = []PackageRepository{repo}
+			got, err := s.Solve(&tt.root)
+			if err != nil {
+				t.Fatalf("Solve() error = %v", err)
+			}
+			if got := resolvedVersions(got.Packages); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Solve() packages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSolver_LockedMismatch(t *testing.T) {
+	repo := NewArrayRepository(testPackage("a/a", "1.0.0", nil), testPackage("a/a", "2.0.0", nil))
+	s := &Solver{Repositories: []PackageRepository{repo}, Locked: []*Package{testPackage("a/a", "1.0.0", nil)}}
+	_, err := s.Solve(&Manifest{Require: map[string]string{"a/a": "^2.0"}})
+
+	var problem *SolverProblem
+	if !errors.As(err, &problem) {
+		t.Fatalf("Solve() error = %v, want a problem", err)
+	}
+	want := []string{"Root composer.json requires a/a ^2.0 -> a/a is locked to version 1.0.0 and an update of this package was not requested."}
+	if !reflect.DeepEqual(problem.Reasons, want) {
+		t.Errorf("Solve() problem = %q, want %q", problem.Reasons, want)
+	}
+}
@@ -0,0 +1,249 @@
+package composer
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// PackageRepository provides package versions to the Solver.
+// Implementations can be in-memory, e.g. ArrayRepository, or remote, e.g. RepositoryClient
+type PackageRepository interface {
+	// FindPackages return every version of a package, or nothing when the repository does not know it
+	FindPackages(name string) ([]*Package, error)
+}
+
+// ArrayRepository is an in-memory PackageRepository
+type ArrayRepository struct {
+	packages map[string][]*Package
+	names    []string
+}
+
+// NewArrayRepository create a repository holding the given package versions
+func NewArrayRepository(packages ...*Package) *ArrayRepository {
+	r := &ArrayRepository{packages: map[string][]*Package{}}
+	r.Add(packages...)
+	return r
+}
+
+// Add more package versions to the repository
+func (r *ArrayRepository) Add(packages ...*Package) {
+	for _, p := range packages {
+		name := strings.ToLower(p.Name)
+		if _, ok := r.packages[name]; !ok {
+			r.names = append(r.names, name)
+		}
+		r.packages[name] = append(r.packages[name], p)
+	}
+}
+
+// FindPackages return every version of a package
+func (r *ArrayRepository) FindPackages(name string) ([]*Package, error) {
+	return r.packages[strings.ToLower(name)], nil
+}
+
+// Packages return all versions of all packages in the order they were added
+func (r *ArrayRepository) Packages() []*Package {
+	var all []*Package
+	for _, name := range r.names {
+		all = append(all, r.packages[name]...)
+	}
+	return all
+}
+
+// NewPlatformRepository create a repository of platform packages from their versions,
+// e.g. {"php": "8.1.2", "ext-json": "8.1.2", "lib-openssl": "3.0.2"}
+func NewPlatformRepository(versions map[string]string) *ArrayRepository {
+	names := make([]string, 0, len(versions))
+	for name := range versions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	r := NewArrayRepository()
+	for _, name := range names {
+		r.Add(&Package{Manifest: Manifest{Name: strings.ToLower(name), Version: versions[name], Type: "platform"}})
+	}
+	return r
+}
+
+// FindPackages adapt a composer repository to the Solver, a missing or filtered package is not an error
+func (c *RepositoryClient) FindPackages(name string) ([]*Package, error) {
+	versions, err := c.Versions(context.Background(), name)
+	if errors.Is(err, ErrPackageNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	packages := make([]*Package, len(versions))
+	for i := range versions {
+		packages[i] = &versions[i]
+	}
+	return packages, nil
+}
+
+// poolPackage is a package version loaded into the pool with its variable id for the solver
+type poolPackage struct {
+	*Package
+	id       int
+	name     string
+	version  string
+	alias    string
+	platform bool
+	root     bool
+}
+
+// String render the package the way Composer prints it in problems, e.g. "monolog/monolog 2.1.0"
+func (p *poolPackage) String() string {
+	if p.root {
+		return "Root composer.json"
+	}
+	return p.name + " " + p.Version
+}
+
+// matches reports whether the package version, or its branch alias, satisfies a constraint
+func (p *poolPackage) matches(c Constraint) bool {
+	return c.Matches(p.version) || p.alias != "" && c.Matches(p.alias)
+}
+
+// link is a parsed require, conflict, replace or provide entry
+type link struct {
+	target     string
+	pretty     string
+	constraint Constraint
+}
+
+// links parse a map of links of a package, "self.version" refers to the version of the package itself
+func (p *poolPackage) links(m map[string]string) []link {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	links := make([]link, 0, len(m))
+	for _, name := range names {
+		pretty := m[name]
+		var c Constraint
+		if strings.TrimSpace(pretty) == "self.version" {
+			c = &versionConstraint{"=", p.version}
+		} else if parsed, err := ParseConstraints(pretty); err == nil {
+			c = parsed
+		} else {
+			continue
+		}
+		links = append(links, link{target: strings.ToLower(name), pretty: pretty, constraint: c})
+	}
+	return links
+}
+
+// requires of the package, root packages include require-dev unless dev is false
+func (p *poolPackage) requires(dev bool) []link {
+	links := p.links(p.Require)
+	if p.root && dev {
+		links = append(links, p.links(p.RequireDev)...)
+	}
+	return links
+}
+
+// pool holds every package version the solver can choose from
+type pool struct {
+	packages []*poolPackage
+	byName   map[string][]*poolPackage
+	provided map[string][]*poolPackage
+	filtered map[string][]*poolPackage
+}
+
+func newPool() *pool {
+	return &pool{
+		byName:   map[string][]*poolPackage{},
+		provided: map[string][]*poolPackage{},
+		filtered: map[string][]*poolPackage{},
+	}
+}
+
+// newPoolPackage normalize the version of a package, packages with invalid versions are skipped
+func newPoolPackage(p *Package) (*poolPackage, bool) {
+	version := p.VersionNormalized
+	if version == "" {
+		normalized, err := NormalizeVersion(p.Version)
+		if err != nil {
+			return nil, false
+		}
+		version = normalized
+	}
+
+	pp := &poolPackage{Package: p, name: strings.ToLower(p.Name), version: version}
+	pp.platform = IsPlatformPackage(pp.name)
+	if strings.HasPrefix(version, "dev-") {
+		if aliases, ok := p.Extra["branch-alias"].(map[string]interface{}); ok {
+			if target, ok := aliases[p.Version].(string); ok {
+				if normalized, err := NormalizeVersion(target); err == nil && strings.HasSuffix(normalized, "-dev") {
+					pp.alias = normalized
+				}
+			}
+		}
+		if pp.alias == "" && bool(p.DefaultBranch) {
+			pp.alias = "9999999-dev"
+		}
+	}
+	return pp, true
+}
+
+// add a package to the pool and index it by its own, replaced and provided names
+func (pl *pool) add(p *poolPackage) {
+	p.id = len(pl.packages) + 1
+	pl.packages = append(pl.packages, p)
+	pl.byName[p.name] = append(pl.byName[p.name], p)
+	for _, name := range sortedKeys(p.Replace) {
+		pl.provided[strings.ToLower(name)] = append(pl.provided[strings.ToLower(name)], p)
+	}
+	for _, name := range sortedKeys(p.Provide) {
+		pl.provided[strings.ToLower(name)] = append(pl.provided[strings.ToLower(name)], p)
+	}
+}
+
+func (pl *pool) get(id int) *poolPackage {
+	return pl.packages[id-1]
+}
+
+// whatProvides return the packages named after a link target whose version matches,
+// followed by the packages replacing or providing it with an intersecting constraint
+func (pl *pool) whatProvides(l link) []*poolPackage {
+	var result []*poolPackage
+	for _, p := range pl.byName[l.target] {
+		if p.matches(l.constraint) {
+			result = append(result, p)
+		}
+	}
+	for _, p := range pl.provided[l.target] {
+		if p.name == l.target {
+			continue
+		}
+		for _, links := range [][]link{p.links(p.Replace), p.links(p.Provide)} {
+			matched := false
+			for _, candidate := range links {
+				if candidate.target == l.target && Intersects(candidate.constraint, l.constraint) {
+					matched = true
+					break
+				}
+			}
+			if matched {
+				result = append(result, p)
+				break
+			}
+		}
+	}
+	return result
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,175 @@
+package composer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// AddRequirement require a package in require, or in require-dev when dev is set, the way composer require does.
+// A package required in the other section is moved, the name of an existing requirement keeps its case.
+// A package the manifest conflicts with in that version range is refused.
+// Maps have no order, see AddRequirementJSON to respect config.sort-packages in a document
+func (m *Manifest) AddRequirement(name, constraint string, dev bool) error {
+	if err := m.checkRequirement(name, constraint); err != nil {
+		return err
+	}
+	from, to := &m.RequireDev, &m.Require
+	if dev {
+		from, to = to, from
+	}
+	if key, ok := findLink(*from, name); ok {
+		delete(*from, key)
+	}
+	if key, ok := findLink(*to, name); ok {
+		name = key
+	}
+	if *to == nil {
+		*to = map[string]string{}
+	}
+	(*to)[name] = constraint
+	return nil
+}
+
+// RemoveRequirement remove a package from require and require-dev, it is an error when it is not required
+func (m *Manifest) RemoveRequirement(name string) error {
+	removed := false
+	for _, links := range []map[string]string{m.Require, m.RequireDev} {
+		if key, ok := findLink(links, name); ok {
+			delete(links, key)
+			removed = true
+		}
+	}
+	if !removed {
+		return errors.New(fmt.Sprintf("%s is not required", name))
+	}
+	return nil
+}
+
+// checkRequirement validate a new requirement against the conflicts of the manifest
+func (m *Manifest) checkRequirement(name, constraint string) error {
+	if name == "" {
+		return errors.New("cannot require a package without a name")
+	}
+	required, err := ParseConstraints(constraint)
+	if err != nil {
+		return err
+	}
+	key, ok := findLink(m.Conflict, name)
+	if !ok {
+		return nil
+	}
+	conflict, err := ParseConstraints(m.Conflict[key])
+	if err != nil {
+		return err
+	}
+	if Intersects(required, conflict) {
+		return errors.New(fmt.Sprintf("cannot require %s %s, it conflicts with %s", name, constraint, m.Conflict[key]))
+	}
+	return nil
+}
+
+// findLink return the key of a package in a map of links, package names are case insensitive
+func findLink(links map[string]string, name string) (string, bool) {
+	if _, ok := links[name]; ok {
+		return name, true
+	}
+	for key := range links {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// AddRequirementJSON add a requirement to a composer.json document like AddRequirement, keeping its formatting.
+// With config.sort-packages the section is sorted with platform packages first, otherwise a new package
+// is appended. A section which becomes empty when a package moves is removed
+func AddRequirementJSON(data []byte, name, constraint string, dev bool) ([]byte, error) {
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	if err := m.AddRequirement(name, constraint, dev); err != nil {
+		return nil, err
+	}
+	return editLinks(data, func(root *jsonObject) {
+		from, to := "require-dev", "require"
+		if dev {
+			from, to = to, from
+		}
+		removeLinkNode(root, from, name)
+
+		links, ok := root.values[to].(*jsonObject)
+		if !ok {
+			links = newJSONObject()
+		}
+		key := name
+		for _, k := range links.keys {
+			if strings.EqualFold(k, name) {
+				key = k
+				break
+			}
+		}
+		links.set(key, marshalRaw(constraint))
+		if m.Config.SortPackages {
+			links = sortPackageLinks(links)
+		}
+		root.set(to, links)
+	})
+}
+
+// RemoveRequirementJSON remove a requirement from a composer.json document like RemoveRequirement,
+// keeping its formatting. A section which becomes empty is removed
+func RemoveRequirementJSON(data []byte, name string) ([]byte, error) {
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	if err := m.RemoveRequirement(name); err != nil {
+		return nil, err
+	}
+	return editLinks(data, func(root *jsonObject) {
+		removeLinkNode(root, "require", name)
+		removeLinkNode(root, "require-dev", name)
+	})
+}
+
+// editLinks apply an edit to a document and encode it back with its indentation and final new line
+func editLinks(data []byte, edit func(root *jsonObject)) ([]byte, error) {
+	doc, err := decodeOrderedText(data)
+	if err != nil {
+		return nil, err
+	}
+	root, ok := doc.(*jsonObject)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("cannot unmarshal %s", data))
+	}
+	edit(root)
+	e := &phpEncoder{pretty: true, indent: detectIndent(data), unescapedSlashes: true, unescapedUnicode: true, raw: true}
+	result := e.encode(root)
+	if strings.HasSuffix(string(data), "\n") {
+		result = append(result, '\n')
+	}
+	return result, nil
+}
+
+// removeLinkNode remove a package from a section of a document, the section is removed when it becomes empty
+func removeLinkNode(root *jsonObject, section, name string) {
+	links, ok := root.values[section].(*jsonObject)
+	if !ok {
+		return
+	}
+	for _, k := range links.keys {
+		if strings.EqualFold(k, name) {
+			links.delete(k)
+			if len(links.keys) == 0 {
+				root.delete(section)
+			} else {
+				root.set(section, links)
+			}
+			return
+		}
+	}
+}
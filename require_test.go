@@ -0,0 +1,196 @@
+package composer
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestManifest_AddRequirement(t *testing.T) {
+	tests := []struct {
+		name           string
+		manifest       string
+		pkg            string
+		constraint     string
+		dev            bool
+		wantRequire    map[string]string
+		wantRequireDev map[string]string
+		wantErr        bool
+	}{
+		{
+			name:        "new package",
+			manifest:    `{"require": {"php": "^8.1"}}`,
+			pkg:         "monolog/monolog",
+			constraint:  "^3.0",
+			wantRequire: map[string]string{"php": "^8.1", "monolog/monolog": "^3.0"},
+		},
+		{
+			name:           "empty manifest",
+			manifest:       `{}`,
+			pkg:            "phpunit/phpunit",
+			constraint:     "^10.0",
+			dev:            true,
+			wantRequireDev: map[string]string{"phpunit/phpunit": "^10.0"},
+		},
+		{
+			name:        "update keeps the case",
+			manifest:    `{"require": {"Monolog/Monolog": "^2.0"}}`,
+			pkg:         "monolog/monolog",
+			constraint:  "^3.0",
+			wantRequire: map[string]string{"Monolog/Monolog": "^3.0"},
+		},
+		{
+			name:           "move to require-dev",
+			manifest:       `{"require": {"php": "^8.1", "phpunit/phpunit": "^9.0"}}`,
+			pkg:            "phpunit/phpunit",
+			constraint:     "^10.0",
+			dev:            true,
+			wantRequire:    map[string]string{"php": "^8.1"},
+			wantRequireDev: map[string]string{"phpunit/phpunit": "^10.0"},
+		},
+		{
+			name:           "move to require",
+			manifest:       `{"require-dev": {"psr/log": "^1.0"}}`,
+			pkg:            "psr/log",
+			constraint:     "^1.0",
+			wantRequire:    map[string]string{"psr/log": "^1.0"},
+			wantRequireDev: map[string]string{},
+		},
+		{
+			name:       "conflict",
+			manifest:   `{"conflict": {"symfony/console": "<5.4"}}`,
+			pkg:        "symfony/console",
+			constraint: "^5.0",
+			wantErr:    true,
+		},
+		{
+			name:        "conflict outside the range",
+			manifest:    `{"conflict": {"symfony/console": "<5.4"}}`,
+			pkg:         "symfony/console",
+			constraint:  "^6.0",
+			wantRequire: map[string]string{"symfony/console": "^6.0"},
+		},
+		{
+			name:       "invalid constraint",
+			manifest:   `{}`,
+			pkg:        "psr/log",
+			constraint: "foo",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m Manifest
+			if err := json.Unmarshal([]byte(tt.manifest), &m); err != nil {
+				t.Fatal(err)
+			}
+			err := m.AddRequirement(tt.pkg, tt.constraint, tt.dev)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AddRequirement() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(m.Require, tt.wantRequire) {
+				t.Errorf("AddRequirement() require = %v, want %v", m.Require, tt.wantRequire)
+			}
+			if !reflect.DeepEqual(m.RequireDev, tt.wantRequireDev) {
+				t.Errorf("AddRequirement() require-dev = %v, want %v", m.RequireDev, tt.wantRequireDev)
+			}
+		})
+	}
+}
+
+func TestManifest_RemoveRequirement(t *testing.T) {
+	m := Manifest{
+		Require:    map[string]string{"php": "^8.1", "Psr/Log": "^1.0"},
+		RequireDev: map[string]string{"psr/log": "^1.0"},
+	}
+	if err := m.RemoveRequirement("psr/log"); err != nil {
+		t.Fatalf("RemoveRequirement() error = %v", err)
+	}
+	if want := map[string]string{"php": "^8.1"}; !reflect.DeepEqual(m.Require, want) {
+		t.Errorf("RemoveRequirement() require = %v, want %v", m.Require, want)
+	}
+	if len(m.RequireDev) != 0 {
+		t.Errorf("RemoveRequirement() require-dev = %v, want empty", m.RequireDev)
+	}
+	if err := m.RemoveRequirement("psr/log"); err == nil {
+		t.Error("RemoveRequirement() of a package not required, want an error")
+	}
+}
+
+func TestAddRequirementJSON(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		pkg        string
+		constraint string
+		dev        bool
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "append",
+			data:       "{\n  \"require\": {\n    \"zeta/zeta\": \"^1.0\",\n    \"php\": \"^8.1\"\n  }\n}\n",
+			pkg:        "alpha/alpha",
+			constraint: "^2.0",
+			want:       "{\n  \"require\": {\n    \"zeta/zeta\": \"^1.0\",\n    \"php\": \"^8.1\",\n    \"alpha/alpha\": \"^2.0\"\n  }\n}\n",
+		},
+		{
+			name:       "sort-packages",
+			data:       `{"require": {"php": "^8.1", "vendor/package10": "^1.0"}, "config": {"sort-packages": true}}`,
+			pkg:        "vendor/package9",
+			constraint: "^1.0",
+			want:       "{\n    \"require\": {\n        \"php\": \"^8.1\",\n        \"vendor/package9\": \"^1.0\",\n        \"vendor/package10\": \"^1.0\"\n    },\n    \"config\": {\"sort-packages\": true}\n}",
+		},
+		{
+			name:       "sort-packages platform first",
+			data:       "{\n    \"require-dev\": {\n        \"phpunit/phpunit\": \"^10.0\"\n    },\n    \"config\": {\n        \"sort-packages\": true\n    }\n}\n",
+			pkg:        "ext-json",
+			constraint: "*",
+			dev:        true,
+			want:       "{\n    \"require-dev\": {\n        \"ext-json\": \"*\",\n        \"phpunit/phpunit\": \"^10.0\"\n    },\n    \"config\": {\n        \"sort-packages\": true\n    }\n}\n",
+		},
+		{
+			name:       "move removes the empty section",
+			data:       "{\n    \"name\": \"acme/app\",\n    \"require\": {\n        \"php\": \"^8.1\"\n    },\n    \"require-dev\": {\n        \"psr/log\": \"^1.0\"\n    }\n}\n",
+			pkg:        "psr/log",
+			constraint: "^3.0",
+			want:       "{\n    \"name\": \"acme/app\",\n    \"require\": {\n        \"php\": \"^8.1\",\n        \"psr/log\": \"^3.0\"\n    }\n}\n",
+		},
+		{
+			name:       "conflict",
+			data:       `{"conflict": {"psr/log": "*"}}`,
+			pkg:        "psr/log",
+			constraint: "^3.0",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AddRequirementJSON([]byte(tt.data), tt.pkg, tt.constraint, tt.dev)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AddRequirementJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("AddRequirementJSON() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveRequirementJSON(t *testing.T) {
+	data := "{\n  \"require\": {\n    \"php\": \"^8.1\",\n    \"psr/log\": \"^1.0\"\n  },\n  \"require-dev\": {\"psr/log\": \"^1.0\"},\n  \"extra\": {\"a\": [1,2]}\n}"
+	want := "{\n  \"require\": {\n    \"php\": \"^8.1\"\n  },\n  \"extra\": {\"a\": [1,2]}\n}"
+	got, err := RemoveRequirementJSON([]byte(data), "psr/log")
+	if err != nil {
+		t.Fatalf("RemoveRequirementJSON() error = %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("RemoveRequirementJSON() = %q, want %q", got, want)
+	}
+	if _, err := RemoveRequirementJSON(got, "psr/log"); err == nil {
+		t.Error("RemoveRequirementJSON() of a package not required, want an error")
+	}
+}
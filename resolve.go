@@ -0,0 +1,179 @@
+package composer
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Rules of a ClassCandidate, in the order the Composer ClassLoader tries them
+const (
+	RuleClassmap     = "classmap"
+	RulePsr4         = "psr-4"
+	RulePsr4Fallback = "psr-4-fallback"
+	RulePsr0         = "psr-0"
+	RulePsr0Fallback = "psr-0-fallback"
+)
+
+// ClassCandidate is a file which may declare a class and the autoload rule which produced it
+type ClassCandidate struct {
+	// File is the slash separated absolute path of the candidate
+	File string `json:"file"`
+	Rule string `json:"rule"`
+	// Prefix is the namespace of a PSR rule, empty for classmap entries and fallback directories
+	Prefix string `json:"prefix,omitempty"`
+	// Dir is the directory of a PSR rule, or the classmap path the file was found in
+	Dir string `json:"dir"`
+	// Package is the name of the package of the rule, empty for the root package
+	Package string `json:"package,omitempty"`
+	Exists  bool   `json:"exists"`
+}
+
+// ClassResolver map class names to files like the Composer ClassLoader, without PHP
+type ClassResolver struct {
+	classMap     map[string]ClassCandidate
+	psr4         map[string][]ClassCandidate
+	psr4Fallback []ClassCandidate
+	// psr0Prefixes are in reverse order, like the prefixes of autoload_namespaces.php
+	psr0Prefixes []string
+	psr0         map[string][]ClassCandidate
+	psr0Fallback []ClassCandidate
+}
+
+// NewClassResolver scan the classmap rules of autoload sections in the order of the autoloader,
+// e.g. the result of AutoloadDumper.AutoloadSources
+func NewClassResolver(sources []AutoloadSource) (*ClassResolver, error) {
+	r := &ClassResolver{
+		classMap: map[string]ClassCandidate{},
+		psr4:     map[string][]ClassCandidate{},
+		psr0:     map[string][]ClassCandidate{},
+	}
+	classmapSources := make([]AutoloadSource, len(sources))
+	var classmapDirs []ClassCandidate
+	for i, s := range sources {
+		classmapSources[i] = AutoloadSource{Name: s.Name, Dir: s.Dir, Autoload: Autoload{
+			Classmap:            s.Autoload.Classmap,
+			ExcludeFromClassmap: s.Autoload.ExcludeFromClassmap,
+		}}
+		dir, err := absolutePath(s.Dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range s.Autoload.Classmap {
+			classmapDirs = append(classmapDirs, ClassCandidate{Rule: RuleClassmap, Dir: resolverPath(dir, p), Package: s.Name})
+		}
+		for _, typ := range []string{RulePsr4, RulePsr0} {
+			psr := s.Autoload.Psr4
+			if typ == RulePsr0 {
+				psr = s.Autoload.Psr0
+			}
+			namespaces := make([]string, 0, len(psr))
+			for namespace := range psr {
+				namespaces = append(namespaces, namespace)
+			}
+			sort.Strings(namespaces)
+			for _, namespace := range namespaces {
+				for _, p := range psr[namespace] {
+					r.add(ClassCandidate{Rule: typ, Prefix: namespace, Dir: resolverPath(dir, p), Package: s.Name})
+				}
+			}
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(r.psr0Prefixes)))
+
+	m, err := ClassMapGenerator{}.Generate(classmapSources)
+	if err != nil {
+		return nil, err
+	}
+	for class, file := range m.Classes {
+		candidate := ClassCandidate{Rule: RuleClassmap, File: file}
+		for _, c := range classmapDirs {
+			if file == c.Dir || strings.HasPrefix(file, c.Dir+"/") {
+				candidate.Dir, candidate.Package = c.Dir, c.Package
+				break
+			}
+		}
+		r.classMap[class] = candidate
+	}
+	return r, nil
+}
+
+// resolverPath is the normalized absolute path of an autoload path of a package installed in dir
+func resolverPath(dir, p string) string {
+	p = filepath.ToSlash(p)
+	if !strings.HasPrefix(p, "/") {
+		p = dir + "/" + p
+	}
+	return normalizePath(p)
+}
+
+func (r *ClassResolver) add(c ClassCandidate) {
+	switch {
+	case c.Rule == RulePsr4 && c.Prefix == "":
+		c.Rule = RulePsr4Fallback
+		r.psr4Fallback = append(r.psr4Fallback, c)
+	case c.Rule == RulePsr4:
+		r.psr4[c.Prefix] = append(r.psr4[c.Prefix], c)
+	case c.Prefix == "":
+		c.Rule = RulePsr0Fallback
+		r.psr0Fallback = append(r.psr0Fallback, c)
+	default:
+		if _, ok := r.psr0[c.Prefix]; !ok {
+			r.psr0Prefixes = append(r.psr0Prefixes, c.Prefix)
+		}
+		r.psr0[c.Prefix] = append(r.psr0[c.Prefix], c)
+	}
+}
+
+// Resolve return the files the autoloader tries for a fully qualified class name, in order: the classmap entry,
+// the PSR-4 prefixes from the longest, the PSR-4 fallback directories, the PSR-0 prefixes and the PSR-0
+// fallback directories. The autoloader includes the first candidate which exists
+func (r *ClassResolver) Resolve(class string) []ClassCandidate {
+	class = strings.TrimPrefix(class, `\`)
+	var candidates []ClassCandidate
+	if c, ok := r.classMap[class]; ok {
+		candidates = append(candidates, c)
+	}
+
+	logicalPsr4 := strings.Replace(class, `\`, "/", -1) + ".php"
+	for sub := class; ; {
+		pos := strings.LastIndex(sub, `\`)
+		if pos == -1 {
+			break
+		}
+		sub = sub[:pos]
+		for _, c := range r.psr4[sub+`\`] {
+			c.File = c.Dir + "/" + logicalPsr4[pos+1:]
+			candidates = append(candidates, c)
+		}
+	}
+	for _, c := range r.psr4Fallback {
+		c.File = c.Dir + "/" + logicalPsr4
+		candidates = append(candidates, c)
+	}
+
+	logicalPsr0 := strings.Replace(class, "_", "/", -1) + ".php"
+	if pos := strings.LastIndex(class, `\`); pos != -1 {
+		logicalPsr0 = logicalPsr4[:pos+1] + strings.Replace(logicalPsr4[pos+1:], "_", "/", -1)
+	}
+	for _, prefix := range r.psr0Prefixes {
+		if !strings.HasPrefix(class, prefix) {
+			continue
+		}
+		for _, c := range r.psr0[prefix] {
+			c.File = c.Dir + "/" + logicalPsr0
+			candidates = append(candidates, c)
+		}
+	}
+	for _, c := range r.psr0Fallback {
+		c.File = c.Dir + "/" + logicalPsr0
+		candidates = append(candidates, c)
+	}
+
+	for i := range candidates {
+		info, err := os.Stat(filepath.FromSlash(candidates[i].File))
+		candidates[i].Exists = err == nil && !info.IsDir()
+	}
+	return candidates
+}
@@ -0,0 +1,74 @@
+package composer
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestClassResolver_Resolve(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"src/Http/Client.php":             "<?php\nnamespace App\\Http;\nclass Client {}\n",
+		"legacy/Legacy/Old/Thing.php":     "<?php\nclass Legacy_Old_Thing {}\n",
+		"classes/Mapped.php":              "<?php\nnamespace App\\Http;\nclass Mapped {}\n",
+		"vendor/acme/http/src/Client.php": "<?php\nnamespace App\\Http;\nclass Client {}\n",
+	})
+	abs, err := absolutePath(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewClassResolver([]AutoloadSource{
+		{Dir: dir, Autoload: Autoload{
+			Psr4:     Psr{`App\`: {"src/"}, "": {"fallback"}},
+			Psr0:     Psr{"Legacy_": {"legacy"}, "Leg": {"old"}, "": {"lib/"}},
+			Classmap: []string{"classes/"},
+		}},
+		{Name: "acme/http", Dir: filepath.Join(dir, "vendor", "acme", "http"), Autoload: Autoload{
+			Psr4: Psr{`App\Http\`: {"src"}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("NewClassResolver() error = %v", err)
+	}
+
+	tests := []struct {
+		class string
+		want  []ClassCandidate
+	}{
+		{
+			class: `App\Http\Client`,
+			want: []ClassCandidate{
+				{File: abs + "/vendor/acme/http/src/Client.php", Rule: RulePsr4, Prefix: `App\Http\`, Dir: abs + "/vendor/acme/http/src", Package: "acme/http", Exists: true},
+				{File: abs + "/src/Http/Client.php", Rule: RulePsr4, Prefix: `App\`, Dir: abs + "/src", Exists: true},
+				{File: abs + "/fallback/App/Http/Client.php", Rule: RulePsr4Fallback, Dir: abs + "/fallback"},
+				{File: abs + "/lib/App/Http/Client.php", Rule: RulePsr0Fallback, Dir: abs + "/lib"},
+			},
+		},
+		{
+			class: `\App\Http\Mapped`,
+			want: []ClassCandidate{
+				{File: abs + "/classes/Mapped.php", Rule: RuleClassmap, Dir: abs + "/classes", Exists: true},
+				{File: abs + "/vendor/acme/http/src/Mapped.php", Rule: RulePsr4, Prefix: `App\Http\`, Dir: abs + "/vendor/acme/http/src", Package: "acme/http"},
+				{File: abs + "/src/Http/Mapped.php", Rule: RulePsr4, Prefix: `App\`, Dir: abs + "/src"},
+				{File: abs + "/fallback/App/Http/Mapped.php", Rule: RulePsr4Fallback, Dir: abs + "/fallback"},
+				{File: abs + "/lib/App/Http/Mapped.php", Rule: RulePsr0Fallback, Dir: abs + "/lib"},
+			},
+		},
+		{
+			class: "Legacy_Old_Thing",
+			want: []ClassCandidate{
+				{File: abs + "/fallback/Legacy_Old_Thing.php", Rule: RulePsr4Fallback, Dir: abs + "/fallback"},
+				{File: abs + "/legacy/Legacy/Old/Thing.php", Rule: RulePsr0, Prefix: "Legacy_", Dir: abs + "/legacy", Exists: true},
+				{File: abs + "/old/Legacy/Old/Thing.php", Rule: RulePsr0, Prefix: "Leg", Dir: abs + "/old"},
+				{File: abs + "/lib/Legacy/Old/Thing.php", Rule: RulePsr0Fallback, Dir: abs + "/lib"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.class, func(t *testing.T) {
+			if got := r.Resolve(tt.class); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,762 @@
+package composer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RootPackageName is the name of the root package when its manifest does not have one
+const RootPackageName = "__root__"
+
+// Solver computes which package versions to install for a root manifest
+type Solver struct {
+	// Repositories are searched in order, the first one having a package provides all its versions
+	Repositories []PackageRepository
+	// Platform provides php, ext-*, lib-* and the other platform packages, see NewPlatformRepository
+	Platform PackageRepository
+	// NoDev skips the require-dev section of the root manifest
+	NoDev bool
+	// IgnorePlatformReqs ignores all requirements on platform packages
+	IgnorePlatformReqs bool
+
+	// PreferLowest picks the lowest matching versions instead of the highest ones
+	PreferLowest bool
+	// PreferStable picks the most stable versions first, it is also enabled by prefer-stable of the root manifest
+	PreferStable bool
+
+	// Locked are the currently installed packages, e.g. the packages of composer.lock.
+	// They keep their version unless they are allowed to change by Update
+	Locked []*Package
+	// Update lists the locked packages allowed to change, * wildcards are supported.
+	// When it is empty and Locked is set, nothing locked can change
+	Update []string
+	// UpdateWithDependencies also allows the dependencies of the Update packages to change,
+	// except those which are root requirements
+	UpdateWithDependencies bool
+	// UpdateWithAllDependencies also allows the dependencies of the Update packages to change,
+	// including those which are root requirements
+	UpdateWithAllDependencies bool
+}
+
+// Resolution is the set of packages a Solver selected
+type Resolution struct {
+	// Packages required by the root package, sorted by name
+	Packages []*Package
+	// DevPackages only required through require-dev, sorted by name
+	DevPackages []*Package
+}
+
+// SolverProblem explains why requirements cannot be resolved, it lists the rules that contradict each other
+type SolverProblem struct {
+	Reasons []string
+}
+
+// Error render the problem the way Composer does
+func (p *SolverProblem) Error() string {
+	var b strings.Builder
+	b.WriteString("Your requirements could not be resolved to an installable set of packages.\n\n  Problem 1\n")
+	for _, r := range p.Reasons {
+		b.WriteString("    - " + r + "\n")
+	}
+	return b.String()
+}
+
+// Solve resolve the requirements of a root manifest, it returns a *SolverProblem when they cannot be satisfied
+func (s *Solver) Solve(root *Manifest) (*Resolution, error) {
+	st, err := s.newState(root)
+	if err != nil {
+		return nil, err
+	}
+	if conflict := st.solve(); conflict >= 0 {
+		return nil, st.problem(conflict)
+	}
+	return st.resolution(!s.NoDev), nil
+}
+
+// ruleKind tells why a rule exists, it is used to explain problems
+type ruleKind int
+
+const (
+	ruleFixed ruleKind = iota
+	ruleRequire
+	ruleConflict
+	ruleSameName
+	ruleLearned
+)
+
+// rule is a clause: at least one of its literals must be true.
+// A positive literal installs the package with that id, a negative one does not.
+// A ruleSameName rule lists negative literals of which at most one can be false, like the Composer
+// MultiConflictRule, instead of a clause for every pair of packages
+type rule struct {
+	literals []int
+	kind     ruleKind
+	source   *poolPackage
+	link     link
+	why      []int
+}
+
+// state of a CDCL search over the pool
+type state struct {
+	solver *Solver
+	pool   *pool
+	root   *poolPackage
+	rules  []rule
+	occurs map[int][]int
+
+	// stabilities of the root manifest
+	minimumStability string
+	stabilityFlags   map[string]string
+
+	// policy picks the literal to try first among the candidates of a requirement
+	policy *policy
+
+	// fixed are locked packages which are not allowed to change, by name
+	fixed map[string]*Package
+
+	values   []int8
+	levels   []int
+	reasons  []int
+	trail    []int
+	trailLim []int
+	qhead    int
+	level0   map[int][]int
+}
+
+func (s *Solver) newState(root *Manifest) (*state, error) {
+	rp := &Package{Manifest: *root}
+	if rp.Name == "" {
+		rp.Name = RootPackageName
+	}
+	if rp.Version == "" {
+		rp.Version = "1.0.0+no-version-set"
+	}
+	rootPackage, ok := newPoolPackage(rp)
+	if !ok {
+		return nil, fmt.Errorf("invalid root version %s", root.Version)
+	}
+	rootPackage.root = true
+
+	st := &state{
+		solver:           s,
+		pool:             newPool(),
+		root:             rootPackage,
+		occurs:           map[int][]int{},
+		minimumStability: "stable",
+		stabilityFlags:   map[string]string{},
+		policy:           &policy{preferLowest: s.PreferLowest, preferStable: s.PreferStable || bool(root.PreferStable)},
+		level0:           map[int][]int{},
+	}
+	st.fixed = s.fixedPackages(rootPackage)
+	if root.MinimumStability != "" {
+		st.minimumStability = normalizeStabilityName(root.MinimumStability)
+	}
+	for _, m := range []map[string]string{root.Require, root.RequireDev} {
+		for name, constraint := range m {
+			if flag := StabilityFlag(constraint); flag != "" {
+				st.stabilityFlags[strings.ToLower(name)] = flag
+			}
+		}
+	}
+
+	if err := st.load(); err != nil {
+		return nil, err
+	}
+	st.generateRules()
+	return st, nil
+}
+
+// acceptable reports whether a package is stable enough for the root manifest
+func (st *state) acceptable(p *poolPackage) bool {
+	if p.platform || p.root {
+		return true
+	}
+	allowed := Stabilities[st.minimumStability]
+	if flag, ok := st.stabilityFlags[p.name]; ok && Stabilities[flag] > allowed {
+		allowed = Stabilities[flag]
+	}
+	return Stabilities[VersionStability(p.version)] <= allowed
+}
+
+// load fill the pool with every version of every package reachable from the root requirements
+func (st *state) load() error {
+	st.pool.add(st.root)
+	queue := []string{}
+	seen := map[string]bool{}
+	enqueue := func(links []link) {
+		for _, l := range links {
+			if !seen[l.target] {
+				seen[l.target] = true
+				queue = append(queue, l.target)
+			}
+		}
+	}
+	enqueue(st.root.requires(!st.solver.NoDev))
+
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+
+		packages, err := st.find(name)
+		if err != nil {
+			return err
+		}
+		if locked, ok := st.fixed[name]; ok {
+			packages = []*Package{locked}
+		}
+		for _, p := range packages {
+			pp, ok := newPoolPackage(p)
+			if !ok {
+				continue
+			}
+			if !st.acceptable(pp) {
+				st.pool.filtered[pp.name] = append(st.pool.filtered[pp.name], pp)
+				continue
+			}
+			st.pool.add(pp)
+			enqueue(pp.requires(false))
+		}
+	}
+	return nil
+}
+
+// find search the repositories for a package, platform packages only come from the platform repository
+func (st *state) find(name string) ([]*Package, error) {
+	if IsPlatformPackage(name) {
+		if st.solver.IgnorePlatformReqs || st.solver.Platform == nil {
+			return nil, nil
+		}
+		return st.solver.Platform.FindPackages(name)
+	}
+	for _, r := range st.solver.Repositories {
+		packages, err := r.FindPackages(name)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load %s: %w", name, err)
+		}
+		if len(packages) > 0 {
+			return packages, nil
+		}
+	}
+	return nil, nil
+}
+
+func (st *state) addRule(r rule) int {
+	idx := len(st.rules)
+	st.rules = append(st.rules, r)
+	for _, lit := range r.literals {
+		st.occurs[lit] = append(st.occurs[lit], idx)
+	}
+	return idx
+}
+
+// generateRules turn the pool into clauses
+func (st *state) generateRules() {
+	st.addRule(rule{literals: []int{st.root.id}, kind: ruleFixed, source: st.root})
+
+	for _, p := range st.pool.packages {
+		for _, l := range p.requires(!st.solver.NoDev) {
+			if st.solver.IgnorePlatformReqs && IsPlatformPackage(l.target) {
+				continue
+			}
+			literals := []int{-p.id}
+			for _, candidate := range st.pool.whatProvides(l) {
+				if candidate != p {
+					literals = append(literals, candidate.id)
+				}
+			}
+			st.addRule(rule{literals: literals, kind: ruleRequire, source: p, link: l})
+		}
+	}
+
+	for _, p := range st.pool.packages {
+		// like Composer 2 a conflict applies to the packages replacing or providing the name too
+		for _, l := range p.links(p.Conflict) {
+			for _, candidate := range st.pool.whatProvides(l) {
+				if candidate != p {
+					st.addRule(rule{literals: []int{-p.id, -candidate.id}, kind: ruleConflict, source: p, link: l})
+				}
+			}
+		}
+	}
+
+	// a package cannot be installed next to another version of itself or next to a package replacing it
+	names := map[string][]*poolPackage{}
+	var order []string
+	for _, p := range st.pool.packages {
+		pNames := []string{p.name}
+		for _, r := range p.links(p.Replace) {
+			pNames = append(pNames, r.target)
+		}
+		for _, n := range pNames {
+			if _, ok := names[n]; !ok {
+				order = append(order, n)
+			}
+			names[n] = append(names[n], p)
+		}
+	}
+	for _, n := range order {
+		var literals []int
+		seen := map[int]bool{}
+		for _, p := range names[n] {
+			if !seen[p.id] {
+				seen[p.id] = true
+				literals = append(literals, -p.id)
+			}
+		}
+		if len(literals) > 1 {
+			st.addRule(rule{literals: literals, kind: ruleSameName, link: link{target: n}})
+		}
+	}
+}
+
+// clause return the literals of a rule as a clause for the current assignment: a ruleSameName rule becomes
+// the clause of its installed packages, plus the literal it implied when it is the reason of an assignment
+func (st *state) clause(idx int, implied int) []int {
+	r := st.rules[idx]
+	if r.kind != ruleSameName {
+		return r.literals
+	}
+	var literals []int
+	for _, l := range r.literals {
+		if st.value(l) == -1 || l == implied {
+			literals = append(literals, l)
+		}
+	}
+	return literals
+}
+
+// implied return the literal assigned to a variable
+func (st *state) implied(v int) int {
+	if st.values[v] == -1 {
+		return -v
+	}
+	return v
+}
+
+func (st *state) value(lit int) int8 {
+	v := st.values[abs(lit)]
+	if lit < 0 {
+		return -v
+	}
+	return v
+}
+
+func (st *state) assign(lit int, reason int) {
+	v := abs(lit)
+	if lit > 0 {
+		st.values[v] = 1
+	} else {
+		st.values[v] = -1
+	}
+	st.levels[v] = len(st.trailLim)
+	st.reasons[v] = reason
+	st.trail = append(st.trail, lit)
+}
+
+// propagate unit rules, it returns the index of a conflicting rule or -1
+func (st *state) propagate() int {
+	for st.qhead < len(st.trail) {
+		lit := st.trail[st.qhead]
+		st.qhead++
+		for _, idx := range st.occurs[-lit] {
+			r := st.rules[idx]
+			if r.kind == ruleSameName {
+				// the package of lit is installed, the other ones cannot be
+				for _, l := range r.literals {
+					switch {
+					case l == -lit:
+					case st.value(l) == -1:
+						return idx
+					case st.value(l) == 0:
+						st.assign(l, idx)
+					}
+				}
+				continue
+			}
+			unassigned, satisfied := 0, false
+			var last int
+			for _, l := range r.literals {
+				switch st.value(l) {
+				case 1:
+					satisfied = true
+				case 0:
+					unassigned++
+					last = l
+				}
+				if satisfied {
+					break
+				}
+			}
+			switch {
+			case satisfied:
+			case unassigned == 0:
+				return idx
+			case unassigned == 1:
+				st.assign(last, idx)
+			}
+		}
+	}
+	return -1
+}
+
+// propagateUnits assign rules with a single literal at level 0, it returns a conflicting rule or -1
+func (st *state) propagateUnits() int {
+	for idx, r := range st.rules {
+		switch {
+		case len(r.literals) == 0:
+			return idx
+		case len(r.literals) == 1:
+			switch st.value(r.literals[0]) {
+			case -1:
+				return idx
+			case 0:
+				st.assign(r.literals[0], idx)
+			}
+		}
+	}
+	return st.propagate()
+}
+
+// solve run the search, it returns the index of a rule conflicting at level 0 or -1 when a solution was found
+func (st *state) solve() int {
+	n := len(st.pool.packages) + 1
+	st.values = make([]int8, n)
+	st.levels = make([]int, n)
+	st.reasons = make([]int, n)
+
+	if conflict := st.propagateUnits(); conflict >= 0 {
+		return conflict
+	}
+	for {
+		if conflict := st.propagate(); conflict >= 0 {
+			if len(st.trailLim) == 0 {
+				return conflict
+			}
+			learned, why, backjump := st.analyze(conflict)
+			st.backtrack(backjump)
+			idx := st.addRule(rule{literals: learned, kind: ruleLearned, why: why})
+			st.assign(learned[0], idx)
+			continue
+		}
+
+		lit := st.decide()
+		if lit == 0 {
+			return -1
+		}
+		st.trailLim = append(st.trailLim, len(st.trail))
+		st.assign(lit, -1)
+	}
+}
+
+// decide pick the preferred candidate of the first requirement of an installed package which is not satisfied yet
+func (st *state) decide() int {
+	for _, r := range st.rules {
+		if r.kind != ruleRequire || st.value(r.literals[0]) != -1 {
+			continue
+		}
+		var candidates []*poolPackage
+		satisfied := false
+		for _, l := range r.literals[1:] {
+			switch st.value(l) {
+			case 1:
+				satisfied = true
+			case 0:
+				candidates = append(candidates, st.pool.get(l))
+			}
+		}
+		if satisfied || len(candidates) == 0 {
+			continue
+		}
+		return st.policy.prefer(candidates, r.link.target).id
+	}
+	return 0
+}
+
+// analyze a conflict and learn the first unique implication point clause
+func (st *state) analyze(conflict int) ([]int, []int, int) {
+	current := len(st.trailLim)
+	seen := map[int]bool{}
+	why := map[int]bool{}
+	learned := []int{0}
+	counter := 0
+	p := 0
+	idx := len(st.trail) - 1
+	reason := conflict
+
+	for {
+		for _, w := range st.why(reason) {
+			why[w] = true
+		}
+		for _, q := range st.clause(reason, p) {
+			v := abs(q)
+			if q == p || seen[v] {
+				continue
+			}
+			seen[v] = true
+			switch {
+			case st.levels[v] == current:
+				counter++
+			case st.levels[v] > 0:
+				learned = append(learned, q)
+			default:
+				for _, w := range st.level0Why(v) {
+					why[w] = true
+				}
+			}
+		}
+		for !seen[abs(st.trail[idx])] {
+			idx--
+		}
+		p = st.trail[idx]
+		idx--
+		counter--
+		if counter <= 0 {
+			break
+		}
+		reason = st.reasons[abs(p)]
+	}
+	learned[0] = -p
+
+	backjump := 0
+	for _, l := range learned[1:] {
+		if lv := st.levels[abs(l)]; lv > backjump {
+			backjump = lv
+		}
+	}
+	return learned, sortedSet(why), backjump
+}
+
+// why return the original rules a rule was derived from
+func (st *state) why(idx int) []int {
+	if st.rules[idx].kind == ruleLearned {
+		return st.rules[idx].why
+	}
+	return []int{idx}
+}
+
+// level0Why return the original rules which forced a variable at level 0
+func (st *state) level0Why(v int) []int {
+	if w, ok := st.level0[v]; ok {
+		return w
+	}
+	st.level0[v] = nil
+	why := map[int]bool{}
+	reason := st.reasons[v]
+	for _, w := range st.why(reason) {
+		why[w] = true
+	}
+	for _, l := range st.clause(reason, st.implied(v)) {
+		if abs(l) != v {
+			for _, w := range st.level0Why(abs(l)) {
+				why[w] = true
+			}
+		}
+	}
+	st.level0[v] = sortedSet(why)
+	return st.level0[v]
+}
+
+func (st *state) backtrack(level int) {
+	if level >= len(st.trailLim) {
+		return
+	}
+	for i := len(st.trail) - 1; i >= st.trailLim[level]; i-- {
+		v := abs(st.trail[i])
+		st.values[v] = 0
+		st.reasons[v] = 0
+	}
+	st.trail = st.trail[:st.trailLim[level]]
+	st.trailLim = st.trailLim[:level]
+	st.qhead = len(st.trail)
+}
+
+// resolution collect the installed packages and split them into regular and dev packages
+func (st *state) resolution(dev bool) *Resolution {
+	installed := map[string]*poolPackage{}
+	for _, p := range st.pool.packages {
+		if st.values[p.id] == 1 && !p.root && !p.platform {
+			installed[p.name] = p
+		}
+	}
+
+	// packages reachable from require without require-dev are regular packages
+	regular := map[string]bool{}
+	queue := st.root.links(st.root.Require)
+	for len(queue) > 0 {
+		l := queue[0]
+		queue = queue[1:]
+		for _, p := range installed {
+			if regular[p.name] {
+				continue
+			}
+			if p.name == l.target || p.provides(l.target) {
+				regular[p.name] = true
+				queue = append(queue, p.links(p.Require)...)
+			}
+		}
+	}
+
+	res := &Resolution{}
+	for _, name := range sortedPoolNames(installed) {
+		if regular[name] || !dev {
+			res.Packages = append(res.Packages, installed[name].Package)
+		} else {
+			res.DevPackages = append(res.DevPackages, installed[name].Package)
+		}
+	}
+	return res
+}
+
+// provides reports whether a package replaces or provides a name
+func (p *poolPackage) provides(name string) bool {
+	for _, m := range []map[string]string{p.Replace, p.Provide} {
+		for n := range m {
+			if strings.ToLower(n) == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// problem explain a conflict found at level 0 with the original rules involved
+func (st *state) problem(conflict int) *SolverProblem {
+	why := map[int]bool{}
+	for _, w := range st.why(conflict) {
+		why[w] = true
+	}
+	for _, l := range st.clause(conflict, 0) {
+		if st.values[abs(l)] != 0 {
+			for _, w := range st.level0Why(abs(l)) {
+				why[w] = true
+			}
+		}
+	}
+
+	problem := &SolverProblem{}
+	seen := map[string]bool{}
+	sameName := map[string][]*poolPackage{}
+	var sameNameOrder []string
+	for _, idx := range sortedSet(why) {
+		r := st.rules[idx]
+		if r.kind == ruleSameName {
+			if _, ok := sameName[r.link.target]; !ok {
+				sameNameOrder = append(sameNameOrder, r.link.target)
+			}
+			for _, l := range r.literals {
+				sameName[r.link.target] = appendUnique(sameName[r.link.target], st.pool.get(abs(l)))
+			}
+			continue
+		}
+		if reason := st.explain(r); reason != "" && !seen[reason] {
+			seen[reason] = true
+			problem.Reasons = append(problem.Reasons, reason)
+		}
+	}
+	for _, name := range sameNameOrder {
+		problem.Reasons = append(problem.Reasons, "Only one of these can be installed: "+formatPackages(sameName[name])+".")
+	}
+	return problem
+}
+
+// explain render a rule as a sentence
+func (st *state) explain(r rule) string {
+	switch r.kind {
+	case ruleRequire:
+		prefix := r.source.String() + " requires " + r.link.target + " " + r.link.pretty
+		var candidates []*poolPackage
+		for _, l := range r.literals[1:] {
+			candidates = append(candidates, st.pool.get(l))
+		}
+		if len(candidates) > 0 {
+			return prefix + " -> satisfiable by " + formatPackages(candidates) + "."
+		}
+		return prefix + " -> " + st.missing(r.link) + "."
+	case ruleConflict:
+		other := st.pool.get(abs(r.literals[1]))
+		return r.source.String() + " conflicts with " + other.String() + "."
+	}
+	return ""
+}
+
+// missing explain why no package satisfies a link
+func (st *state) missing(l link) string {
+	if IsPlatformPackage(l.target) {
+		if installed := st.pool.byName[l.target]; len(installed) > 0 {
+			return "your " + l.target + " version (" + installed[0].Version + ") does not satisfy that requirement"
+		}
+		if strings.HasPrefix(l.target, "ext-") {
+			return "it is missing from your system. Install or enable PHP's " + strings.TrimPrefix(l.target, "ext-") + " extension"
+		}
+		return "it is missing from your system"
+	}
+
+	var filtered []*poolPackage
+	for _, p := range st.pool.filtered[l.target] {
+		if p.matches(l.constraint) {
+			filtered = append(filtered, p)
+		}
+	}
+	if len(filtered) > 0 {
+		return "found " + formatPackages(filtered) + " but it does not match your minimum-stability"
+	}
+	if locked, ok := st.fixed[l.target]; ok {
+		return l.target + " is locked to version " + locked.Version + " and an update of this package was not requested"
+	}
+	if others := st.pool.byName[l.target]; len(others) > 0 {
+		return "found " + formatPackages(others) + " but it does not match the constraint"
+	}
+	return "no matching package found"
+}
+
+// formatPackages render packages grouped by name, e.g. "a/b[1.0.0, 1.1.0], c/d[2.0.0]"
+func formatPackages(packages []*poolPackage) string {
+	versions := map[string][]string{}
+	var names []string
+	for _, p := range packages {
+		if _, ok := versions[p.name]; !ok {
+			names = append(names, p.name)
+		}
+		versions[p.name] = append(versions[p.name], p.Version)
+	}
+	parts := make([]string, len(names))
+	for i, n := range names {
+		parts[i] = n + "[" + strings.Join(versions[n], ", ") + "]"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func appendUnique(packages []*poolPackage, p *poolPackage) []*poolPackage {
+	for _, existing := range packages {
+		if existing == p {
+			return packages
+		}
+	}
+	return append(packages, p)
+}
+
+func sortedPoolNames(m map[string]*poolPackage) []string {
+	names := make([]string, 0, len(m))
+	for n := range m {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedSet(m map[int]bool) []int {
+	s := make([]int, 0, len(m))
+	for k := range m {
+		s = append(s, k)
+	}
+	sort.Ints(s)
+	return s
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
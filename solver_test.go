@@ -0,0 +1,197 @@
+package composer
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testPackage build a package version, links are given as "require:name": "constraint"
+func testPackage(name, version string, links map[string]string) *Package {
+	p := &Package{Manifest: Manifest{Name: name, Version: version}}
+	for k, v := range links {
+		kind := strings.SplitN(k, ":", 2)
+		var m *map[string]string
+		switch kind[0] {
+		case "require":
+			m = &p.Require
+		case "conflict":
+			m = &p.Conflict
+		case "replace":
+			m = &p.Replace
+		case "provide":
+			m = &p.Provide
+		}
+		if *m == nil {
+			*m = map[string]string{}
+		}
+		(*m)[kind[1]] = v
+	}
+	return p
+}
+
+func resolvedVersions(packages []*Package) []string {
+	versions := make([]string, len(packages))
+	for i, p := range packages {
+		versions[i] = p.Name + " " + p.Version
+	}
+	return versions
+}
+
+func TestSolver_Solve(t *testing.T) {
+	repo := NewArrayRepository(
+		testPackage("a/a", "1.0.0", map[string]string{"require:c/c": "^1.0"}),
+		testPackage("a/a", "2.0.0", map[string]string{"require:c/c": "^2.0"}),
+		testPackage("b/b", "1.0.0", map[string]string{"require:c/c": "^1.0"}),
+		testPackage("b/b", "1.1.0", map[string]string{"require:c/c": "^1.0", "conflict:d/d": "^2.0"}),
+		testPackage("c/c", "1.0.0", nil),
+		testPackage("c/c", "1.2.0", nil),
+		testPackage("c/c", "2.0.0", nil),
+		testPackage("d/d", "1.0.0", nil),
+		testPackage("d/d", "2.0.0", nil),
+		testPackage("d/d", "dev-main", nil),
+		testPackage("d/d", "3.0.0-beta1", nil),
+		testPackage("monolog/monolog", "2.0.0", map[string]string{"provide:psr/log-implementation": "1.0.0", "require:php": ">=7.2"}),
+		testPackage("laminas/laminas-zendframework-bridge", "1.0.0", map[string]string{"replace:zendframework/zend-stdlib": "^3.2"}),
+		testPackage("zendframework/zend-stdlib", "3.2.1", nil),
+		testPackage("e/e", "1.0.0", map[string]string{"require:php": "^8.0"}),
+		testPackage("f/f", "0.9.0", nil),
+		testPackage("f/f", "1.0.0", map[string]string{"conflict:psr/log-implementation": "^1.0"}),
+	)
+	platform := NewPlatformRepository(map[string]string{"php": "7.4.3", "ext-json": "7.4.3"})
+
+	tests := []struct {
+		name     string
+		root     Manifest
+		noDev    bool
+		want     []string
+		wantDev  []string
+		problems []string
+	}{
+		{"highest versions", Manifest{Require: map[string]string{"a/a": "*"}}, false,
+			[]string{"a/a 2.0.0", "c/c 2.0.0"}, nil, nil},
+		{"backtracks on shared dependency", Manifest{Require: map[string]string{"a/a": "*", "b/b": "1.0.0"}}, false,
+			[]string{"a/a 1.0.0", "b/b 1.0.0", "c/c 1.2.0"}, nil, nil},
+		{"conflict lowers version", Manifest{Require: map[string]string{"b/b": "^1.0", "d/d": "^2.0"}}, false,
+			[]string{"b/b 1.0.0", "c/c 1.2.0", "d/d 2.0.0"}, nil, nil},
+		{"provide", Manifest{Require: map[string]string{"monolog/monolog": "^2.0", "psr/log-implementation": "^1.0"}}, false,
+			[]string{"monolog/monolog 2.0.0"}, nil, nil},
+		{"conflict with a provided name", Manifest{Require: map[string]string{"monolog/monolog": "^2.0", "f/f": "*"}}, false,
+			[]string{"f/f 0.9.0", "monolog/monolog 2.0.0"}, nil, nil},
+		{"conflicting provided name", Manifest{Require: map[string]string{"monolog/monolog": "^2.0", "f/f": "^1.0"}}, false, nil, nil, []string{
+			"Root composer.json requires f/f ^1.0 -> satisfiable by f/f[1.0.0].",
+			"Root composer.json requires monolog/monolog ^2.0 -> satisfiable by monolog/monolog[2.0.0].",
+			"f/f 1.0.0 conflicts with monolog/monolog 2.0.0.",
+		}},
+		{"replace", Manifest{Require: map[string]string{"laminas/laminas-zendframework-bridge": "^1.0", "zendframework/zend-stdlib": "^3.2"}}, false,
+			[]string{"laminas/laminas-zendframework-bridge 1.0.0"}, nil, nil},
+		{"stability flag", Manifest{Require: map[string]string{"d/d": "dev-main"}}, false,
+			[]string{"d/d dev-main"}, nil, nil},
+		{"require-dev", Manifest{Require: map[string]string{"b/b": "1.0.0"}, RequireDev: map[string]string{"d/d": "^1.0"}}, false,
+			[]string{"b/b 1.0.0", "c/c 1.2.0"}, []string{"d/d 1.0.0"}, nil},
+		{"no-dev", Manifest{Require: map[string]string{"b/b": "1.0.0"}, RequireDev: map[string]string{"d/d": "^1.0"}}, true,
+			[]string{"b/b 1.0.0", "c/c 1.2.0"}, nil, nil},
+		{"platform", Manifest{Require: map[string]string{"php": "^7.4", "ext-json": "*"}}, false,
+			nil, nil, nil},
+		{"incompatible dependencies", Manifest{Require: map[string]string{"a/a": "^2.0", "b/b": "^1.0"}}, false, nil, nil, []string{
+			"Root composer.json requires a/a ^2.0 -> satisfiable by a/a[2.0.0].",
+			"Root composer.json requires b/b ^1.0 -> satisfiable by b/b[1.0.0, 1.1.0].",
+			"a/a 2.0.0 requires c/c ^2.0 -> satisfiable by c/c[2.0.0].",
+			"b/b 1.0.0 requires c/c ^1.0 -> satisfiable by c/c[1.0.0, 1.2.0].",
+			"b/b 1.1.0 requires c/c ^1.0 -> satisfiable by c/c[1.0.0, 1.2.0].",
+			"Only one of these can be installed: c/c[1.0.0, 1.2.0, 2.0.0].",
+		}},
+		{"missing package", Manifest{Require: map[string]string{"x/x": "^1.0"}}, false, nil, nil, []string{
+			"Root composer.json requires x/x ^1.0 -> no matching package found.",
+		}},
+		{"minimum-stability", Manifest{Require: map[string]string{"d/d": "dev-main as 3.0.0"}, MinimumStability: "stable"}, false,
+			[]string{"d/d dev-main"}, nil, nil},
+		{"unstable version", Manifest{Require: map[string]string{"d/d": ">=3.0"}}, false, nil, nil, []string{
+			"Root composer.json requires d/d >=3.0 -> found d/d[3.0.0-beta1] but it does not match your minimum-stability.",
+		}},
+		{"platform version", Manifest{Require: map[string]string{"e/e": "^1.0"}}, false, nil, nil, []string{
+			"Root composer.json requires e/e ^1.0 -> satisfiable by e/e[1.0.0].",
+			"e/e 1.0.0 requires php ^8.0 -> your php version (7.4.3) does not satisfy that requirement.",
+		}},
+		{"missing extension", Manifest{Require: map[string]string{"ext-intl": "*"}}, false, nil, nil, []string{
+			"Root composer.json requires ext-intl * -> it is missing from your system. Install or enable PHP's intl extension.",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Solver{Repositories: []PackageRepository{repo}, Platform: platform, NoDev: tt.noDev}
+			got, err := s.Solve(&tt.root)
+			var problem *SolverProblem
+			if errors.As(err, &problem) {
+				if !reflect.DeepEqual(problem.Reasons, tt.problems) {
+					t.Errorf("Solve() problem = %q, want %q", problem.Reasons, tt.problems)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Solve() error = %v", err)
+			}
+			if tt.problems != nil {
+				t.Fatalf("Solve() got %v, want problems %q", resolvedVersions(got.Packages), tt.problems)
+			}
+			if got := resolvedVersions(got.Packages); !reflect.DeepEqual(got, append([]string{}, tt.want...)) {
+				t.Errorf("Solve() packages = %v, want %v", got, tt.want)
+			}
+			if got := resolvedVersions(got.DevPackages); !reflect.DeepEqual(got, append([]string{}, tt.wantDev...)) {
+				t.Errorf("Solve() dev packages = %v, want %v", got, tt.wantDev)
+			}
+		})
+	}
+}
+
+func TestSolver_IgnorePlatformReqs(t *testing.T) {
+	repo := NewArrayRepository(testPackage("e/e", "1.0.0", map[string]string{"require:php": "^8.0", "require:ext-intl": "*"}))
+	s := &Solver{Repositories: []PackageRepository{repo}, IgnorePlatformReqs: true}
+	got, err := s.Solve(&Manifest{Require: map[string]string{"e/e": "^1.0"}})
+	if err != nil {
+		t.Fatalf("Solve() error = %v", err)
+	}
+	if want := []string{"e/e 1.0.0"}; !reflect.DeepEqual(resolvedVersions(got.Packages), want) {
+		t.Errorf("Solve() packages = %v, want %v", resolvedVersions(got.Packages), want)
+	}
+}
+
+func TestSolver_sameNameRules(t *testing.T) {
+	var packages []*Package
+	for i := 0; i < 500; i++ {
+		packages = append(packages, testPackage("c/c", fmt.Sprintf("1.%d.0", i), nil))
+	}
+	packages = append(packages,
+		testPackage("a/a", "1.0.0", map[string]string{"require:c/c": "<1.100"}),
+		testPackage("b/b", "1.0.0", map[string]string{"require:c/c": ">=1.50", "replace:c/c": "1.0.0"}),
+	)
+	s := &Solver{Repositories: []PackageRepository{NewArrayRepository(packages...)}}
+	st, err := s.newState(&Manifest{Require: map[string]string{"a/a": "*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameName := 0
+	for _, r := range st.rules {
+		if r.kind == ruleSameName {
+			sameName++
+		}
+	}
+	if sameName != 1 {
+		t.Errorf("generateRules() got %d same name rules, want 1", sameName)
+	}
+
+	got, err := s.Solve(&Manifest{Require: map[string]string{"a/a": "*"}})
+	if err != nil {
+		t.Fatalf("Solve() error = %v", err)
+	}
+	if want := []string{"a/a 1.0.0", "c/c 1.99.0"}; !reflect.DeepEqual(resolvedVersions(got.Packages), want) {
+		t.Errorf("Solve() packages = %v, want %v", resolvedVersions(got.Packages), want)
+	}
+	_, err = s.Solve(&Manifest{Require: map[string]string{"a/a": "*", "b/b": "*"}})
+	var problem *SolverProblem
+	if !errors.As(err, &problem) {
+		t.Fatalf("Solve() error = %v, want a problem", err)
+	}
+}
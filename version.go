@@ -0,0 +1,253 @@
+package composer
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Stabilities ordered from the most to the least stable, the same way Composer weighs them
+var Stabilities = map[string]int{
+	"stable": 0,
+	"RC":     5,
+	"beta":   10,
+	"alpha":  15,
+	"dev":    20,
+}
+
+const modifierRegex = `[._-]?(?:(stable|beta|b|RC|alpha|a|patch|pl|p)((?:[.-]?\d+)*)?)?([.-]?dev)?`
+
+var (
+	aliasRegex          = regexp.MustCompile(`^([^,\s]+) +as +([^,\s]+)$`)
+	stabilityFlagRegex  = regexp.MustCompile(`(?i)@(stable|RC|beta|alpha|dev)$`)
+	buildMetadataRegex  = regexp.MustCompile(`^([^,\s+]+)\+\S+$`)
+	classicVersionRegex = regexp.MustCompile(`(?i)^v?(\d{1,5})(\.\d+)?(\.\d+)?(\.\d+)?` + modifierRegex + `$`)
+	dateVersionRegex    = regexp.MustCompile(`(?i)^v?(\d{4}(?:[.:-]?\d{2}){1,6}(?:[.:-]?\d{1,3})?)` + modifierRegex + `$`)
+	devSuffixRegex      = regexp.MustCompile(`(?i)^(.*?)[.-]?dev$`)
+	branchRegex         = regexp.MustCompile(`(?i)^v?(\d+)(\.(?:\d+|[xX*]))?(\.(?:\d+|[xX*]))?(\.(?:\d+|[xX*]))?$`)
+	stabilityRegex      = regexp.MustCompile(`(?i)` + modifierRegex + `(?:\+.*)?$`)
+	nonDigitRegex       = regexp.MustCompile(`\D`)
+)
+
+// NormalizeVersion convert a version into the normalized form Composer compares, e.g. "v1.2" into "1.2.0.0"
+// Branches become "dev-name" and numeric branches like "2.x-dev" become "2.9999999.9999999.9999999-dev"
+func NormalizeVersion(version string) (string, error) {
+	version = strings.TrimSpace(version)
+	orig := version
+
+	if m := aliasRegex.FindStringSubmatch(version); m != nil {
+		version = m[1]
+	}
+	if m := stabilityFlagRegex.FindString(version); m != "" {
+		version = version[:len(version)-len(m)]
+	}
+	switch version {
+	case "master", "trunk", "default":
+		version = "dev-" + version
+	}
+	if len(version) >= 4 && strings.EqualFold(version[:4], "dev-") {
+		return "dev-" + version[4:], nil
+	}
+	if m := buildMetadataRegex.FindStringSubmatch(version); m != nil {
+		version = m[1]
+	}
+
+	var m []string
+	var index int
+	if m = classicVersionRegex.FindStringSubmatch(version); m != nil {
+		version = m[1]
+		for i := 2; i <= 4; i++ {
+			if m[i] != "" {
+				version += m[i]
+			} else {
+				version += ".0"
+			}
+		}
+		index = 5
+	} else if m = dateVersionRegex.FindStringSubmatch(version); m != nil {
+		version = nonDigitRegex.ReplaceAllString(m[1], ".")
+		index = 2
+	}
+
+	if m != nil {
+		if m[index] != "" {
+			if m[index] == "stable" {
+				return version, nil
+			}
+			version += "-" + expandStability(m[index]) + strings.TrimLeft(m[index+1], ".-")
+		}
+		if m[index+2] != "" {
+			version += "-dev"
+		}
+		return version, nil
+	}
+
+	if m := devSuffixRegex.FindStringSubmatch(version); m != nil {
+		if normalized := normalizeBranch(m[1]); !strings.HasPrefix(normalized, "dev-") {
+			return normalized, nil
+		}
+	}
+
+	return "", errors.New("invalid version string \"" + orig + "\"")
+}
+
+// normalizeBranch convert a branch name into a version when it is numeric, e.g. "2.1.x" into "2.1.9999999.9999999-dev"
+func normalizeBranch(name string) string {
+	name = strings.TrimSpace(name)
+	m := branchRegex.FindStringSubmatch(name)
+	if m == nil {
+		return "dev-" + name
+	}
+	version := ""
+	for i := 1; i < 5; i++ {
+		if m[i] != "" {
+			version += strings.NewReplacer("*", "x", "X", "x").Replace(m[i])
+		} else {
+			version += ".x"
+		}
+	}
+	return strings.Replace(version, "x", "9999999", -1) + "-dev"
+}
+
+func expandStability(stability string) string {
+	switch strings.ToLower(stability) {
+	case "a":
+		return "alpha"
+	case "b":
+		return "beta"
+	case "p", "pl":
+		return "patch"
+	case "rc":
+		return "RC"
+	}
+	return strings.ToLower(stability)
+}
+
+// VersionStability return one of "stable", "RC", "beta", "alpha" or "dev" for a version
+func VersionStability(version string) string {
+	if i := strings.Index(version, "#"); i >= 0 {
+		version = version[:i]
+	}
+	if strings.HasPrefix(version, "dev-") || strings.HasSuffix(version, "-dev") {
+		return "dev"
+	}
+	m := stabilityRegex.FindStringSubmatch(strings.ToLower(version))
+	if m == nil {
+		return "stable"
+	}
+	if m[3] != "" {
+		return "dev"
+	}
+	switch m[1] {
+	case "beta", "b":
+		return "beta"
+	case "alpha", "a":
+		return "alpha"
+	case "rc":
+		return "RC"
+	}
+	return "stable"
+}
+
+// CompareVersions compare two normalized versions the way PHP version_compare does.
+// It returns -1, 0 or 1. Branches starting with "dev-" only equal themselves and sort before anything else
+func CompareVersions(a, b string) int {
+	aBranch, bBranch := strings.HasPrefix(a, "dev-"), strings.HasPrefix(b, "dev-")
+	switch {
+	case aBranch && bBranch:
+		return strings.Compare(a, b)
+	case aBranch:
+		return -1
+	case bBranch:
+		return 1
+	}
+
+	pa, pb := canonicalVersion(a), canonicalVersion(b)
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		if c := compareVersionParts(pa[i], pb[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(pa) > len(pb):
+		return compareTrailingPart(pa[len(pb)])
+	case len(pb) > len(pa):
+		return -compareTrailingPart(pb[len(pa)])
+	}
+	return 0
+}
+
+// canonicalVersion split a version like PHP: separators become dots and a dot is inserted between digits and letters
+func canonicalVersion(v string) []string {
+	var parts []string
+	var cur strings.Builder
+	flush := func() {
+		if cur.Len() > 0 {
+			parts = append(parts, cur.String())
+			cur.Reset()
+		}
+	}
+	prevDigit, prevSet := false, false
+	for _, r := range v {
+		if r == '.' || r == '-' || r == '_' || r == '+' {
+			flush()
+			prevSet = false
+			continue
+		}
+		digit := r >= '0' && r <= '9'
+		if prevSet && digit != prevDigit {
+			flush()
+		}
+		cur.WriteRune(r)
+		prevDigit, prevSet = digit, true
+	}
+	flush()
+	return parts
+}
+
+func compareVersionParts(a, b string) int {
+	ai, aErr := strconv.Atoi(a)
+	bi, bErr := strconv.Atoi(b)
+	switch {
+	case aErr == nil && bErr == nil:
+		return compareInts(ai, bi)
+	case aErr == nil:
+		return compareInts(specialFormOrder("#"), specialFormOrder(b))
+	case bErr == nil:
+		return compareInts(specialFormOrder(a), specialFormOrder("#"))
+	}
+	return compareInts(specialFormOrder(a), specialFormOrder(b))
+}
+
+// compareTrailingPart compare the extra part of the longer version with a missing part
+func compareTrailingPart(part string) int {
+	if _, err := strconv.Atoi(part); err == nil {
+		return 1
+	}
+	return compareInts(specialFormOrder(part), specialFormOrder("#"))
+}
+
+// specialFormOrder weigh the special forms of PHP version_compare, matched by prefix the same way
+func specialFormOrder(form string) int {
+	forms := []struct {
+		name  string
+		order int
+	}{{"dev", 0}, {"alpha", 1}, {"a", 1}, {"beta", 2}, {"b", 2}, {"RC", 3}, {"rc", 3}, {"#", 4}, {"pl", 5}, {"p", 5}}
+	for _, f := range forms {
+		if strings.HasPrefix(form, f.name) {
+			return f.order
+		}
+	}
+	return -6
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,113 @@
+package composer
+
+import "testing"
+
+func TestNormalizeVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		want    string
+		wantErr bool
+	}{
+		{"none", "1.0.0", "1.0.0.0", false},
+		{"none/2", "1.2.3.4", "1.2.3.4", false},
+		{"parses state", "1.0.0RC1dev", "1.0.0.0-RC1-dev", false},
+		{"CI parsing", "1.0.0-rC15-dev", "1.0.0.0-RC15-dev", false},
+		{"delimiters", "1.0.0.RC.15-dev", "1.0.0.0-RC15-dev", false},
+		{"RC uppercase", "1.0.0-rc1", "1.0.0.0-RC1", false},
+		{"patch replace", "1.0.0.pl3-dev", "1.0.0.0-patch3-dev", false},
+		{"forces w.x.y.z", "1.0-dev", "1.0.0.0-dev", false},
+		{"forces w.x.y.z/2", "0", "0.0.0.0", false},
+		{"parses long", "10.4.13-beta", "10.4.13.0-beta", false},
+		{"parses long/2", "10.4.13beta2", "10.4.13.0-beta2", false},
+		{"expand shorthand", "10.4.13-b", "10.4.13.0-beta", false},
+		{"strips leading v", "v1.0.0", "1.0.0.0", false},
+		{"parses dates w/ -", "2010-01-02", "2010.01.02", false},
+		{"parses numbers", "2010-01-02.5", "2010.01.02.5", false},
+		{"parses dt+number", "20100102-203040-p1", "20100102.203040-patch1", false},
+		{"parses master", "dev-master", "dev-master", false},
+		{"parses trunk", "dev-trunk", "dev-trunk", false},
+		{"parses branches", "1.x-dev", "1.9999999.9999999.9999999-dev", false},
+		{"parses arbitrary", "dev-feature-foo", "dev-feature-foo", false},
+		{"parses arbitrary/2", "DEV-FOOBAR", "dev-FOOBAR", false},
+		{"ignores aliases", "dev-master as 1.0.0", "dev-master", false},
+		{"master without prefix", "master", "dev-master", false},
+		{"strips stability flag", "1.0.0@dev", "1.0.0.0", false},
+		{"semver metadata", "1.0.0+foo", "1.0.0.0", false},
+		{"invalid", "foo bar", "", true},
+		{"invalid chars", "1.0.0-meh", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeVersion(tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeVersion() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionStability(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{"1", "stable"},
+		{"1.0", "stable"},
+		{"3.2.1", "stable"},
+		{"v3.2.1", "stable"},
+		{"v2.0.x-dev", "dev"},
+		{"v2.0.x-dev#abc123", "dev"},
+		{"v2.0.x-dev#trunk/@123", "dev"},
+		{"3.0-RC2", "RC"},
+		{"dev-master", "dev"},
+		{"3.1.2-dev", "dev"},
+		{"3.1.2-p1", "stable"},
+		{"3.1.2-pl2", "stable"},
+		{"3.1.2-patch", "stable"},
+		{"3.1.2-alpha5", "alpha"},
+		{"3.1.2-beta", "beta"},
+		{"2.0B1", "beta"},
+		{"1.2.0a1", "alpha"},
+		{"1.2_a1", "alpha"},
+		{"2.0.0rc1", "RC"},
+		{"1.0.0-alpha11+cs-1.1.0", "alpha"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			if got := VersionStability(tt.version); got != tt.want {
+				t.Errorf("VersionStability() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0.0", "1.0.0.0", 0},
+		{"1.0.0.0", "1.0.0.1", -1},
+		{"1.10.0.0", "1.9.0.0", 1},
+		{"1.0.0.0-dev", "1.0.0.0-alpha1", -1},
+		{"1.0.0.0-alpha1", "1.0.0.0-beta1", -1},
+		{"1.0.0.0-beta2", "1.0.0.0-RC1", -1},
+		{"1.0.0.0-RC1", "1.0.0.0", -1},
+		{"1.0.0.0", "1.0.0.0-patch1", -1},
+		{"1.0.0.0-beta10", "1.0.0.0-beta9", 1},
+		{"2.9999999.9999999.9999999-dev", "2.1.0.0", 1},
+		{"dev-master", "1.0.0.0", -1},
+		{"dev-master", "dev-master", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			if got := CompareVersions(tt.a, tt.b); got != tt.want {
+				t.Errorf("CompareVersions() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}